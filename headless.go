@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"fyne.io/fyne/v2/app"
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/cangw"
+	"github.com/roffe/txlogger/pkg/common"
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/presets"
+	"github.com/roffe/txlogger/pkg/widgets/settings"
+)
+
+var (
+	headless        = flag.Bool("headless", false, "log without starting the GUI")
+	headlessECU     = flag.String("ecu", "T7", "headless: ECU type, T5, T7 or T8")
+	headlessAdapter = flag.String("adapter", "", "headless: CANbus adapter name, use -adapters to list available adapters")
+	headlessPort    = flag.String("port", "", "headless: serial port for adapters that requires it")
+	headlessBaud    = flag.Int("baud", 1000000, "headless: serial port speed")
+	headlessPreset  = flag.String("preset", "", "headless: symbol preset, defaults to \"<ecu> Dash\"")
+	headlessBin     = flag.String("bin", "", "headless: sync preset symbols against this bin")
+	headlessRate    = flag.Int("rate", 25, "headless: logging rate in Hz")
+	headlessFormat  = flag.String("format", "CSV", "headless: log format, CSV, TXL or TXB")
+	headlessOutput  = flag.String("output", "", "headless: log output directory")
+	headlessDebug   = flag.Bool("candebug", false, "headless: enable adapter debug output")
+	listAdapters    = flag.Bool("adapters", false, "list available CANbus adapters and exit")
+)
+
+// runHeadless logs to file without a display, status and counters are written to stdout.
+// SIGINT/SIGTERM stops logging and closes the log file
+func runHeadless() error {
+	if *listAdapters {
+		for _, a := range gocan.ListAdapters() {
+			fmt.Printf("%-30s serial port: %t\n", a.Name, a.RequiresSerialPort)
+		}
+		return nil
+	}
+
+	ecuType := strings.ToUpper(*headlessECU)
+	switch ecuType {
+	case "T5", "T7", "T8":
+	default:
+		return fmt.Errorf("unsupported ECU: %s", *headlessECU)
+	}
+
+	if *headlessAdapter == "" {
+		return errors.New("no adapter specified, use -adapter")
+	}
+
+	symbols, err := headlessSymbols(ecuType)
+	if err != nil {
+		return err
+	}
+
+	logPath := *headlessOutput
+	if logPath == "" {
+		logPath, err = common.GetLogPath()
+		if err != nil {
+			return err
+		}
+	}
+
+	if strings.HasPrefix(*headlessAdapter, "J2534") {
+		p, err := cangw.Start()
+		if p != nil {
+			defer killProcess(p)
+		}
+		if err != nil {
+			return fmt.Errorf("cangateway is not ready: %w", err)
+		}
+	}
+
+	device, err := settings.NewAdapter(ecuType, *headlessAdapter, *headlessPort, *headlessBaud, *headlessDebug, headlessMessage)
+	if err != nil {
+		return err
+	}
+
+	var prefix string
+	if *headlessBin != "" {
+		prefix = strings.TrimSuffix(filepath.Base(*headlessBin), filepath.Ext(*headlessBin))
+	}
+
+	var captured, errs, fps atomic.Int64
+
+	dlc, filename, err := datalogger.New(datalogger.Config{
+		FilenamePrefix: prefix,
+		ECU:            ecuType,
+		Device:         device,
+		Symbols:        symbols,
+		Rate:           *headlessRate,
+		OnMessage:      headlessMessage,
+		CaptureCounter: func(i int) { captured.Store(int64(i)) },
+		ErrorCounter:   func(i int) { errs.Store(int64(i)) },
+		FpsCounter:     func(i int) { fps.Store(int64(i)) },
+		LogFormat:      strings.ToUpper(*headlessFormat),
+		LogPath:        logPath,
+		WidebandConfig: datalogger.WidebandConfig{
+			Type: "None",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- dlc.Start()
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case s := <-sig:
+			headlessMessage("caught " + s.String() + ", closing " + filename)
+			dlc.Close()
+			err := <-errChan
+			headlessStatus(captured.Load(), errs.Load(), 0)
+			return err
+		case err := <-errChan:
+			headlessStatus(captured.Load(), errs.Load(), 0)
+			return err
+		case <-ticker.C:
+			headlessStatus(captured.Load(), errs.Load(), fps.Load())
+		}
+	}
+}
+
+// headlessSymbols loads the selected preset and optionally syncs it against a bin
+func headlessSymbols(ecuType string) ([]*symbol.Symbol, error) {
+	// presets are stored in the same preferences as the GUI uses
+	if err := presets.Load(app.NewWithID("com.roffe.txlogger")); err != nil {
+		headlessMessage("failed to load presets: " + err.Error())
+	}
+
+	presetName := *headlessPreset
+	if presetName == "" {
+		presetName = ecuType + " Dash"
+	}
+	preset, err := presets.GetPreset(presetName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", presetName, err)
+	}
+	if err := ebus.SetDerivedChannels(preset.Channels); err != nil {
+		return nil, fmt.Errorf("%s: %w", presetName, err)
+	}
+	symbols := preset.Symbols
+
+	if *headlessBin != "" {
+		data, err := os.ReadFile(*headlessBin)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+		_, fw, err := symbol.Load(*headlessBin, data, headlessMessage)
+		if err != nil {
+			return nil, fmt.Errorf("error loading symbols: %w", err)
+		}
+		cnt := 0
+		for _, v := range symbols {
+			if sym := fw.GetByName(v.Name); sym != nil {
+				v.Number = sym.Number
+				v.Address = sym.Address
+				v.SramOffset = sym.SramOffset
+				v.Length = sym.Length
+				v.Mask = sym.Mask
+				v.Type = sym.Type
+				v.Unit = sym.Unit
+				v.Correctionfactor = sym.Correctionfactor
+				cnt++
+			}
+		}
+		headlessMessage(fmt.Sprintf("Synced %d / %d symbols", cnt, len(symbols)))
+	}
+
+	if len(symbols) == 0 {
+		return nil, errors.New("no symbols selected for logging")
+	}
+
+	return symbols, nil
+}
+
+func headlessMessage(s string) {
+	fmt.Println(time.Now().Format("15:04:05.000") + " " + s)
+}
+
+func headlessStatus(captured, errs, fps int64) {
+	fmt.Printf("%s Cap: %d Err: %d Fps: %d\n", time.Now().Format("15:04:05.000"), captured, errs, fps)
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/roffe/txlogger/pkg/common"
 	"github.com/roffe/txlogger/pkg/debug"
 	"github.com/roffe/txlogger/pkg/ipc"
+	"github.com/roffe/txlogger/pkg/logfile"
 	"github.com/roffe/txlogger/pkg/presets"
 	"github.com/roffe/txlogger/pkg/theme"
 	"github.com/roffe/txlogger/pkg/windows"
@@ -69,6 +70,17 @@ func main() {
 	defer debug.Close()
 	defer debug.Log("txlogger exit")
 
+	// log without GUI
+	if *headless || *listAdapters {
+		if err := runHeadless(); err != nil {
+			debug.Log("headless: " + err.Error())
+			fmt.Fprintln(os.Stderr, err)
+			debug.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
 	// if another instance is running, just show its window and exit
 	if ipc.IsRunning() && !allowMultipleInstances {
 		return
@@ -137,14 +149,14 @@ func killProcess(p *os.Process) {
 func handleArgs(mw *windows.MainWindow, tx fyne.App) {
 	var loadedSymbols bool
 	if filename := flag.Arg(0); filename != "" {
-		switch strings.ToLower(path.Ext(filename)) {
-		case ".bin":
+		switch {
+		case strings.ToLower(path.Ext(filename)) == ".bin":
 			if err := mw.LoadSymbolsFromFile(filename); err != nil {
 				mw.Error(err)
 			} else {
 				loadedSymbols = true
 			}
-		case ".t5l", ".t7l", ".t8l", ".csv":
+		case logfile.IsLogfile(filename):
 			f, err := os.Open(filename)
 			if err != nil {
 				mw.Error(err)
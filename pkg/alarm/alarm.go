@@ -0,0 +1,298 @@
+// Package alarm watches event bus topics and raises alarms when a value
+// passes a threshold for long enough
+package alarm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/eventbus"
+)
+
+// Rule is defined on a single line as
+//
+//	name: value > threshold [hysteresis h] [for duration] [when condition] [silent]
+//
+// value and condition are expressions on event bus topics, see eventbus.Expression.
+// Use < to alarm when the value drops below the threshold. Examples:
+//
+//	Lean under boost: Lambda.External > 1.05 hysteresis 0.02 for 500ms when In.p_AirInlet > 0.2
+//	Coolant: ActualIn.T_Engine > 105 hysteresis 3 for 5s
+type Rule struct {
+	Name  string
+	Value string
+	// Above alarms when the value is above Threshold, otherwise below
+	Above     bool
+	Threshold float64
+	// Hysteresis is how far back past the threshold the value must go to clear the alarm
+	Hysteresis float64
+	// MinDuration is how long the value must be past the threshold before the alarm is raised
+	MinDuration time.Duration
+	// When is an optional condition that must be true for the alarm to be raised
+	When string
+	// Silent alarms does not play a sound
+	Silent bool
+}
+
+func (r Rule) String() string {
+	op := "<"
+	if r.Above {
+		op = ">"
+	}
+	s := fmt.Sprintf("%s: %s %s %g", r.Name, r.Value, op, r.Threshold)
+	if r.Hysteresis != 0 {
+		s += fmt.Sprintf(" hysteresis %g", r.Hysteresis)
+	}
+	if r.MinDuration != 0 {
+		s += " for " + r.MinDuration.String()
+	}
+	if r.When != "" {
+		s += " when " + r.When
+	}
+	if r.Silent {
+		s += " silent"
+	}
+	return s
+}
+
+var comparisonRe = regexp.MustCompile(`^(.+?)\s*([<>])\s*([-+]?[0-9]*\.?[0-9]+(?:[eE][-+]?[0-9]+)?)$`)
+
+// ParseRule parses a rule definition, see Rule for the syntax
+func ParseRule(def string) (Rule, error) {
+	var r Rule
+	name, rest, found := strings.Cut(def, ":")
+	if !found {
+		return r, fmt.Errorf("invalid alarm %q, expected name: value > threshold", def)
+	}
+	r.Name = strings.TrimSpace(name)
+	if r.Name == "" {
+		return r, fmt.Errorf("invalid alarm %q, missing name", def)
+	}
+
+	// silent is always last and when takes the rest of the line
+	rest = strings.TrimSpace(rest)
+	if s, ok := strings.CutSuffix(rest, " silent"); ok {
+		r.Silent = true
+		rest = strings.TrimSpace(s)
+	}
+	if before, after, ok := strings.Cut(rest, " when "); ok {
+		r.When = strings.TrimSpace(after)
+		rest = strings.TrimSpace(before)
+	}
+
+	fields := strings.Fields(rest)
+options:
+	for len(fields) >= 2 {
+		key, value := fields[len(fields)-2], fields[len(fields)-1]
+		switch key {
+		case "hysteresis":
+			h, err := strconv.ParseFloat(value, 64)
+			if err != nil || h < 0 {
+				return r, fmt.Errorf("%s: invalid hysteresis %q", r.Name, value)
+			}
+			r.Hysteresis = h
+		case "for":
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return r, fmt.Errorf("%s: invalid duration %q, use a unit such as 500ms or 2s", r.Name, value)
+			}
+			r.MinDuration = d
+		default:
+			break options
+		}
+		fields = fields[:len(fields)-2]
+	}
+
+	m := comparisonRe.FindStringSubmatch(strings.Join(fields, " "))
+	if m == nil {
+		return r, fmt.Errorf("%s: expected value > threshold or value < threshold", r.Name)
+	}
+	r.Value = strings.TrimSpace(m[1])
+	r.Above = m[2] == ">"
+	r.Threshold, _ = strconv.ParseFloat(m[3], 64)
+
+	if _, err := eventbus.ParseExpression(r.Value); err != nil {
+		return r, fmt.Errorf("%s: %w", r.Name, err)
+	}
+	if r.When != "" {
+		if _, err := eventbus.ParseExpression(r.When); err != nil {
+			return r, fmt.Errorf("%s: %w", r.Name, err)
+		}
+	}
+	return r, nil
+}
+
+// ParseRules parses one rule per line, empty lines are ignored
+func ParseRules(lines []string) ([]Rule, error) {
+	var rules []Rule
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		r, err := ParseRule(line)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Event is sent when an alarm is raised or cleared
+type Event struct {
+	Rule   Rule
+	Active bool
+	Value  float64
+	Time   time.Time
+}
+
+type state struct {
+	rule   Rule
+	value  *eventbus.Expression
+	when   *eventbus.Expression
+	topics map[string]bool
+	// topics updated since the rule was last evaluated
+	updated map[string]bool
+
+	active       bool
+	pendingSince time.Time
+}
+
+// Monitor evaluates the rules as topics are updated
+type Monitor struct {
+	mu       sync.Mutex
+	rules    []*state
+	values   map[string]float64
+	onChange func(Event)
+}
+
+// NewMonitor returns a monitor calling onChange when an alarm is raised or cleared
+func NewMonitor(rules []Rule, onChange func(Event)) (*Monitor, error) {
+	m := &Monitor{
+		values:   make(map[string]float64),
+		onChange: onChange,
+	}
+	for _, r := range rules {
+		s := &state{rule: r, topics: make(map[string]bool), updated: make(map[string]bool)}
+		var err error
+		if s.value, err = eventbus.ParseExpression(r.Value); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Name, err)
+		}
+		for _, t := range s.value.Topics() {
+			s.topics[t] = true
+		}
+		if r.When != "" {
+			if s.when, err = eventbus.ParseExpression(r.When); err != nil {
+				return nil, fmt.Errorf("%s: %w", r.Name, err)
+			}
+			for _, t := range s.when.Topics() {
+				s.topics[t] = true
+			}
+		}
+		m.rules = append(m.rules, s)
+	}
+	return m, nil
+}
+
+// Topics returns every topic used by the rules
+func (m *Monitor) Topics() []string {
+	seen := make(map[string]bool)
+	var topics []string
+	for _, s := range m.rules {
+		for t := range s.topics {
+			if !seen[t] {
+				seen[t] = true
+				topics = append(topics, t)
+			}
+		}
+	}
+	return topics
+}
+
+// Update sets the value of a topic. A rule is evaluated once every topic it uses has
+// been updated since it was last evaluated, like derived channels, so averages and
+// holds in the rule sees every sample once
+func (m *Monitor) Update(topic string, value float64, ts time.Time) {
+	var events []Event
+	m.mu.Lock()
+	m.values[topic] = value
+	for _, s := range m.rules {
+		if !s.topics[topic] {
+			continue
+		}
+		s.updated[topic] = true
+		if len(s.updated) < len(s.topics) {
+			continue
+		}
+		clear(s.updated)
+		if e, changed := s.eval(m.values, ts); changed {
+			events = append(events, e)
+		}
+	}
+	m.mu.Unlock()
+	for _, e := range events {
+		m.onChange(e)
+	}
+}
+
+// Active returns the rules currently in alarm
+func (m *Monitor) Active() []Rule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var active []Rule
+	for _, s := range m.rules {
+		if s.active {
+			active = append(active, s.rule)
+		}
+	}
+	return active
+}
+
+func (s *state) eval(values map[string]float64, ts time.Time) (Event, bool) {
+	v, err := s.value.Eval(values, ts)
+	if err != nil {
+		// not all topics has been published yet
+		return Event{}, false
+	}
+	enabled := true
+	if s.when != nil {
+		w, err := s.when.Eval(values, ts)
+		enabled = err == nil && w != 0
+	}
+
+	r := s.rule
+	var past, cleared bool
+	if r.Above {
+		past = v > r.Threshold
+		cleared = v < r.Threshold-r.Hysteresis
+	} else {
+		past = v < r.Threshold
+		cleared = v > r.Threshold+r.Hysteresis
+	}
+
+	if s.active {
+		if !enabled || cleared {
+			s.active = false
+			s.pendingSince = time.Time{}
+			return Event{Rule: r, Active: false, Value: v, Time: ts}, true
+		}
+		return Event{}, false
+	}
+
+	if !enabled || !past {
+		s.pendingSince = time.Time{}
+		return Event{}, false
+	}
+	if s.pendingSince.IsZero() {
+		s.pendingSince = ts
+	}
+	if ts.Sub(s.pendingSince) < r.MinDuration {
+		return Event{}, false
+	}
+	s.active = true
+	return Event{Rule: r, Active: true, Value: v, Time: ts}, true
+}
@@ -0,0 +1,335 @@
+// Package autotune proposes fuel map corrections from wideband lambda logs.
+//
+// Every usable sample is placed in the fuel map using the same bilinear
+// interpolation as the ECU and its measured/target lambda ratio is spread over
+// the four surrounding cells by weight. Cells with enough weight get a proposed
+// value scaled by the average ratio and a confidence based on the hit count
+package autotune
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/interpolate"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/mapfile"
+)
+
+// Reasons a sample was not used
+const (
+	RejectMissing     = "missing channels"
+	RejectTransient   = "transient"
+	RejectClosedLoop  = "closed loop"
+	RejectCold        = "cold engine"
+	RejectLambdaRange = "lambda out of range"
+)
+
+type Profile struct {
+	FuelMap        string
+	RPM            string
+	Airmass        string
+	Pedal          string
+	Coolant        string
+	ClosedLoop     string
+	MaxAirmassRate float64
+}
+
+// Profiles holds the channels used for filtering per ECU type
+var Profiles = map[string]Profile{
+	"T5": {
+		FuelMap:        "Insp_mat!",
+		RPM:            "Rpm",
+		Airmass:        "P_medel",
+		Pedal:          "Medeltrot",
+		Coolant:        "Kyl_temp",
+		ClosedLoop:     "Lambdaint",
+		MaxAirmassRate: 50,
+	},
+	"T7": {
+		FuelMap:        "BFuelCal.Map",
+		RPM:            "ActualIn.n_Engine",
+		Airmass:        "MAF.m_AirInlet",
+		Pedal:          "Out.X_AccPedal",
+		Coolant:        "ActualIn.T_Engine",
+		ClosedLoop:     "Lambda.LambdaInt",
+		MaxAirmassRate: 150,
+	},
+	"T8": {
+		FuelMap:        "BFuelCal.LambdaOneFacMap",
+		RPM:            "ActualIn.n_Engine",
+		Airmass:        "MAF.m_AirInlet",
+		Pedal:          "Out.X_AccPos",
+		Coolant:        "ActualIn.T_Engine",
+		ClosedLoop:     "Lambda.LambdaInt",
+		MaxAirmassRate: 150,
+	},
+}
+
+type Config struct {
+	// Channels used to place a sample in the fuel map, the live source of the map axes
+	XChannel string
+	YChannel string
+	// Measured lambda
+	Lambda string
+
+	// Filter channels, a filter is skipped if its channel is empty or not in the log
+	RPM        string
+	Airmass    string
+	Pedal      string
+	Coolant    string
+	ClosedLoop string
+
+	// Minimum coolant temperature
+	MinCoolant float64
+	// Max change per second before a sample is considered transient
+	MaxPedalRate   float64
+	MaxRPMRate     float64
+	MaxAirmassRate float64
+	// Samples within this time of the closed loop integrator moving are rejected
+	ClosedLoopHold time.Duration
+
+	// Valid wideband range
+	MinLambda float64
+	MaxLambda float64
+
+	// Minimum summed sample weight for a cell to get a proposal
+	MinHits float64
+	// Summed sample weight where the full correction is applied
+	FullConfidenceHits float64
+	// Max correction as a fraction, 0.25 = ±25%
+	MaxCorrection float64
+}
+
+func DefaultConfig(ecu string) (*Config, error) {
+	p, ok := Profiles[ecu]
+	if !ok {
+		return nil, fmt.Errorf("autotune is not supported for %q", ecu)
+	}
+	return &Config{
+		Lambda:             "Lambda.External",
+		RPM:                p.RPM,
+		Airmass:            p.Airmass,
+		Pedal:              p.Pedal,
+		Coolant:            p.Coolant,
+		ClosedLoop:         p.ClosedLoop,
+		MinCoolant:         70,
+		MaxPedalRate:       20,
+		MaxRPMRate:         1000,
+		MaxAirmassRate:     p.MaxAirmassRate,
+		ClosedLoopHold:     500 * time.Millisecond,
+		MinLambda:          0.6,
+		MaxLambda:          1.4,
+		MinHits:            5,
+		FullConfidenceHits: 20,
+		MaxCorrection:      0.25,
+	}, nil
+}
+
+type Cell struct {
+	// Summed sample weight, a sample exactly on the cell counts as 1
+	Weight float64
+	// Average measured and target lambda
+	Lambda float64
+	Target float64
+	// 0-1, how much of the measured correction was applied
+	Confidence float64
+	// Applied correction factor, 1 is no change
+	Correction float64
+}
+
+type Result struct {
+	// Proposed map values, NaN for cells without a proposal
+	Proposal []float64
+	Cells    []Cell
+
+	Samples  int
+	Used     int
+	Rejected map[string]int
+}
+
+// Proposed returns the number of cells with a proposal
+func (r *Result) Proposed() int {
+	var n int
+	for _, v := range r.Proposal {
+		if !math.IsNaN(v) {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Result) String() string {
+	s := fmt.Sprintf("%d samples, %d used, %d cells proposed", r.Samples, r.Used, r.Proposed())
+	reasons := make([]string, 0, len(r.Rejected))
+	for k := range r.Rejected {
+		reasons = append(reasons, k)
+	}
+	sort.Strings(reasons)
+	for _, k := range reasons {
+		s += fmt.Sprintf(", %d %s", r.Rejected[k], k)
+	}
+	return s
+}
+
+// Run analyzes the logs against fuel. target holds the wanted lambda over the
+// same axes as fuel, if nil lambda 1.0 is used everywhere
+func Run(cfg *Config, fuel, target *mapfile.Map, logs ...logfile.Logfile) (*Result, error) {
+	if err := fuel.Validate(); err != nil {
+		return nil, err
+	}
+	if target != nil {
+		if err := target.Validate(); err != nil {
+			return nil, fmt.Errorf("target lambda: %w", err)
+		}
+	}
+	if cfg.XChannel == "" || cfg.YChannel == "" || cfg.Lambda == "" {
+		return nil, errors.New("x, y and lambda channels must be set")
+	}
+
+	type sum struct {
+		weight, lambda, target float64
+	}
+	sums := make([]sum, len(fuel.ZData))
+	res := &Result{
+		Proposal: make([]float64, len(fuel.ZData)),
+		Cells:    make([]Cell, len(fuel.ZData)),
+		Rejected: make(map[string]int),
+	}
+
+	cols, rows := len(fuel.XData), len(fuel.YData)
+	for _, lf := range logs {
+		var prev logfile.Record
+		var lastLoopMove time.Time
+		lf.Seek(-1)
+		for rec := lf.Next(); !rec.EOF; rec = lf.Next() {
+			res.Samples++
+			reason := cfg.check(rec, prev, &lastLoopMove)
+			prev = rec
+			if reason != "" {
+				res.Rejected[reason]++
+				continue
+			}
+			x, y, lambda := rec.Values[cfg.XChannel], rec.Values[cfg.YChannel], rec.Values[cfg.Lambda]
+
+			wanted := 1.0
+			if target != nil {
+				_, _, wanted, _ = interpolate.Interpolate64(target.XData, target.YData, target.ZData, x, y)
+			}
+			if wanted <= 0 {
+				res.Rejected[RejectLambdaRange]++
+				continue
+			}
+
+			xf, yf, err := interpolate.Interpolate64S(fuel.XData, fuel.YData, fuel.ZData, x, y)
+			if err != nil {
+				return nil, err
+			}
+			x0, y0 := int(xf), int(yf)
+			x1, y1 := min(x0+1, cols-1), min(y0+1, rows-1)
+			xw, yw := xf-float64(x0), yf-float64(y0)
+			for _, c := range [4]struct {
+				idx int
+				w   float64
+			}{
+				{y0*cols + x0, (1 - xw) * (1 - yw)},
+				{y0*cols + x1, xw * (1 - yw)},
+				{y1*cols + x0, (1 - xw) * yw},
+				{y1*cols + x1, xw * yw},
+			} {
+				if c.w <= 0 {
+					continue
+				}
+				sums[c.idx].weight += c.w
+				sums[c.idx].lambda += c.w * lambda
+				sums[c.idx].target += c.w * wanted
+			}
+			res.Used++
+		}
+	}
+
+	scale := math.Pow10(fuel.ZPrecision)
+	for i, s := range sums {
+		res.Proposal[i] = math.NaN()
+		if s.weight == 0 {
+			continue
+		}
+		cell := &res.Cells[i]
+		cell.Weight = s.weight
+		cell.Lambda = s.lambda / s.weight
+		cell.Target = s.target / s.weight
+		cell.Correction = 1
+		if s.weight < cfg.MinHits {
+			continue
+		}
+		cell.Confidence = min(1, s.weight/max(cfg.FullConfidenceHits, cfg.MinHits))
+		// lean (measured above target) needs more fuel
+		ratio := cell.Lambda / cell.Target
+		cell.Correction = clamp(1+(ratio-1)*cell.Confidence, 1-cfg.MaxCorrection, 1+cfg.MaxCorrection)
+		proposed := math.Round(fuel.ZData[i]*cell.Correction*scale) / scale
+		if proposed != fuel.ZData[i] {
+			res.Proposal[i] = proposed
+		}
+	}
+	return res, nil
+}
+
+// check returns why rec can't be used or an empty string if it can
+func (cfg *Config) check(rec, prev logfile.Record, lastLoopMove *time.Time) string {
+	for _, name := range [...]string{cfg.XChannel, cfg.YChannel, cfg.Lambda} {
+		if _, ok := rec.Values[name]; !ok {
+			return RejectMissing
+		}
+	}
+
+	if cfg.ClosedLoop != "" {
+		if v, ok := rec.Values[cfg.ClosedLoop]; ok {
+			if pv, ok := prev.Values[cfg.ClosedLoop]; !ok || v != pv {
+				*lastLoopMove = rec.Time
+			}
+			if rec.Time.Sub(*lastLoopMove) < cfg.ClosedLoopHold {
+				return RejectClosedLoop
+			}
+		}
+	}
+
+	if cfg.Coolant != "" {
+		if v, ok := rec.Values[cfg.Coolant]; ok && v < cfg.MinCoolant {
+			return RejectCold
+		}
+	}
+
+	// we need the previous sample to tell if the engine is in a steady state
+	dt := rec.Time.Sub(prev.Time).Seconds()
+	if prev.Values == nil || dt <= 0 {
+		return RejectTransient
+	}
+	for _, r := range [...]struct {
+		name string
+		max  float64
+	}{
+		{cfg.Pedal, cfg.MaxPedalRate},
+		{cfg.RPM, cfg.MaxRPMRate},
+		{cfg.Airmass, cfg.MaxAirmassRate},
+	} {
+		if r.name == "" || r.max <= 0 {
+			continue
+		}
+		v, ok := rec.Values[r.name]
+		pv, pok := prev.Values[r.name]
+		if ok && pok && math.Abs(v-pv)/dt > r.max {
+			return RejectTransient
+		}
+	}
+
+	if lambda := rec.Values[cfg.Lambda]; lambda < cfg.MinLambda || lambda > cfg.MaxLambda {
+		return RejectLambdaRange
+	}
+	return ""
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
@@ -0,0 +1,89 @@
+// Package bincompare finds the symbols that differ between two binaries of the same ECU type
+package bincompare
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+type Diff struct {
+	Name string
+	// Number of values that differ, 0 if only the raw bytes differ
+	Cells int
+	// Total number of values in the symbol
+	Total int
+	// The symbol only exists in one of the binaries
+	OnlyInA, OnlyInB bool
+}
+
+func (d Diff) String() string {
+	switch {
+	case d.OnlyInA:
+		return d.Name + " (only in A)"
+	case d.OnlyInB:
+		return d.Name + " (only in B)"
+	}
+	return d.Name
+}
+
+// Compare returns the symbols whose contents differs, sorted by name
+func Compare(a, b symbol.SymbolCollection) []Diff {
+	var diffs []Diff
+	for _, symA := range a.Symbols() {
+		if symA.Length == 0 {
+			continue
+		}
+		symB := b.GetByName(symA.Name)
+		if symB == nil {
+			diffs = append(diffs, Diff{Name: symA.Name, OnlyInA: true})
+			continue
+		}
+		if d, ok := compareSymbol(symA, symB); ok {
+			diffs = append(diffs, d)
+		}
+	}
+	for _, symB := range b.Symbols() {
+		if symB.Length == 0 {
+			continue
+		}
+		if a.GetByName(symB.Name) == nil {
+			diffs = append(diffs, Diff{Name: symB.Name, OnlyInB: true})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return strings.ToLower(diffs[i].Name) < strings.ToLower(diffs[j].Name)
+	})
+	return diffs
+}
+
+// CompareSymbol compares a single symbol present in both binaries, ok is false if they are equal
+func CompareSymbol(a, b symbol.SymbolCollection, name string) (Diff, bool) {
+	symA, symB := a.GetByName(name), b.GetByName(name)
+	switch {
+	case symA == nil && symB == nil:
+		return Diff{}, false
+	case symB == nil:
+		return Diff{Name: name, OnlyInA: true}, true
+	case symA == nil:
+		return Diff{Name: name, OnlyInB: true}, true
+	}
+	return compareSymbol(symA, symB)
+}
+
+func compareSymbol(symA, symB *symbol.Symbol) (Diff, bool) {
+	if bytes.Equal(symA.Bytes(), symB.Bytes()) {
+		return Diff{}, false
+	}
+	d := Diff{Name: symA.Name}
+	valuesA, valuesB := symA.Float64s(), symB.Float64s()
+	d.Total = max(len(valuesA), len(valuesB))
+	for i := range d.Total {
+		if i >= len(valuesA) || i >= len(valuesB) || valuesA[i] != valuesB[i] {
+			d.Cells++
+		}
+	}
+	return d, true
+}
@@ -65,6 +65,16 @@ func GetBinPath() (string, error) {
 	return binPath, createDirIfNotExists(binPath)
 }
 
+// GetBackupPath returns the directory of the pre-flash backup archive
+func GetBackupPath() (string, error) {
+	dir, err := GetUserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	backupPath := GetComponentPath(dir, "backups")
+	return backupPath, createDirIfNotExists(backupPath)
+}
+
 func GetComponentPath(base, typ string) string {
 	return filepath.Join(base, "txlogger", typ)
 }
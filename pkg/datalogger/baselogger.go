@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/ebus"
 	"github.com/roffe/txlogger/pkg/wbl"
+	"github.com/roffe/txlogger/pkg/wbl/sensor"
 	"github.com/roffe/txlogger/relayserver"
 )
 
@@ -17,6 +20,10 @@ type BaseLogger struct {
 	lamb wbl.LambdaProvider
 	lw   LogWriter
 
+	// wideband sysvars written to the log, channels reported later are only published
+	wblLogged   map[string]bool
+	wblUnlogged map[string]bool
+
 	sysvars *ThreadSafeMap
 
 	readChan  chan *DataRequest
@@ -38,12 +45,17 @@ type BaseLogger struct {
 
 	r *relayserver.Client
 
+	// a session has been set up, later failures are reconnected with AutoReconnect
+	connected bool
+	// sysvar order of the log
+	order []string
+
 	Config
 }
 
 func NewBaseLogger(cfg Config, lw LogWriter) *BaseLogger {
 	bl := &BaseLogger{
-		lw:           lw,
+		lw:           &lockedWriter{lw: lw},
 		Config:       cfg,
 		sysvars:      NewThreadSafeMap(),
 		writeChan:    make(chan *DataRequest, 1),
@@ -70,6 +82,11 @@ func (bl *BaseLogger) Close() {
 	})
 }
 
+// AddMarker writes a text marker to the log at the current time
+func (bl *BaseLogger) AddMarker(text string) error {
+	return writeMarker(bl.lw, text, time.Now())
+}
+
 func (bl *BaseLogger) SetRAM(address uint32, data []byte) error {
 	req := NewWriteDataRequest(address, data)
 	select {
@@ -129,3 +146,83 @@ func (bl *BaseLogger) setupWBL(ctx context.Context, cl *gocan.Client) error {
 	bl.lamb = lamb
 	return nil
 }
+
+// wblSymbol returns the sysvar a wideband channel is published as, the lambda keeps its old name
+func wblSymbol(i int, ch sensor.Channel) string {
+	if i == 0 {
+		return EXTERNALWBLSYM
+	}
+	return ch.Name + ".External"
+}
+
+// wblChannels returns the sysvars of the wideband channels to log. The channels are
+// read once a device like a PLX chain has had time to report every sensor, later
+// sessions log the same channels as the first
+func (bl *BaseLogger) wblChannels(ctx context.Context) []string {
+	if bl.wblLogged == nil {
+		select {
+		case <-ctx.Done():
+		case <-time.After(wblSettleTime):
+		}
+		bl.wblLogged = make(map[string]bool)
+		bl.wblUnlogged = make(map[string]bool)
+		var desc []string
+		for i, ch := range bl.lamb.Channels() {
+			name := wblSymbol(i, ch)
+			bl.wblLogged[name] = true
+			bl.sysvars.SetUnit(name, ch.Unit)
+			if ch.HasStatus {
+				bl.wblLogged[name+".Status"] = true
+			}
+			desc = append(desc, strings.TrimSpace(name+" "+ch.Unit))
+		}
+		bl.OnMessage("Wideband channels: " + strings.Join(desc, ", "))
+	}
+	names := make([]string, 0, len(bl.wblLogged))
+	for name := range bl.wblLogged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// publishWBL publishes every wideband channel on the event bus and sets the logged ones in the sysvars.
+// The status flags of channels with a status are published as <sysvar>.Status
+func (bl *BaseLogger) publishWBL() {
+	publish := func(name string, value float64) {
+		ebus.Publish(name, value)
+		if bl.wblLogged[name] {
+			bl.sysvars.Set(name, value)
+			return
+		}
+		if !bl.wblUnlogged[name] {
+			bl.wblUnlogged[name] = true
+			bl.OnMessage(name + " was reported after logging started and is not written to the log")
+		}
+	}
+	for i, ch := range bl.lamb.Channels() {
+		name := wblSymbol(i, ch)
+		publish(name, ch.Value)
+		if ch.HasStatus {
+			publish(name+".Status", float64(ch.Status))
+		}
+	}
+}
+
+// subscribeDerived mirrors the derived channels from the event bus into the sysvars
+// so they are written to the log like any other sysvar
+func (bl *BaseLogger) subscribeDerived() ([]string, func()) {
+	names := ebus.DerivedChannels()
+	cancels := make([]func(), 0, len(names))
+	for _, name := range names {
+		bl.sysvars.Set(name, 0)
+		cancels = append(cancels, ebus.CONTROLLER.SubscribeFunc(name, func(v float64) {
+			bl.sysvars.Set(name, v)
+		}))
+	}
+	return names, func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
@@ -1,20 +1,54 @@
 package datalogger
 
 import (
+	"crypto/tls"
 	"fmt"
 
 	"github.com/roffe/txlogger/relayserver"
 )
 
-func (bl *BaseLogger) runRelay() error {
-	c, err := relayserver.NewClient(relayserver.SERVER_HOST)
+const defaultRelaySession = "1337"
+
+// dialRelay connects to the relay server and joins the configured session, the
+// role granted by the server is returned with the client
+func dialRelay(cfg RelayConfig, role relayserver.Role, owner bool, onMessage func(string)) (*relayserver.Client, relayserver.Role, error) {
+	host := cfg.Host
+	if host == "" {
+		host = relayserver.SERVER_HOST
+	}
+	session := cfg.Session
+	if session == "" {
+		session = defaultRelaySession
+	}
+
+	var c *relayserver.Client
+	var err error
+	if cfg.TLS {
+		c, err = relayserver.NewTLSClient(host, &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+		})
+	} else {
+		c, err = relayserver.NewClient(host)
+	}
 	if err != nil {
-		return fmt.Errorf("dial error: %w", err)
+		return nil, role, fmt.Errorf("dial error: %w", err)
 	}
-	bl.OnMessage("Connected to relay server")
+	onMessage("Connected to relay server " + host)
 
-	if err := c.JoinSession("1337"); err != nil {
-		return fmt.Errorf("join session error: %w", err)
+	granted, err := c.Join(session, cfg.Token, role, owner)
+	if err != nil {
+		c.Close()
+		return nil, role, fmt.Errorf("join session error: %w", err)
+	}
+	onMessage(fmt.Sprintf("Joined relay session %s as %s", session, granted))
+	return c, granted, nil
+}
+
+func (bl *BaseLogger) runRelay() error {
+	c, _, err := dialRelay(bl.Relay, relayserver.RoleTuner, true, bl.OnMessage)
+	if err != nil {
+		return err
 	}
 
 	bl.r = c
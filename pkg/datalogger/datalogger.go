@@ -7,25 +7,62 @@ import (
 
 	symbol "github.com/roffe/ecusymbol"
 	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/logformat"
+	"github.com/roffe/txlogger/relayserver"
 )
 
 var (
-	ErrToManyErrors = fmt.Errorf("too many errors, aborting logging")
+	ErrToManyErrors = fmt.Errorf("too many errors")
+	// ErrMarkersNotSupported is returned when a marker is written to a log that can't store it
+	ErrMarkersNotSupported = fmt.Errorf("the log format does not support markers")
 )
 
 const ISO8601 = "2006-01-02T15:04:05.999-0700"
-const ISONICO = "2006-01-02 15:04:05,999"
+const ISONICO = logformat.ISONICO
 const EXTERNALWBLSYM = "Lambda.External"
 
+// wblSettleTime is how long the wideband gets to report its channels before logging starts
+const wblSettleTime = time.Second
+
 type LogWriter interface {
 	Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error
 	Close() error
 }
 
+// PartialWriter is implemented by log writers that can store records where
+// only some of the symbols were read, updated has one entry per symbol in vars.
+// Writers without it get the full record with the last read values
+type PartialWriter interface {
+	WritePartial(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, updated []bool, ts time.Time) error
+}
+
+// MarkerWriter is implemented by log writers that can store a text marker at a point in time
+type MarkerWriter interface {
+	WriteMarker(text string, ts time.Time) error
+}
+
+// writeMarker writes a marker, ErrMarkersNotSupported is returned if the log format does not support it
+func writeMarker(lw LogWriter, text string, ts time.Time) error {
+	if mw, ok := lw.(MarkerWriter); ok {
+		return mw.WriteMarker(text, ts)
+	}
+	return ErrMarkersNotSupported
+}
+
+// writeRecord writes a record where only the symbols marked in updated was read this tick
+func writeRecord(lw LogWriter, sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, updated []bool, ts time.Time) error {
+	if pw, ok := lw.(PartialWriter); ok {
+		return pw.WritePartial(sysvars, sysvarOrder, vars, updated, ts)
+	}
+	return lw.Write(sysvars, sysvarOrder, vars, ts)
+}
+
 type IClient interface {
 	Start() error
 	SetRAM(address uint32, data []byte) error
 	GetRAM(address uint32, length uint32) ([]byte, error)
+	// AddMarker writes a text marker to the log, an error is returned when the marker was not written
+	AddMarker(text string) error
 	Close()
 }
 
@@ -47,6 +84,14 @@ type Config struct {
 	LogPath        string
 	WidebandConfig WidebandConfig
 	RemoteMode     int
+	// Relay is the relay server used when RemoteMode is Local+Relay or Remote
+	Relay RelayConfig
+	// RateClasses overrides the rate class of symbols for loggers that supports logging symbols at different rates
+	RateClasses map[string]RateClass
+	// AutoReconnect reconnects to the ECU and keeps logging to the same file when the connection is lost
+	AutoReconnect bool
+	// Trigger only writes the log while a condition is met
+	Trigger TriggerConfig
 }
 
 type Client struct {
@@ -63,18 +108,35 @@ type WidebandConfig struct {
 	High                   float64
 }
 
+// RelayConfig selects the relay server and the session to join
+type RelayConfig struct {
+	// Host defaults to relayserver.SERVER_HOST
+	Host    string
+	Session string
+	Token   string
+	// Role requested by a remote client, the logger sharing the ECU always joins as the tuner owning the session
+	Role relayserver.Role
+	TLS  bool
+	// InsecureSkipVerify accepts self signed server certificates
+	InsecureSkipVerify bool
+}
+
 func New(cfg Config) (IClient, string, error) {
 	log.Println("RemoteMode", cfg.RemoteMode)
 	datalogger := &Client{
 		cfg: cfg,
 	}
 
-	filename, lw, err := NewWriter(cfg)
+	filename, lw, err := newLogWriter(cfg)
 	if err != nil {
 		return nil, "", err
 	}
 
-	cfg.OnMessage(fmt.Sprintf("Logging to %s", filename))
+	if filename != "" {
+		cfg.OnMessage(fmt.Sprintf("Logging to %s", filename))
+	} else {
+		cfg.OnMessage("Waiting for trigger: " + cfg.Trigger.Condition)
+	}
 
 	if cfg.RemoteMode == 2 {
 		datalogger.IClient, err = NewRemote(cfg, lw)
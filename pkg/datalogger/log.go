@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	symbol "github.com/roffe/ecusymbol"
@@ -25,10 +26,42 @@ func NewWriter(cfg Config) (string, LogWriter, error) {
 			return "", nil, err
 		}
 		return filename, NewTXLWriter(file), nil
+	case "TXB":
+		file, filename, err := createLog(cfg.LogPath, cfg.FilenamePrefix, "txb")
+		if err != nil {
+			return "", nil, err
+		}
+		return filename, NewTXBinWriter(file), nil
 	}
 	return "unknown", nil, fmt.Errorf("unknown format: %s", cfg.LogFormat)
 }
 
+// newLogWriter creates the writer for a logging session. With a trigger in
+// SplitFiles mode no file is created until the condition is met and the filename is empty
+func newLogWriter(cfg Config) (string, LogWriter, error) {
+	if !cfg.Trigger.Enabled() {
+		return NewWriter(cfg)
+	}
+	tw, err := NewTriggerWriter(cfg.Trigger, func() (string, LogWriter, error) {
+		return NewWriter(cfg)
+	}, cfg.OnMessage)
+	if err != nil {
+		return "", nil, err
+	}
+	if cfg.Trigger.SplitFiles {
+		return "", tw, nil
+	}
+	filename, lw, err := NewWriter(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	tw.lw = lw
+	return filename, tw, nil
+}
+
+// number of names tried by createLog before giving up
+const maxLogNameAttempts = 100
+
 func createLog(path, prefix, extension string) (*os.File, string, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		if err := os.Mkdir(path, 0755); err != nil {
@@ -38,33 +71,76 @@ func createLog(path, prefix, extension string) (*os.File, string, error) {
 		}
 	}
 
-	filename := fmt.Sprintf("%s-%s.%s", strings.ReplaceAll(prefix, ".", "_"), time.Now().Format("2006-01-02_150405"), extension)
-	filename = common.SanitizeFilename(filename)
+	// the name has one second resolution, a trigger can open several logs within the
+	// same second so a counter is added until the name is not taken
+	name := fmt.Sprintf("%s-%s", strings.ReplaceAll(prefix, ".", "_"), time.Now().Format("2006-01-02_150405"))
+	for i := 0; i < maxLogNameAttempts; i++ {
+		filename := name + "." + extension
+		if i > 0 {
+			filename = fmt.Sprintf("%s_%d.%s", name, i, extension)
+		}
+		fullFilename := filepath.Join(path, common.SanitizeFilename(filename))
 
-	fullFilename := filepath.Join(path, filename)
+		file, err := os.OpenFile(fullFilename, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0666)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open file: %w", err)
+		}
+		return file, fullFilename, nil
+	}
+	return nil, "", fmt.Errorf("failed to open file: %s.%s and %d numbered names already exists", name, extension, maxLogNameAttempts-1)
+}
 
-	file, err := os.OpenFile(fullFilename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to open file: %w", err)
+// channelUnits returns the logged sysvars and symbols that has a unit, in log order
+func channelUnits(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol) (names, units []string) {
+	for _, k := range sysvarOrder {
+		if unit := sysvars.Unit(k); unit != "" {
+			names = append(names, k)
+			units = append(units, unit)
+		}
 	}
-	return file, fullFilename, nil
+	for _, va := range vars {
+		if va.Number < 0 || va.Unit == "" {
+			continue
+		}
+		names = append(names, va.Name)
+		units = append(units, va.Unit)
+	}
+	return names, units
 }
 
 func replaceDot(s string) string {
 	return strings.Replace(s, ".", ",", 1)
 }
 
-type TXBinWriter struct {
-	file *os.File
+// lockedWriter lets markers be written from other goroutines than the logger
+type lockedWriter struct {
+	mu sync.Mutex
+	lw LogWriter
 }
 
-func NewTXBinWriter(f *os.File) *TXBinWriter {
-	return &TXBinWriter{
-		file: f,
-	}
+func (l *lockedWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lw.Write(sysvars, sysvarOrder, vars, ts)
 }
 
-func (t *TXBinWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+func (l *lockedWriter) WritePartial(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, updated []bool, ts time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return writeRecord(l.lw, sysvars, sysvarOrder, vars, updated, ts)
+}
+
+func (l *lockedWriter) WriteMarker(text string, ts time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return writeMarker(l.lw, text, ts)
+}
 
-	return nil
+func (l *lockedWriter) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lw.Close()
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/logformat"
 )
 
 func NewCSVWriter(f *os.File) *CSVWriter {
@@ -25,8 +26,17 @@ type CSVWriter struct {
 }
 
 func (c *CSVWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	return c.write(sysvars, sysvarOrder, vars, nil, ts)
+}
+
+// WritePartial leaves the fields of symbols not read this tick empty
+func (c *CSVWriter) WritePartial(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, updated []bool, ts time.Time) error {
+	return c.write(sysvars, sysvarOrder, vars, updated, ts)
+}
+
+func (c *CSVWriter) write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, updated []bool, ts time.Time) error {
 	if !c.headerWritten {
-		if err := c.writeHeader(vars, sysvarOrder); err != nil {
+		if err := c.writeHeader(sysvars, vars, sysvarOrder); err != nil {
 			return err
 		}
 	}
@@ -43,16 +53,36 @@ func (c *CSVWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*
 		}
 		record = append(record, strconv.FormatFloat(val, 'f', c.precission, 64))
 	}
-	for _, va := range vars {
+	for i, va := range vars {
 		if va.Number < 0 {
 			continue
 		}
+		if updated != nil && !updated[i] {
+			record = append(record, "")
+			continue
+		}
 		record = append(record, va.StringValue())
 	}
 	return c.cw.Write(record)
 }
 
-func (c *CSVWriter) writeHeader(vars []*symbol.Symbol, sysvarOrder []string) error {
+// WriteMarker writes the marker as a comment line starting with #
+func (c *CSVWriter) WriteMarker(text string, ts time.Time) error {
+	c.cw.Flush()
+	if err := c.cw.Error(); err != nil {
+		return err
+	}
+	_, err := c.file.WriteString(logformat.CSVMarkerLine(text, ts) + "\n")
+	return err
+}
+
+// writeHeader writes the column names, preceded by a comment with the units of the columns that has one
+func (c *CSVWriter) writeHeader(sysvars *ThreadSafeMap, vars []*symbol.Symbol, sysvarOrder []string) error {
+	if names, units := channelUnits(sysvars, sysvarOrder, vars); len(names) > 0 {
+		if _, err := c.file.WriteString(logformat.CSVUnitsLine(names, units) + "\n"); err != nil {
+			return err
+		}
+	}
 	var header []string
 	header = append(header, "Time")
 	header = append(header, sysvarOrder...)
@@ -0,0 +1,198 @@
+package datalogger
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/eventbus"
+)
+
+// ErrTriggerNotRecording is returned for markers written while the trigger condition is not met
+var ErrTriggerNotRecording = errors.New("trigger is not recording")
+
+// TriggerConfig makes the logger write only while a condition is met
+type TriggerConfig struct {
+	// Condition is an expression on event bus topics such as
+	// "ActualIn.n_Engine > 3000 && Out.X_AccPedal > 80", see eventbus.Expression.
+	// An empty condition logs everything
+	Condition string
+	// PreTrigger is how much data before the condition was met that is written
+	PreTrigger time.Duration
+	// PostTrigger is how long the condition must be false before writing stops
+	PostTrigger time.Duration
+	// SplitFiles writes every triggered event to a new file
+	SplitFiles bool
+}
+
+func (t TriggerConfig) Enabled() bool {
+	return t.Condition != ""
+}
+
+type triggerRecord struct {
+	sysvars *ThreadSafeMap
+	vars    []*symbol.Symbol
+	updated []bool
+	ts      time.Time
+}
+
+// TriggerWriter sits in front of a LogWriter and only passes on records while
+// the trigger condition is met, records from before the trigger are kept in a buffer
+type TriggerWriter struct {
+	cfg       TriggerConfig
+	condition *eventbus.Expression
+	// opens the log file for an event
+	open      func() (string, LogWriter, error)
+	onMessage func(string)
+
+	lw         LogWriter
+	recording  bool
+	lastActive time.Time
+	buffer     []triggerRecord
+	values     map[string]float64
+}
+
+// NewTriggerWriter returns a writer opening files with open when the condition is met.
+// Without SplitFiles the first file opened is used for all events
+func NewTriggerWriter(cfg TriggerConfig, open func() (string, LogWriter, error), onMessage func(string)) (*TriggerWriter, error) {
+	condition, err := eventbus.ParseExpression(cfg.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trigger condition: %w", err)
+	}
+	return &TriggerWriter{
+		cfg:       cfg,
+		condition: condition,
+		open:      open,
+		onMessage: onMessage,
+		values:    make(map[string]float64),
+	}, nil
+}
+
+func (t *TriggerWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	return t.WritePartial(sysvars, sysvarOrder, vars, nil, ts)
+}
+
+func (t *TriggerWriter) WritePartial(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, updated []bool, ts time.Time) error {
+	active := t.eval(sysvars, sysvarOrder, vars, ts)
+
+	if t.recording {
+		if active {
+			t.lastActive = ts
+		} else if ts.Sub(t.lastActive) >= t.cfg.PostTrigger {
+			return t.stop()
+		}
+		return writeRecord(t.lw, sysvars, sysvarOrder, vars, updated, ts)
+	}
+
+	t.buffer = append(t.buffer, snapshotRecord(sysvars, sysvarOrder, vars, updated, ts))
+	for len(t.buffer) > 0 && ts.Sub(t.buffer[0].ts) > t.cfg.PreTrigger {
+		t.buffer = t.buffer[1:]
+	}
+	if !active {
+		return nil
+	}
+	return t.start(sysvarOrder, ts)
+}
+
+// start opens the log if needed and writes the buffered records
+func (t *TriggerWriter) start(sysvarOrder []string, ts time.Time) error {
+	if t.lw == nil {
+		filename, lw, err := t.open()
+		if err != nil {
+			return err
+		}
+		t.lw = lw
+		t.onMessage("Trigger logging to " + filename)
+	} else {
+		t.onMessage("Trigger started")
+	}
+	t.recording = true
+	t.lastActive = ts
+
+	buffer := t.buffer
+	t.buffer = nil
+	if len(buffer) > 0 {
+		if err := writeMarker(t.lw, "trigger: "+t.cfg.Condition, buffer[0].ts); err != nil {
+			return err
+		}
+	}
+	for _, rec := range buffer {
+		if err := writeRecord(t.lw, rec.sysvars, sysvarOrder, rec.vars, rec.updated, rec.ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *TriggerWriter) stop() error {
+	t.recording = false
+	t.condition.Reset()
+	if !t.cfg.SplitFiles {
+		t.onMessage("Trigger stopped")
+		return nil
+	}
+	lw := t.lw
+	t.lw = nil
+	t.onMessage("Trigger stopped, closing log")
+	return lw.Close()
+}
+
+// eval evaluates the condition on the current record, missing values counts as not triggered
+func (t *TriggerWriter) eval(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) bool {
+	for _, k := range sysvarOrder {
+		t.values[k] = sysvars.Get(k)
+	}
+	for _, va := range vars {
+		t.values[va.Name] = va.Float64()
+	}
+	v, err := t.condition.Eval(t.values, ts)
+	return err == nil && v != 0
+}
+
+// WriteMarker passes markers on while recording
+func (t *TriggerWriter) WriteMarker(text string, ts time.Time) error {
+	if !t.recording {
+		return ErrTriggerNotRecording
+	}
+	return writeMarker(t.lw, text, ts)
+}
+
+func (t *TriggerWriter) Close() error {
+	if t.lw == nil {
+		return nil
+	}
+	return t.lw.Close()
+}
+
+// snapshotRecord copies the values of a record so it can be written later
+func snapshotRecord(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, updated []bool, ts time.Time) triggerRecord {
+	rec := triggerRecord{
+		sysvars: NewThreadSafeMap(),
+		vars:    make([]*symbol.Symbol, len(vars)),
+		updated: slices.Clone(updated),
+		ts:      ts,
+	}
+	for _, k := range sysvarOrder {
+		rec.sysvars.Set(k, sysvars.Get(k))
+		if unit := sysvars.Unit(k); unit != "" {
+			rec.sysvars.SetUnit(k, unit)
+		}
+	}
+	for i, va := range vars {
+		c := &symbol.Symbol{
+			Name:             va.Name,
+			Number:           va.Number,
+			Address:          va.Address,
+			SramOffset:       va.SramOffset,
+			Length:           va.Length,
+			Type:             va.Type,
+			Correctionfactor: va.Correctionfactor,
+			Unit:             va.Unit,
+		}
+		_ = c.SetData(slices.Clone(va.Bytes()))
+		rec.vars[i] = c
+	}
+	return rec
+}
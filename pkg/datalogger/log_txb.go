@@ -0,0 +1,158 @@
+package datalogger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/logformat"
+)
+
+func NewTXBinWriter(f *os.File) *TXBinWriter {
+	return &TXBinWriter{
+		file: f,
+		bw:   bufio.NewWriter(f),
+	}
+}
+
+type TXBinWriter struct {
+	file          *os.File
+	bw            *bufio.Writer
+	headerWritten bool
+	channels      []logformat.TXBChannel
+	last          time.Time
+	buf           []byte
+	// markers written before the header, the header needs the first record
+	pending []txbMarker
+}
+
+type txbMarker struct {
+	text string
+	ts   time.Time
+}
+
+func (t *TXBinWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	if !t.headerWritten {
+		if err := t.writeHeader(sysvars, sysvarOrder, vars, ts); err != nil {
+			return err
+		}
+		for _, m := range t.pending {
+			if err := t.WriteMarker(m.text, m.ts); err != nil {
+				return err
+			}
+		}
+		t.pending = nil
+	}
+
+	t.buf = t.buf[:0]
+	t.buf = binary.BigEndian.AppendUint32(t.buf, t.delta(ts))
+	idx := 0
+	for _, k := range sysvarOrder {
+		if idx >= len(t.channels) {
+			break
+		}
+		t.buf = appendRaw(t.buf, sysvars.Get(k), t.channels[idx].Factor)
+		idx++
+	}
+	for _, va := range vars {
+		if va.Number < 0 {
+			continue
+		}
+		if idx >= len(t.channels) {
+			break
+		}
+		t.buf = appendRaw(t.buf, va.Float64(), t.channels[idx].Factor)
+		idx++
+	}
+	// pad if channels disappeared since the header was written to keep the record width fixed
+	for ; idx < len(t.channels); idx++ {
+		t.buf = binary.BigEndian.AppendUint32(t.buf, 0)
+	}
+	_, err := t.bw.Write(t.buf)
+	return err
+}
+
+// WriteMarker writes a marker record, markers before the first record are kept until the header is written
+func (t *TXBinWriter) WriteMarker(text string, ts time.Time) error {
+	if !t.headerWritten {
+		t.pending = append(t.pending, txbMarker{text, ts})
+		return nil
+	}
+	t.buf = t.buf[:0]
+	t.buf = binary.BigEndian.AppendUint32(t.buf, logformat.TXBMarkerRecord)
+	t.buf = binary.BigEndian.AppendUint32(t.buf, t.delta(ts))
+	t.buf = appendString(t.buf, logformat.MarkerText(text))
+	_, err := t.bw.Write(t.buf)
+	return err
+}
+
+// delta returns the milliseconds since the previous record or marker, time never goes backwards in a TXB
+func (t *TXBinWriter) delta(ts time.Time) uint32 {
+	delta := min(max(ts.Sub(t.last).Milliseconds(), 0), logformat.TXBMaxDelta)
+	t.last = t.last.Add(time.Duration(delta) * time.Millisecond)
+	return uint32(delta)
+}
+
+func appendRaw(b []byte, value, factor float64) []byte {
+	raw := math.Round(value / factor)
+	raw = max(min(raw, math.MaxInt32), math.MinInt32)
+	return binary.BigEndian.AppendUint32(b, uint32(int32(raw)))
+}
+
+func (t *TXBinWriter) writeHeader(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	for _, k := range sysvarOrder {
+		t.channels = append(t.channels, logformat.TXBChannel{Name: k, Unit: sysvars.Unit(k), Factor: logformat.TXBSysvarFactor})
+	}
+	for _, va := range vars {
+		if va.Number < 0 {
+			continue
+		}
+		factor := va.Correctionfactor
+		if factor == 0 {
+			factor = 1
+		}
+		t.channels = append(t.channels, logformat.TXBChannel{Name: va.Name, Unit: va.Unit, Factor: factor})
+	}
+	if len(t.channels) > math.MaxUint16 {
+		return errors.New("too many channels for TXB log")
+	}
+
+	var b []byte
+	b = append(b, logformat.TXBMagic...)
+	b = binary.BigEndian.AppendUint16(b, logformat.TXBVersion)
+	b = binary.BigEndian.AppendUint64(b, uint64(ts.UnixMilli()))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(t.channels)))
+	for _, ch := range t.channels {
+		b = appendString(b, ch.Name)
+		b = appendString(b, ch.Unit)
+		b = binary.BigEndian.AppendUint64(b, math.Float64bits(ch.Factor))
+	}
+
+	// track the time the reader reconstructs from the deltas
+	t.last = time.UnixMilli(ts.UnixMilli())
+	t.headerWritten = true
+	_, err := t.bw.Write(b)
+	return err
+}
+
+func appendString(b []byte, s string) []byte {
+	if len(s) > math.MaxUint16 {
+		s = s[:math.MaxUint16]
+	}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func (t *TXBinWriter) Close() error {
+	if err := t.bw.Flush(); err != nil {
+		return err
+	}
+	if err := t.file.Sync(); err != nil {
+		return err
+	}
+	return t.file.Close()
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/logformat"
 )
 
 func NewTXLWriter(f *os.File) *TXWriter {
@@ -16,12 +17,30 @@ func NewTXLWriter(f *os.File) *TXWriter {
 }
 
 type TXWriter struct {
-	file       *os.File
-	precission int
+	file         *os.File
+	precission   int
+	unitsWritten bool
 }
 
 func (t *TXWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
-	_, err := t.file.Write([]byte(ts.Format("02-01-2006 15:04:05.999") + "|"))
+	return t.write(sysvars, sysvarOrder, vars, nil, ts)
+}
+
+// WritePartial leaves out symbols not read this tick
+func (t *TXWriter) WritePartial(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, updated []bool, ts time.Time) error {
+	return t.write(sysvars, sysvarOrder, vars, updated, ts)
+}
+
+func (t *TXWriter) write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, updated []bool, ts time.Time) error {
+	if !t.unitsWritten {
+		t.unitsWritten = true
+		if names, units := channelUnits(sysvars, sysvarOrder, vars); len(names) > 0 {
+			if _, err := t.file.Write([]byte(logformat.TXLUnitsLine(names, units, ts) + "\n")); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := t.file.Write([]byte(ts.Format(logformat.TXLTimeFormat) + "|"))
 	if err != nil {
 		return err
 	}
@@ -38,8 +57,8 @@ func (t *TXWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*s
 			return err
 		}
 	}
-	for _, va := range vars {
-		if va.Number < 0 {
+	for i, va := range vars {
+		if va.Number < 0 || (updated != nil && !updated[i]) {
 			continue
 		}
 		if _, err := t.file.Write([]byte(va.Name + "=" + replaceDot(va.StringValue()) + "|")); err != nil {
@@ -50,6 +69,12 @@ func (t *TXWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*s
 	return err
 }
 
+// WriteMarker writes a line with only the marker, flagged as an important line
+func (t *TXWriter) WriteMarker(text string, ts time.Time) error {
+	_, err := t.file.Write([]byte(logformat.TXLMarkerLine(text, ts) + "\n"))
+	return err
+}
+
 func (t *TXWriter) Close() error {
 	if err := t.file.Sync(); err != nil {
 		return err
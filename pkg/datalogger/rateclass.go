@@ -0,0 +1,79 @@
+package datalogger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RateClass controls how often a symbol is written to the log, symbols are still
+// read every tick so gauges and derived channels stays up to date
+type RateClass int
+
+const (
+	// logged every tick
+	RateFast RateClass = iota
+	// logged every mediumRateDivider tick
+	RateMedium
+	// logged once per second
+	RateSlow
+)
+
+const mediumRateDivider = 4
+
+var rateClassNames = []string{"fast", "medium", "slow"}
+
+func (r RateClass) String() string {
+	if r < 0 || int(r) >= len(rateClassNames) {
+		return "unknown"
+	}
+	return rateClassNames[r]
+}
+
+func ParseRateClass(s string) (RateClass, error) {
+	for i, name := range rateClassNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return RateClass(i), nil
+		}
+	}
+	return RateFast, fmt.Errorf("unknown rate class %q, use fast, medium or slow", s)
+}
+
+// ParseRateClasses parses "symbol = class" lines, empty lines are ignored
+func ParseRateClasses(lines []string) (map[string]RateClass, error) {
+	classes := make(map[string]RateClass)
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, class, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid rate class %q, expected name = class", line)
+		}
+		rc, err := ParseRateClass(class)
+		if err != nil {
+			return nil, err
+		}
+		classes[strings.TrimSpace(name)] = rc
+	}
+	return classes, nil
+}
+
+// rateClass returns the configured rate class for a symbol, symbols not configured are logged every tick
+func (c Config) rateClass(name string) RateClass {
+	if rc, ok := c.RateClasses[name]; ok {
+		return rc
+	}
+	return RateFast
+}
+
+// due reports if a symbol of rate class r should be logged on tick, rate is the number of ticks per second
+func (r RateClass) due(tick, rate int) bool {
+	switch r {
+	case RateMedium:
+		return tick%mediumRateDivider == 0
+	case RateSlow:
+		return tick%max(rate, 1) == 0
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package datalogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrConnectionLost is returned by a logging session when the adapter or the ECU stopped responding
+var ErrConnectionLost = errors.New("connection lost")
+
+const (
+	reconnectMinBackoff = 500 * time.Millisecond
+	reconnectMaxBackoff = 10 * time.Second
+)
+
+// runSessions runs session until logging is stopped. With AutoReconnect a
+// session ending in an error burst or a lost connection is started again after
+// a backoff, the log keeps going in the same file with a gap marker where data is missing.
+// A session calls onConnected once the ECU is set up for logging, failures before the
+// first successful session are returned since they are most likely configuration errors
+func (bl *BaseLogger) runSessions(session func() error) error {
+	backoff := reconnectMinBackoff
+	for {
+		started := time.Now()
+		err := session()
+		if err == nil || !bl.AutoReconnect || bl.stopped() {
+			return err
+		}
+		if !bl.connected && !errors.Is(err, ErrToManyErrors) && !errors.Is(err, ErrConnectionLost) {
+			return err
+		}
+		// a session that ran for a while starts over with a short backoff
+		if time.Since(started) > reconnectMaxBackoff {
+			backoff = reconnectMinBackoff
+		}
+
+		// gaps while a trigger is not recording are not in the log anyway
+		if err := writeMarker(bl.lw, "gap: "+err.Error(), time.Now()); err != nil && !errors.Is(err, ErrTriggerNotRecording) {
+			bl.OnMessage("failed to write gap marker: " + err.Error())
+		}
+		bl.OnMessage(fmt.Sprintf("%v, reconnecting in %s", err, backoff))
+
+		select {
+		case <-bl.quitChan:
+			bl.OnMessage("Stopped logging..")
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, reconnectMaxBackoff)
+		bl.resetPerSecond()
+	}
+}
+
+// onConnected is called by a session when the ECU is ready for logging
+func (bl *BaseLogger) onConnected() {
+	if bl.connected {
+		bl.OnMessage("Resumed logging")
+	}
+	bl.connected = true
+}
+
+// logOrder returns the sysvar order of the first session, every session must write the same channels to the log
+func (bl *BaseLogger) logOrder(order []string) []string {
+	if bl.order == nil {
+		bl.order = order
+	}
+	return bl.order
+}
+
+func (bl *BaseLogger) stopped() bool {
+	select {
+	case <-bl.quitChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitSession waits for the adapter and the read loop of a session. The read loop
+// returns nil when logging is stopped, if the adapter goes away first the session is lost
+func waitSession(cancel context.CancelFunc, wait func() error, loop <-chan error) error {
+	waitErr := wait()
+	cancel()
+	if err := <-loop; !errors.Is(err, context.Canceled) {
+		return err
+	}
+	if waitErr != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionLost, waitErr)
+	}
+	return ErrConnectionLost
+}
@@ -1,13 +1,19 @@
 package datalogger
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/roffe/txlogger/pkg/ebus"
 	"github.com/roffe/txlogger/relayserver"
 )
 
+// ErrViewerReadOnly is returned for RAM reads and writes when the relay session
+// was joined as viewer, the server only relays log data to viewers
+var ErrViewerReadOnly = errors.New("relay viewers can not read or write RAM")
+
 type RemoteClient struct {
 	*BaseLogger
 
@@ -27,24 +33,23 @@ func (c *RemoteClient) Start() error {
 	defer c.secondTicker.Stop()
 	defer c.lw.Close()
 
-	cl, err := relayserver.NewClient(relayserver.SERVER_HOST)
+	cl, role, err := dialRelay(c.Relay, c.Relay.Role, false, c.OnMessage)
 	if err != nil {
-		return fmt.Errorf("dial error: %w", err)
+		return err
 	}
 	defer cl.Close()
 
-	c.OnMessage("Connected to relay server")
-
-	if err := cl.JoinSession("1337"); err != nil {
-		return fmt.Errorf("join session error: %w", err)
-	}
-
-	symbols, err := cl.GetSymbolList()
-	if err != nil {
-		return fmt.Errorf("get symbol list error: %w", err)
-	}
-	for _, sym := range symbols {
-		log.Println(sym.String())
+	// the server only relays log data to viewers, they learn the channels from it
+	var channels []string
+	if role != relayserver.RoleViewer {
+		symbols, err := cl.GetSymbolList()
+		if err != nil {
+			return fmt.Errorf("get symbol list error: %w", err)
+		}
+		for _, sym := range symbols {
+			log.Println(sym.String())
+			channels = append(channels, sym.Name)
+		}
 	}
 
 	recvChan := cl.Ch()
@@ -61,6 +66,10 @@ func (c *RemoteClient) Start() error {
 			}
 			c.resetPerSecond()
 		case read := <-c.readChan:
+			if role == relayserver.RoleViewer {
+				read.Complete(ErrViewerReadOnly)
+				continue
+			}
 			data, err := cl.ReadRAM(read.Address, read.Length)
 			if err != nil {
 				c.onError()
@@ -71,6 +80,10 @@ func (c *RemoteClient) Start() error {
 			read.Left = 0
 			read.Complete(nil)
 		case write := <-c.writeChan:
+			if role == relayserver.RoleViewer {
+				write.Complete(ErrViewerReadOnly)
+				continue
+			}
 			err := cl.WriteRAM(write.Address, write.Data)
 			if err != nil {
 				c.onError()
@@ -88,6 +101,12 @@ func (c *RemoteClient) Start() error {
 					c.OnMessage("Invalid data values")
 					continue
 				}
+				if channels == nil {
+					for _, va := range values {
+						channels = append(channels, va.Name)
+					}
+					c.OnMessage(fmt.Sprintf("Receiving %d channels: %s", len(channels), strings.Join(channels, ", ")))
+				}
 				for _, va := range values {
 					ebus.Publish(va.Name, va.Value)
 				}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"fmt"
 	"math"
 	"time"
 
@@ -24,7 +25,10 @@ func NewT5(cfg Config, lw LogWriter) (IClient, error) {
 func (c *T5Client) Start() error {
 	defer c.secondTicker.Stop()
 	defer c.lw.Close()
+	return c.runSessions(c.session)
+}
 
+func (c *T5Client) session() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -57,27 +61,37 @@ func (c *T5Client) Start() error {
 
 	if c.lamb != nil {
 		defer c.lamb.Stop()
-		order = append(order, EXTERNALWBLSYM)
+		order = append(order, c.wblChannels(ctx)...)
 	}
 
+	derived, unsubscribeDerived := c.subscribeDerived()
+	defer unsubscribeDerived()
+	order = append(order, derived...)
+	order = c.logOrder(order)
+
 	tx := cl.Subscribe(ctx, gocan.SystemMsgDataResponse)
 	defer tx.Close()
 
 	converto := newT5Converter(c.WidebandConfig)
 
+	c.onConnected()
+
+	loop := make(chan error, 1)
 	go func() {
 		defer cl.Close()
 		for {
 			select {
 			case <-ctx.Done():
+				loop <- ctx.Err()
 				return
 			case <-c.quitChan:
 				c.OnMessage("Stopped logging..")
+				loop <- nil
 				return
 			case <-c.secondTicker.C:
 				c.FpsCounter(c.capturePerSecond)
 				if c.errPerSecond > 5 {
-					c.OnMessage("too many errors, aborting logging")
+					loop <- ErrToManyErrors
 					return
 				}
 				c.resetPerSecond()
@@ -107,7 +121,7 @@ func (c *T5Client) Start() error {
 					}
 					r := bytes.NewReader(resp)
 					if err := sym.Read(r); err != nil {
-						c.OnMessage("failed to read symbol " + sym.Name + ": " + err.Error())
+						loop <- fmt.Errorf("failed to read symbol %s: %w", sym.Name, err)
 						return
 					}
 					val := converto(sym.Name, sym.Bytes())
@@ -116,20 +130,18 @@ func (c *T5Client) Start() error {
 				}
 
 				if c.lamb != nil {
-					lambda := c.lamb.GetLambda()
-					c.sysvars.Set(EXTERNALWBLSYM, lambda)
-					ebus.Publish(EXTERNALWBLSYM, lambda)
+					c.publishWBL()
 				}
 
 				if err := c.lw.Write(c.sysvars, order, []*symbol.Symbol{}, ts); err != nil {
-					c.OnMessage("failed to write log: " + err.Error())
+					loop <- fmt.Errorf("failed to write log: %w", err)
 					return
 				}
 				c.onCapture()
 			}
 		}
 	}()
-	return cl.Wait(ctx)
+	return waitSession(cancel, func() error { return cl.Wait(ctx) }, loop)
 }
 
 const (
@@ -76,7 +76,10 @@ func t7broadcastListener(ctx context.Context, cl *gocan.Client, sysvars *ThreadS
 func (c *T7Client) Start() error {
 	defer c.secondTicker.Stop()
 	defer c.lw.Close()
+	return c.runSessions(c.session)
+}
 
+func (c *T7Client) session() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -123,9 +126,14 @@ func (c *T7Client) Start() error {
 
 	if c.lamb != nil {
 		defer c.lamb.Stop()
-		sysvarOrder = append(sysvarOrder, EXTERNALWBLSYM)
+		sysvarOrder = append(sysvarOrder, c.wblChannels(ctx)...)
 	}
 
+	derived, unsubscribeDerived := c.subscribeDerived()
+	defer unsubscribeDerived()
+	sysvarOrder = append(sysvarOrder, derived...)
+	sysvarOrder = c.logOrder(sysvarOrder)
+
 	for _, sym := range c.Symbols {
 		if c.sysvars.Exists(sym.Name) {
 			log.Println("Skipping", sym.Name, "in broadcast")
@@ -166,6 +174,9 @@ func (c *T7Client) Start() error {
 	//	}
 	//}
 
+	c.onConnected()
+
+	loop := make(chan error, 1)
 	go func() {
 		defer cl.Close()
 		defer func() {
@@ -175,14 +186,16 @@ func (c *T7Client) Start() error {
 		for {
 			select {
 			case <-ctx.Done():
+				loop <- ctx.Err()
 				return
 			case <-c.quitChan:
 				c.OnMessage("Stopped logging..")
+				loop <- nil
 				return
 			case <-c.secondTicker.C:
 				c.FpsCounter(c.capturePerSecond)
 				if c.errPerSecond > 5 {
-					c.OnMessage("too many errors, aborting logging")
+					loop <- ErrToManyErrors
 					return
 				}
 				c.resetPerSecond()
@@ -269,9 +282,7 @@ func (c *T7Client) Start() error {
 				}
 
 				if c.lamb != nil {
-					lambda := c.lamb.GetLambda()
-					c.sysvars.Set(EXTERNALWBLSYM, lambda)
-					ebus.Publish(EXTERNALWBLSYM, lambda)
+					c.publishWBL()
 				}
 
 				/*
@@ -306,7 +317,7 @@ func (c *T7Client) Start() error {
 			}
 		}
 	}()
-	return cl.Wait(ctx)
+	return waitSession(cancel, func() error { return cl.Wait(ctx) }, loop)
 }
 
 func initT7logging(ctx context.Context, kwp *kwp2000.Client, symbols []*symbol.Symbol, onMessage func(string)) error {
@@ -40,7 +40,10 @@ const lastPresentInterval = 2500 * time.Millisecond
 func (c *T8Client) Start() error {
 	defer c.secondTicker.Stop()
 	defer c.lw.Close()
+	return c.runSessions(c.session)
+}
 
+func (c *T8Client) session() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -65,11 +68,16 @@ func (c *T8Client) Start() error {
 
 	if c.lamb != nil {
 		defer c.lamb.Stop()
-		order = append(order, EXTERNALWBLSYM)
+		order = append(order, c.wblChannels(ctx)...)
 	}
 
+	derived, unsubscribeDerived := c.subscribeDerived()
+	defer unsubscribeDerived()
+	order = append(order, derived...)
+
 	// sort order
 	sort.StringSlice(order).Sort()
+	order = c.logOrder(order)
 
 	opts := []gmlan.GMLanOption{gmlan.WithCanID(0x7E0), gmlan.WithRecvID(0x7E8)}
 	if cl.AdapterName() == "ELM327" {
@@ -82,22 +90,34 @@ func (c *T8Client) Start() error {
 		return fmt.Errorf("failed to init t8 logging: %w", err)
 	}
 
-	go c.run(ctx, cl, gm, order)
+	c.onConnected()
 
-	return cl.Wait(ctx)
+	loop := make(chan error, 1)
+	go func() {
+		loop <- c.run(ctx, cl, gm, order)
+	}()
+
+	return waitSession(cancel, func() error { return cl.Wait(ctx) }, loop)
 }
 
-func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client, order []string) {
+func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client, order []string) error {
 	defer cl.Close()
 
 	var timeStamp time.Time
 	var chunkSize uint32
 
 	var expectedPayloadSize uint16
-	for _, sym := range c.Symbols {
+	classes := make([]RateClass, len(c.Symbols))
+	for i, sym := range c.Symbols {
 		expectedPayloadSize += sym.Length
+		classes[i] = c.rateClass(sym.Name)
 	}
 
+	// ticks since logging started, decides which rate classes are logged
+	var tick int
+	// symbols logged in the current tick
+	updated := make([]bool, len(c.Symbols))
+
 	lastPresent := time.Now()
 
 	testerPresent := func() {
@@ -123,15 +143,14 @@ func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client,
 		select {
 		case <-ctx.Done():
 			log.Println("ctx done")
-			return
+			return ctx.Err()
 		case <-c.quitChan:
 			c.OnMessage("Stopped logging..")
-			return
+			return nil
 		case <-c.secondTicker.C:
 			c.FpsCounter(c.capturePerSecond)
 			if c.errPerSecond > 5 {
-				c.OnMessage("too many errors, aborting logging")
-				return
+				return ErrToManyErrors
 			}
 			c.resetPerSecond()
 		case read := <-c.readChan:
@@ -178,30 +197,32 @@ func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client,
 				continue
 			}
 			if len(databuff) != int(expectedPayloadSize) {
-				c.OnMessage(fmt.Sprintf("expected %d bytes, got %d", expectedPayloadSize, len(databuff)))
-				return
+				return fmt.Errorf("expected %d bytes, got %d", expectedPayloadSize, len(databuff))
 			}
 			r := bytes.NewReader(databuff)
 
-			for _, va := range c.Symbols {
+			// all symbols are read every tick, the rate classes decides how often they are logged
+			clear(updated)
+			for i, va := range c.Symbols {
 				if err := va.Read(r); err != nil {
 					c.onError()
 					c.OnMessage("failed to set data: " + err.Error())
 					break
 				}
 				ebus.Publish(va.Name, va.Float64())
+				updated[i] = classes[i].due(tick, c.Rate)
 			}
+			tick++
 
 			if r.Len() > 0 {
 				c.OnMessage(fmt.Sprintf("%d leftover bytes!", r.Len()))
 			}
 
 			if c.lamb != nil {
-				ebus.Publish(EXTERNALWBLSYM, c.lamb.GetLambda())
-				c.sysvars.Set(EXTERNALWBLSYM, c.lamb.GetLambda())
+				c.publishWBL()
 			}
 
-			if err := c.lw.Write(c.sysvars, order, c.Symbols, timeStamp); err != nil {
+			if err := writeRecord(c.lw, c.sysvars, order, c.Symbols, updated, timeStamp); err != nil {
 				c.onError()
 				c.OnMessage("failed to write log: " + err.Error())
 			}
@@ -4,12 +4,15 @@ import "sync"
 
 type ThreadSafeMap struct {
 	values map[string]float64
+	// units of the values that has one, written to the log header
+	units map[string]string
 	sync.Mutex
 }
 
 func NewThreadSafeMap() *ThreadSafeMap {
 	return &ThreadSafeMap{
 		values: make(map[string]float64),
+		units:  make(map[string]string),
 	}
 }
 
@@ -46,4 +49,17 @@ func (t *ThreadSafeMap) Delete(name string) {
 	t.Lock()
 	defer t.Unlock()
 	delete(t.values, name)
+	delete(t.units, name)
+}
+
+func (t *ThreadSafeMap) SetUnit(name, unit string) {
+	t.Lock()
+	defer t.Unlock()
+	t.units[name] = unit
+}
+
+func (t *ThreadSafeMap) Unit(name string) string {
+	t.Lock()
+	defer t.Unlock()
+	return t.units[name]
 }
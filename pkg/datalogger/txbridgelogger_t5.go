@@ -26,9 +26,13 @@ func (c *TxBridge) t5(pctx context.Context, cl *gocan.Client) error {
 
 	if c.lamb != nil {
 		defer c.lamb.Stop()
-		order = append(order, EXTERNALWBLSYM)
+		order = append(order, c.wblChannels(ctx)...)
 	}
 
+	derived, unsubscribeDerived := c.subscribeDerived()
+	defer unsubscribeDerived()
+	order = append(order, derived...)
+
 	expectedPayloadSize, err := c.configureT5Symbols(cl)
 	if err != nil {
 		return fmt.Errorf("error configuring symbols: %w", err)
@@ -176,9 +180,7 @@ func (c *TxBridge) t5(pctx context.Context, cl *gocan.Client) error {
 				}
 
 				if c.lamb != nil {
-					lambda := c.lamb.GetLambda()
-					c.sysvars.Set(EXTERNALWBLSYM, lambda)
-					ebus.Publish(EXTERNALWBLSYM, lambda)
+					c.publishWBL()
 				}
 
 				if err := c.lw.Write(c.sysvars, order, []*symbol.Symbol{}, timeStamp); err != nil {
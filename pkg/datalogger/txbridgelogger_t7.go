@@ -35,9 +35,13 @@ func (c *TxBridge) t7(pctx context.Context, cl *gocan.Client) error {
 
 	if c.lamb != nil {
 		defer c.lamb.Stop()
-		order = append(order, EXTERNALWBLSYM)
+		order = append(order, c.wblChannels(ctx)...)
 	}
 
+	derived, unsubscribeDerived := c.subscribeDerived()
+	defer unsubscribeDerived()
+	order = append(order, derived...)
+
 	for _, sym := range c.Symbols {
 		if c.sysvars.Exists(sym.Name) {
 			log.Println("Skipping", sym.Name, "in broadcast")
@@ -225,9 +229,7 @@ func (c *TxBridge) t7(pctx context.Context, cl *gocan.Client) error {
 				}
 
 				if c.lamb != nil {
-					lambda := c.lamb.GetLambda()
-					c.sysvars.Set(EXTERNALWBLSYM, lambda)
-					ebus.Publish(EXTERNALWBLSYM, lambda)
+					c.publishWBL()
 				}
 
 				if err := c.lw.Write(c.sysvars, order, c.Symbols, timeStamp); err != nil {
@@ -22,9 +22,13 @@ func (c *TxBridge) t8(pctx context.Context, cl *gocan.Client) error {
 	order := c.sysvars.Keys()
 	if c.lamb != nil {
 		defer c.lamb.Stop()
-		order = append(order, EXTERNALWBLSYM)
+		order = append(order, c.wblChannels(ctx)...)
 	}
 
+	derived, unsubscribeDerived := c.subscribeDerived()
+	defer unsubscribeDerived()
+	order = append(order, derived...)
+
 	sort.StringSlice(order).Sort()
 
 	gm := gmlan.New(cl, 0x7e0, 0x7e8)
@@ -129,9 +133,7 @@ func (c *TxBridge) t8(pctx context.Context, cl *gocan.Client) error {
 				}
 
 				if c.lamb != nil {
-					lambda := c.lamb.GetLambda()
-					c.sysvars.Set(EXTERNALWBLSYM, lambda)
-					ebus.Publish(EXTERNALWBLSYM, lambda)
+					c.publishWBL()
 				}
 
 				if err := c.lw.Write(c.sysvars, order, c.Symbols, timeStamp); err != nil {
@@ -10,6 +10,7 @@ const (
 	ECU_T5 ECU = iota
 	ECU_T7
 	ECU_T8
+	ECU_Z22SE
 )
 
 type DTC struct {
@@ -40,6 +41,10 @@ func (d DTC) Info() DTCInfo {
 		if info, ok := T8DTCS[d.Code]; ok {
 			return info
 		}
+	case ECU_Z22SE:
+		if info, ok := Z22SEDTCS[d.Code]; ok {
+			return info
+		}
 	}
 
 	return DTCInfo{
@@ -0,0 +1,73 @@
+package dtc
+
+var Z22SEDTCS = map[string]DTCInfo{
+	"P0010": {"Camshaft Position Actuator Circuit", "Intake camshaft phaser solenoid circuit open or shorted"},
+	"P0011": {"Camshaft Position Timing Over-Advanced", "Intake camshaft position differs from desired, check oil level and phaser solenoid"},
+	"P0016": {"Crankshaft/Camshaft Position Correlation", "Timing chain stretched or jumped, check chain and tensioner"},
+	"P0030": {"HO2S Heater Control Circuit Bank 1 Sensor 1", "Front oxygen sensor heater circuit fault"},
+	"P0036": {"HO2S Heater Control Circuit Bank 1 Sensor 2", "Rear oxygen sensor heater circuit fault"},
+	"P0101": {"Mass Air Flow Sensor Performance", "MAF signal does not match calculated airflow, check for intake leaks"},
+	"P0102": {"Mass Air Flow Sensor Circuit Low", ""},
+	"P0103": {"Mass Air Flow Sensor Circuit High", ""},
+	"P0106": {"Manifold Absolute Pressure Sensor Performance", ""},
+	"P0107": {"Manifold Absolute Pressure Sensor Circuit Low", ""},
+	"P0108": {"Manifold Absolute Pressure Sensor Circuit High", ""},
+	"P0112": {"Intake Air Temperature Sensor Circuit Low", ""},
+	"P0113": {"Intake Air Temperature Sensor Circuit High", ""},
+	"P0117": {"Engine Coolant Temperature Sensor Circuit Low", ""},
+	"P0118": {"Engine Coolant Temperature Sensor Circuit High", ""},
+	"P0121": {"Throttle Position Sensor 1 Performance", "Throttle position sensor 1 does not match calculated position"},
+	"P0122": {"Throttle Position Sensor 1 Circuit Low", ""},
+	"P0123": {"Throttle Position Sensor 1 Circuit High", ""},
+	"P0125": {"Insufficient Coolant Temperature For Closed Loop", "Thermostat stuck open or coolant temperature sensor fault"},
+	"P0128": {"Coolant Thermostat Below Regulating Temperature", "Thermostat stuck open"},
+	"P0130": {"HO2S Circuit Bank 1 Sensor 1", "Front oxygen sensor circuit fault"},
+	"P0131": {"HO2S Circuit Low Voltage Bank 1 Sensor 1", ""},
+	"P0132": {"HO2S Circuit High Voltage Bank 1 Sensor 1", ""},
+	"P0133": {"HO2S Slow Response Bank 1 Sensor 1", "Front oxygen sensor aged or contaminated"},
+	"P0134": {"HO2S No Activity Bank 1 Sensor 1", ""},
+	"P0136": {"HO2S Circuit Bank 1 Sensor 2", "Rear oxygen sensor circuit fault"},
+	"P0137": {"HO2S Circuit Low Voltage Bank 1 Sensor 2", ""},
+	"P0138": {"HO2S Circuit High Voltage Bank 1 Sensor 2", ""},
+	"P0171": {"System Too Lean Bank 1", "Check for vacuum leaks, fuel pressure and MAF sensor"},
+	"P0172": {"System Too Rich Bank 1", "Check fuel pressure, injectors and purge valve"},
+	"P0201": {"Injector Circuit Cylinder 1", ""},
+	"P0202": {"Injector Circuit Cylinder 2", ""},
+	"P0203": {"Injector Circuit Cylinder 3", ""},
+	"P0204": {"Injector Circuit Cylinder 4", ""},
+	"P0221": {"Throttle Position Sensor 2 Performance", "Throttle position sensor 2 does not match calculated position"},
+	"P0222": {"Throttle Position Sensor 2 Circuit Low", ""},
+	"P0223": {"Throttle Position Sensor 2 Circuit High", ""},
+	"P0300": {"Random/Multiple Cylinder Misfire Detected", "Check ignition cassette, spark plugs and injectors"},
+	"P0301": {"Cylinder 1 Misfire Detected", ""},
+	"P0302": {"Cylinder 2 Misfire Detected", ""},
+	"P0303": {"Cylinder 3 Misfire Detected", ""},
+	"P0304": {"Cylinder 4 Misfire Detected", ""},
+	"P0325": {"Knock Sensor Circuit", ""},
+	"P0335": {"Crankshaft Position Sensor Circuit", ""},
+	"P0336": {"Crankshaft Position Sensor Performance", "Check sensor air gap and reluctor wheel"},
+	"P0340": {"Camshaft Position Sensor Circuit", ""},
+	"P0341": {"Camshaft Position Sensor Performance", ""},
+	"P0351": {"Ignition Coil A Primary Circuit", "Ignition cassette cylinder 1 and 4"},
+	"P0352": {"Ignition Coil B Primary Circuit", "Ignition cassette cylinder 2 and 3"},
+	"P0401": {"Exhaust Gas Recirculation Flow Insufficient", ""},
+	"P0420": {"Catalyst System Efficiency Below Threshold Bank 1", "Catalytic converter worn or rear oxygen sensor fault"},
+	"P0440": {"Evaporative Emission System", ""},
+	"P0443": {"Evaporative Emission Purge Solenoid Circuit", ""},
+	"P0500": {"Vehicle Speed Sensor", "No vehicle speed signal received"},
+	"P0506": {"Idle Speed Lower Than Expected", "Dirty throttle body or intake leak"},
+	"P0507": {"Idle Speed Higher Than Expected", "Intake leak or throttle body fault"},
+	"P0560": {"System Voltage", "Battery voltage out of range, check battery and alternator"},
+	"P0601": {"Control Module Read Only Memory", "ECU flash checksum error"},
+	"P0602": {"Control Module Not Programmed", ""},
+	"P0604": {"Control Module Random Access Memory", ""},
+	"P0606": {"Control Module Internal Performance", "Main processor and MCP disagree"},
+	"P1516": {"Throttle Actuator Control Module Throttle Actuator Position Performance", "Throttle body sticking or motor fault"},
+	"P2101": {"Throttle Actuator Control Motor Circuit Range/Performance", ""},
+	"P2135": {"Throttle Position Sensor 1 and 2 Correlation", ""},
+	"P2138": {"Pedal Position Sensor 1 and 2 Correlation", ""},
+	"U0073": {"Control Module Communication Bus Off", ""},
+	"U0100": {"Lost Communication With ECM/PCM", ""},
+	"U0101": {"Lost Communication With TCM", ""},
+	"U0121": {"Lost Communication With ABS Control Module", ""},
+}
@@ -68,3 +68,13 @@ func Unsubscribe(channel chan float64) {
 func SetOnMessage(f func(string, float64)) {
 	CONTROLLER.SetOnMessage(f)
 }
+
+// SetDerivedChannels replaces the derived channels, see eventbus.Expression for the syntax
+func SetDerivedChannels(defs []string) error {
+	return CONTROLLER.SetDerivedChannels(defs)
+}
+
+// DerivedChannels returns the names of the derived channels
+func DerivedChannels() []string {
+	return CONTROLLER.DerivedChannels()
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"sort"
+	"time"
 
 	"github.com/roffe/gocan"
 	"github.com/roffe/txlogger/pkg/dtc"
@@ -13,6 +14,7 @@ import (
 
 type Client interface {
 	ReadDTC(context.Context) ([]dtc.DTC, error)
+	ClearDTC(context.Context) error
 	PrintECUInfo(context.Context) error
 	Info(context.Context) ([]model.HeaderResult, error)
 	DumpECU(context.Context) ([]byte, error)
@@ -21,11 +23,103 @@ type Client interface {
 	ResetECU(context.Context) error
 }
 
+// Resumer is implemented by ECUs whose FlashECU only writes the partitions that
+// differ from the file, an interrupted flash is finished by flashing the same file again
+type Resumer interface {
+	// InBootloader reports if the flash bootloader is still running on the ECU
+	InBootloader(context.Context) bool
+}
+
 type Config struct {
 	Name       string
 	OnProgress func(float64)
 	OnError    func(error)
 	OnMessage  func(string)
+	// Flash holds the dump and flash options, the zero value keeps the ECU defaults
+	Flash FlashOptions
+	// OnFlashResult is called with the partitions written and the verify result
+	// by ECUs that flash by partition
+	OnFlashResult func(FlashResult)
+}
+
+type FlashResult struct {
+	// Partitions written, 1-9
+	Partitions []int
+	Verify     VerifyMode
+	// The written partitions matched the file, only set with VerifyMD5
+	Verified bool
+}
+
+// MaskPartitions returns the partitions of a mask where bit 0 is partition 1
+func MaskPartitions(mask uint64) []int {
+	var partitions []int
+	for i := range 64 {
+		if mask&(1<<i) != 0 {
+			partitions = append(partitions, i+1)
+		}
+	}
+	return partitions
+}
+
+type VerifyMode int
+
+const (
+	// VerifyMD5 compares the MD5 of the ECU with the file after flashing or dumping
+	VerifyMD5 VerifyMode = iota
+	VerifyNone
+)
+
+func (v VerifyMode) String() string {
+	switch v {
+	case VerifyMD5:
+		return "md5"
+	case VerifyNone:
+		return "none"
+	}
+	return "unknown"
+}
+
+type FlashOptions struct {
+	// Also write the boot partition, Trionic 8 only
+	Boot bool
+	// Also write the NVDM partitions holding adaptions and VIN, Trionic 8 only
+	NVDM bool
+	// Only write these partitions (1-9), empty writes every partition that differs
+	Partitions []int
+	Verify     VerifyMode
+	// Don't lower the bootloader inter frame latency, for adapters that drop frames
+	NoHighSpeed bool
+	// ExtraRetries is added to the attempts of every retried operation, zero keeps the ECU defaults
+	ExtraRetries uint
+	// RetryDelay is the delay between attempts, zero keeps the ECU default
+	RetryDelay time.Duration
+}
+
+// Attempts returns the attempts of an operation tried def times by default plus the extra retries
+func (o FlashOptions) Attempts(def uint) uint {
+	return def + o.ExtraRetries
+}
+
+// Delay returns the configured retry delay or def if unset
+func (o FlashOptions) Delay(def time.Duration) time.Duration {
+	if o.RetryDelay > 0 {
+		return o.RetryDelay
+	}
+	return def
+}
+
+// PartitionMask limits a partition mask, where bit 0 is partition 1, to the selected partitions
+func (o FlashOptions) PartitionMask(mask uint64) uint64 {
+	if len(o.Partitions) == 0 {
+		return mask
+	}
+	var selected uint64
+	for _, p := range o.Partitions {
+		if p >= 1 && p <= 64 {
+			selected |= 1 << (p - 1)
+		}
+	}
+	return mask & selected
 }
 
 func LoadConfig(cfg *Config) *Config {
@@ -53,6 +147,10 @@ func LoadConfig(cfg *Config) *Config {
 		}
 	}
 
+	if cfg.OnFlashResult == nil {
+		cfg.OnFlashResult = func(FlashResult) {}
+	}
+
 	return cfg
 }
 
@@ -0,0 +1,57 @@
+// Package gmlandtc reads and clears diagnostic trouble codes on the GMLAN ECUs
+package gmlandtc
+
+import (
+	"context"
+	"time"
+
+	"github.com/roffe/gocan/pkg/gmlan"
+	"github.com/roffe/txlogger/pkg/dtc"
+)
+
+// Read returns the DTCs of the ECU, ecu selects the description database
+func Read(ctx context.Context, gm *gmlan.Client, ecu dtc.ECU) ([]dtc.DTC, error) {
+	gm.TesterPresentNoResponseAllowed()
+
+	if err := gm.InitiateDiagnosticOperation(ctx, gmlan.LEV_DADTC); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = gm.ReturnToNormalMode(ctx)
+		time.Sleep(75 * time.Millisecond)
+	}()
+
+	dtcs, err := gm.ReadDiagnosticInformationStatusOfDTCByStatusMask(ctx, 0x12)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []dtc.DTC
+	for _, f := range dtcs {
+		out = append(out, dtc.DTC{
+			ECU:    ecu,
+			Code:   f.Code,
+			Status: f.Status,
+		})
+	}
+
+	return out, nil
+}
+
+// Clear clears the DTCs of the ECU with the physical request id canID.
+// The functional id 0x7DF is not used since it clears every module on the bus
+func Clear(ctx context.Context, gm *gmlan.Client, canID uint32) error {
+	gm.TesterPresentNoResponseAllowed()
+
+	if err := gm.InitiateDiagnosticOperation(ctx, gmlan.LEV_DADTC); err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = gm.ReturnToNormalMode(ctx)
+		time.Sleep(75 * time.Millisecond)
+	}()
+
+	return gm.ClearDiagnosticInformation(ctx, canID)
+}
@@ -10,3 +10,7 @@ import (
 func (t *Client) ReadDTC(ctx context.Context) ([]dtc.DTC, error) {
 	return nil, errors.New("not implemented yet")
 }
+
+func (t *Client) ClearDTC(ctx context.Context) error {
+	return errors.New("not implemented yet")
+}
@@ -47,7 +47,7 @@ func (t *Client) DumpECU(ctx context.Context) ([]byte, error) {
 			retry.OnRetry(func(n uint, err error) {
 				t.cfg.OnError(fmt.Errorf("retrying to read memory by address: %w", err))
 			}),
-			retry.Attempts(3),
+			retry.Attempts(t.cfg.Flash.Attempts(3)),
 			retry.LastErrorOnly(true),
 		)
 		if err != nil {
@@ -74,7 +74,7 @@ func (t *Client) DumpECU(ctx context.Context) ([]byte, error) {
 			retry.OnRetry(func(n uint, err error) {
 				t.cfg.OnError(fmt.Errorf("t5 retrying to read memory by address: %w", err))
 			}),
-			retry.Attempts(3),
+			retry.Attempts(t.cfg.Flash.Attempts(3)),
 			retry.LastErrorOnly(true),
 		)
 		if err != nil {
@@ -1,14 +1,205 @@
 package t7
 
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// address in the bin holding a pointer to the coded checksum area table
+	checksumAreaPointer = 0x20140
+	checksumAreaCount   = 16
+	checksumAreaSize    = 6
+)
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// F2 checksum xor table, the first dword is xored with the second entry
+var f2XorTable = [8]uint32{
+	0x81184224, 0x24421881, 0xC3955E3A, 0x3A5E95C3,
+	0x4F3D4AB2, 0xB24A3D4F, 0x1C34F7D2, 0xD2F7341C,
+}
+
 type checksumArea struct {
 	addr   uint32
 	length uint16
 }
 
+type ChecksumResult struct {
+	HasF2        bool
+	F2Stored     uint32
+	F2Calculated uint32
+	FBStored     uint32
+	FBCalculated uint32
+}
+
+func (r *ChecksumResult) OK() bool {
+	if r.HasF2 && r.F2Stored != r.F2Calculated {
+		return false
+	}
+	return r.FBStored == r.FBCalculated
+}
+
+func (r *ChecksumResult) String() string {
+	s := fmt.Sprintf("FB stored: %08X calculated: %08X", r.FBStored, r.FBCalculated)
+	if r.HasF2 {
+		s = fmt.Sprintf("F2 stored: %08X calculated: %08X, ", r.F2Stored, r.F2Calculated) + s
+	}
+	return s
+}
+
+// CalculateChecksum calculates the F2 & FB checksums of a T7 bin and returns them together with the values stored in the footer
+func CalculateChecksum(bin []byte) (*ChecksumResult, error) {
+	if len(bin) != 0x80000 {
+		return nil, fmt.Errorf("invalid bin size: %d", len(bin))
+	}
+	fh, err := ParseFileHeader(bin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse footer: %w", err)
+	}
+
+	fwLength := fh.FWLength()
+	if fwLength <= 0 || fwLength > len(bin)-footerSize {
+		return nil, fmt.Errorf("invalid firmware length in footer: 0x%X", fwLength)
+	}
+
+	areas, err := readChecksumAreas(bin, fwLength)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ChecksumResult{
+		FBStored:     uint32(fh.ChecksumFB()),
+		FBCalculated: calculateFBChecksum(bin, areas),
+	}
+
+	if f2, found := fh.ChecksumF2(); found {
+		res.HasF2 = true
+		res.F2Stored = uint32(f2)
+		res.F2Calculated = calculateF2Checksum(bin, fwLength)
+	}
+
+	return res, nil
+}
+
+// VerifyChecksum returns an error wrapping ErrChecksumMismatch if the stored checksums does not match the bin contents
 func VerifyChecksum(bin []byte) error {
-	var areas []checksumArea
-	for i := 0; i < 16; i++ {
-		areas = append(areas, checksumArea{})
+	res, err := CalculateChecksum(bin)
+	if err != nil {
+		return err
+	}
+	if !res.OK() {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, res)
+	}
+	return nil
+}
+
+// FixChecksum writes the calculated F2 & FB checksums into the footer of bin
+func FixChecksum(bin []byte) error {
+	res, err := CalculateChecksum(bin)
+	if err != nil {
+		return err
+	}
+	if res.HasF2 && res.F2Stored != res.F2Calculated {
+		if err := setFooterUint32(bin, 0xF2, res.F2Calculated); err != nil {
+			return err
+		}
+	}
+	if res.FBStored != res.FBCalculated {
+		if err := setFooterUint32(bin, 0xFB, res.FBCalculated); err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+// The checksum area table is coded, every byte is decoded with (b + 0xD6) ^ 0x21.
+// Each entry is a 32 bit address followed by a 16 bit length
+func readChecksumAreas(bin []byte, fwLength int) ([]checksumArea, error) {
+	offset := int(binary.BigEndian.Uint32(bin[checksumAreaPointer:]))
+	if offset <= 0 || offset+checksumAreaCount*checksumAreaSize > fwLength {
+		return nil, fmt.Errorf("invalid checksum area offset: 0x%X", offset)
+	}
+
+	decoded := make([]byte, checksumAreaCount*checksumAreaSize)
+	for i := range decoded {
+		decoded[i] = (bin[offset+i] + 0xD6) ^ 0x21
+	}
+
+	var areas []checksumArea
+	for i := 0; i < checksumAreaCount; i++ {
+		area := checksumArea{
+			addr:   binary.BigEndian.Uint32(decoded[i*checksumAreaSize:]),
+			length: binary.BigEndian.Uint16(decoded[i*checksumAreaSize+4:]),
+		}
+		if area.length == 0 {
+			continue
+		}
+		if int(area.addr)+int(area.length) > fwLength {
+			return nil, fmt.Errorf("checksum area %d out of range: 0x%X+0x%X", i, area.addr, area.length)
+		}
+		areas = append(areas, area)
+	}
+
+	if len(areas) == 0 {
+		return nil, errors.New("no checksum areas found")
+	}
+
+	return areas, nil
+}
+
+// FB is the byte sum of all checksum areas
+func calculateFBChecksum(bin []byte, areas []checksumArea) uint32 {
+	var checksum uint32
+	for _, area := range areas {
+		for _, b := range bin[area.addr : area.addr+uint32(area.length)] {
+			checksum += uint32(b)
+		}
+	}
+	return checksum
+}
+
+// F2 is the sum of all dwords up to the firmware length xored with a rolling table
+func calculateF2Checksum(bin []byte, fwLength int) uint32 {
+	var checksum uint32
+	xorCount := 1
+	for i := 0; i+4 <= fwLength; i += 4 {
+		checksum += binary.BigEndian.Uint32(bin[i:]) ^ f2XorTable[xorCount]
+		xorCount++
+		if xorCount > 7 {
+			xorCount = 0
+		}
+	}
+	checksum ^= 0x40314081
+	checksum -= 0x7FEFDFD0
+	return checksum
+}
+
+// setFooterUint32 overwrites the value of an existing 4 byte footer field
+func setFooterUint32(bin []byte, id byte, value uint32) error {
+	binLength := len(bin)
+	addr := binLength - 1
+	for addr > (binLength - footerSize) {
+		fieldLength := bin[addr]
+		if fieldLength == 0x00 || fieldLength == 0xFF {
+			break
+		}
+		addr--
+		fieldID := bin[addr]
+		addr--
+		if fieldID == id {
+			if fieldLength != 4 {
+				return fmt.Errorf("footer field 0x%02X has unexpected length %d", id, fieldLength)
+			}
+			b := make([]byte, 4)
+			binary.BigEndian.PutUint32(b, value)
+			for i := range b {
+				bin[addr-i] = b[i]
+			}
+			return nil
+		}
+		addr -= int(fieldLength)
+	}
+	return fmt.Errorf("did not find footer field 0x%02X", id)
+}
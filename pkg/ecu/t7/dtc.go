@@ -2,6 +2,7 @@ package t7
 
 import (
 	"context"
+	"errors"
 
 	"github.com/roffe/txlogger/pkg/dtc"
 )
@@ -20,3 +21,7 @@ func (t *Client) ReadDTC(ctx context.Context) ([]dtc.DTC, error) {
 
 	return nil, nil
 }
+
+func (t *Client) ClearDTC(ctx context.Context) error {
+	return errors.New("not implemented yet")
+}
@@ -53,7 +53,7 @@ func (t *Client) readECU(ctx context.Context, addr, length int) ([]byte, error)
 				return nil
 			},
 				retry.Context(ctx),
-				retry.Attempts(3),
+				retry.Attempts(t.cfg.Flash.Attempts(3)),
 				retry.OnRetry(func(n uint, err error) {
 					t.cfg.OnMessage(fmt.Sprintf("Failed to read memory by address, pos: 0x%X, length: 0x%X, retrying: %v", readPos, readLength, err))
 				}),
@@ -105,11 +105,11 @@ func (t *Client) FlashECU(ctx context.Context, bin []byte) error {
 			return nil
 		},
 			retry.Context(ctx),
-			retry.Attempts(3),
+			retry.Attempts(t.cfg.Flash.Attempts(3)),
 			retry.OnRetry(func(n uint, err error) {
 				t.cfg.OnMessage(fmt.Sprintf("retrying writeRange: %v", err))
 			}),
-			retry.Delay(150*time.Millisecond),
+			retry.Delay(t.cfg.Flash.Delay(150*time.Millisecond)),
 			retry.LastErrorOnly(true),
 		)
 		if err != nil {
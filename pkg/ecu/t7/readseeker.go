@@ -1,8 +1,21 @@
+package t7
+
+import (
+	"errors"
+	"io"
+)
+
+// ReadWriteSeeker is an in-memory io.ReadWriteSeeker used to run the footer
+// parsing helpers against a bin that has already been loaded into memory.
 type ReadWriteSeeker struct {
 	buf []byte
 	pos int
 }
 
+func NewReadWriteSeeker(buf []byte) *ReadWriteSeeker {
+	return &ReadWriteSeeker{buf: buf}
+}
+
 func (m *ReadWriteSeeker) Byte() []byte {
 	return m.buf
 }
@@ -48,4 +61,4 @@ func (m *ReadWriteSeeker) Seek(offset int64, whence int) (int64, error) {
 	}
 	m.pos = newPos
 	return int64(newPos), nil
-}
\ No newline at end of file
+}
@@ -1,6 +1,7 @@
 package t7
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -186,32 +187,76 @@ func NewFileHeader(filename string, autoFixFooter bool) (*FileHeader, error) {
 	fh.SetLastModifiedBy(0xFF, 4)
 	fh.testSerialNr = "050225"
 
-	if _, err := file.Seek(-1, io.SeekEnd); err != nil {
+	if err := fh.readFields(file); err != nil {
 		return nil, err
 	}
 
+	if (fh.chassisIDCounter > 1 || !fh.immoCodeDetected || !fh.chassisIDDetected) && autoFixFooter {
+		log.Println("bad footer detected & auto fix enabled")
+		fh.clearFooter(file)
+		fh.createNewFooter(file, fh.symbolTableMarkerDetected, fh.symbolTableChecksumDetected, fh.f2ChecksumDetected)
+	}
+
+	log.Printf("%+v", fh)
+
+	return fh, nil
+}
+
+// ParseFileHeader reads the footer of a T7 bin that has already been loaded into memory.
+// Unlike NewFileHeader it never modifies the bin.
+func ParseFileHeader(bin []byte) (*FileHeader, error) {
+	if len(bin) < footerSize {
+		return nil, fmt.Errorf("bin too small to contain a footer: %d bytes", len(bin))
+	}
+	fh := new(FileHeader)
+	if err := fh.readFields(NewReadWriteSeeker(bin)); err != nil {
+		return nil, err
+	}
+	return fh, nil
+}
+
+// footerSize is the maximum number of bytes at the end of the bin the footer may occupy
+const footerSize = 0x200
+
+func (fh *FileHeader) readFields(file io.ReadWriteSeeker) error {
+	end, err := file.Seek(-1, io.SeekEnd)
+	if err != nil {
+		return err
+	}
 	for {
+		pos, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if end-pos >= footerSize {
+			return errors.New("footer end marker not found")
+		}
 		fhf, err := ReadField(file)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if fhf.ID == 0xFF || fhf.ID == 0x00 {
-			break
+			return nil
+		}
+		if err := fh.parseField(fhf); err != nil {
+			return err
 		}
-		fh.parseField(fhf)
 	}
+}
 
-	if (fh.chassisIDCounter > 1 || !fh.immoCodeDetected || !fh.chassisIDDetected) && autoFixFooter {
-		log.Println("bad footer detected & auto fix enabled")
-		fh.clearFooter(file)
-		fh.createNewFooter(file, fh.symbolTableMarkerDetected, fh.symbolTableChecksumDetected, fh.f2ChecksumDetected)
-	}
+func (fh *FileHeader) ChecksumF2() (int, bool) {
+	return fh.checksumF2, fh.f2ChecksumDetected
+}
 
-	log.Printf("%+v", fh)
+func (fh *FileHeader) ChecksumFB() int {
+	return fh.checksumFB
+}
 
-	return fh, nil
+func (fh *FileHeader) FWLength() int {
+	return fh.fwLength
 }
-func (fh *FileHeader) parseField(fhf *FileHeaderField) {
+
+func (fh *FileHeader) parseField(fhf *FileHeaderField) error {
 	switch fhf.ID {
 	case 0x90:
 		fh.chassisID = fhf.String()
@@ -266,8 +311,9 @@ func (fh *FileHeader) parseField(fhf *FileHeaderField) {
 	case 0xFE:
 		fh.fwLength = fhf.Int()
 	default:
-		panic(fmt.Sprintf("Unknown ID: 0x%02X", fhf.ID))
+		return fmt.Errorf("unknown footer ID: 0x%02X", fhf.ID)
 	}
+	return nil
 }
 
 func (f *FileHeader) clearFooter(file io.ReadWriteSeeker) {
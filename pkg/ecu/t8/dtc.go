@@ -4,38 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 
-	"github.com/roffe/gocan/pkg/gmlan"
 	"github.com/roffe/txlogger/pkg/dtc"
+	"github.com/roffe/txlogger/pkg/ecu/gmlandtc"
 )
 
 func (t *Client) ReadDTC(ctx context.Context) ([]dtc.DTC, error) {
-	t.gm.TesterPresentNoResponseAllowed()
-
-	if err := t.gm.InitiateDiagnosticOperation(ctx, gmlan.LEV_DADTC); err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		_ = t.gm.ReturnToNormalMode(ctx)
-		time.Sleep(75 * time.Millisecond)
-	}()
-
-	dtcs, err := t.gm.ReadDiagnosticInformationStatusOfDTCByStatusMask(ctx, 0x12)
-	if err != nil {
-		return nil, err
-	}
-
-	var out []dtc.DTC
-	for _, f := range dtcs {
-		out = append(out, dtc.DTC{
-			Code:   f.Code,
-			Status: f.Status,
-		})
-	}
+	return gmlandtc.Read(ctx, t.gm, dtc.ECU_T8)
+}
 
-	return out, nil
+func (t *Client) ClearDTC(ctx context.Context) error {
+	return gmlandtc.Clear(ctx, t.gm, 0x7E0)
 }
 
 // How to read DTC codes
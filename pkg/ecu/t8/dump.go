@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/roffe/txlogger/pkg/ecu"
 	"github.com/roffe/txlogger/pkg/ecu/t8legion"
 )
 
@@ -24,19 +25,21 @@ func (t *Client) DumpECU(ctx context.Context) ([]byte, error) {
 		return nil, err
 	}
 
-	t.cfg.OnMessage("Verifying md5..")
+	if t.cfg.Flash.Verify == ecu.VerifyMD5 {
+		t.cfg.OnMessage("Verifying md5..")
 
-	ecuMD5bytes, err := t.legion.IDemand(ctx, t8legion.GetTrionic8MD5, 0x00)
-	if err != nil {
-		return nil, err
-	}
-	calculatedMD5 := md5.Sum(bin)
+		ecuMD5bytes, err := t.legion.IDemand(ctx, t8legion.GetTrionic8MD5, 0x00)
+		if err != nil {
+			return nil, err
+		}
+		calculatedMD5 := md5.Sum(bin)
 
-	t.cfg.OnMessage(fmt.Sprintf("Remote MD5 : %X", ecuMD5bytes))
-	t.cfg.OnMessage(fmt.Sprintf("Local MD5  : %X", calculatedMD5))
+		t.cfg.OnMessage(fmt.Sprintf("Remote MD5 : %X", ecuMD5bytes))
+		t.cfg.OnMessage(fmt.Sprintf("Local MD5  : %X", calculatedMD5))
 
-	if !bytes.Equal(ecuMD5bytes, calculatedMD5[:]) {
-		return nil, errors.New("md5 Verification failed")
+		if !bytes.Equal(ecuMD5bytes, calculatedMD5[:]) {
+			return nil, errors.New("md5 Verification failed")
+		}
 	}
 
 	t.cfg.OnMessage("Done, took: " + time.Since(start).String())
@@ -54,8 +54,8 @@ func (t *Client) ResetECU(ctx context.Context) error {
 		err := retry.Do(func() error {
 			return t.legion.Exit(ctx)
 		},
-			retry.Attempts(3),
-			retry.Delay(400*time.Millisecond),
+			retry.Attempts(t.cfg.Flash.Attempts(3)),
+			retry.Delay(t.cfg.Flash.Delay(400*time.Millisecond)),
 			retry.Context(ctx),
 			retry.LastErrorOnly(true),
 		)
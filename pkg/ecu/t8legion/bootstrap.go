@@ -30,8 +30,8 @@ func (t *Client) Alive(ctx context.Context) bool {
 		retry.OnRetry(func(n uint, err error) {
 			log.Printf("retrying %d: %s", n, err)
 		}),
-		retry.Attempts(3),
-		retry.Delay(200*time.Millisecond),
+		retry.Attempts(t.cfg.Flash.Attempts(3)),
+		retry.Delay(t.cfg.Flash.Delay(200*time.Millisecond)),
 		retry.Context(ctx),
 		retry.LastErrorOnly(true),
 	)
@@ -73,15 +73,20 @@ func (t *Client) Bootstrap(ctx context.Context, z22se bool) error {
 		t.legionRunning = t.Alive(ctx)
 	}
 
-	if t.legionRunning {
-		t.cfg.OnMessage("enabling high speed mode")
-		if err := t.EnableHighSpeed(ctx); err != nil {
-			return err
-		}
-	} else {
+	if !t.legionRunning {
 		return errors.New("legion is not running")
 	}
 
+	if t.cfg.Flash.NoHighSpeed {
+		t.cfg.OnMessage("high speed mode disabled")
+		return nil
+	}
+
+	t.cfg.OnMessage("enabling high speed mode")
+	if err := t.EnableHighSpeed(ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
 
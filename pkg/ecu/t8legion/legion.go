@@ -24,6 +24,9 @@ var (
 	EcuByte_T8  byte = 6
 )
 
+// Failed attempts of a single block before WriteFlash gives up
+const maxBlockFailures = 20
+
 type Client struct {
 	c                 *gocan.Client
 	defaultTimeout    time.Duration
@@ -437,7 +440,8 @@ func (t *Client) IDemand(ctx context.Context, command Command, wish uint16) ([]b
 		}
 	},
 		retry.Context(ctx),
-		retry.Attempts(10),
+		retry.Attempts(t.cfg.Flash.Attempts(10)),
+		retry.Delay(t.cfg.Flash.Delay(100*time.Millisecond)),
 		retry.LastErrorOnly(true),
 		//retry.OnRetry(func(n uint, err error) {
 		//	log.Printf("#%d: %v", n, err)
@@ -486,7 +490,8 @@ func (t *Client) ReadFlash(ctx context.Context, device byte, lastAddress int) ([
 				}
 				return nil
 			},
-			retry.Attempts(10),
+			retry.Attempts(t.cfg.Flash.Attempts(10)),
+			retry.Delay(t.cfg.Flash.Delay(100*time.Millisecond)),
 			retry.Context(ctx),
 			retry.OnRetry(func(n uint, err error) {
 				t.cfg.OnError(fmt.Errorf("retrying read flash: #%d %w", n, err))
@@ -507,24 +512,34 @@ func (t *Client) ReadFlash(ctx context.Context, device byte, lastAddress int) ([
 }
 
 func (t *Client) VerifyFlash(ctx context.Context, file []byte, device byte, fmask uint64) (bool, error) {
-	start := uint32(0)
-	eq := bool(true)
-	md5type := Command(GetTrionic8MD5)
-	if device == 5 {
-		md5type = GetTrionic8MCPMD5
+	mismatch, err := t.MismatchedPartitions(ctx, file, device, fmask)
+	if err != nil {
+		return false, err
 	}
-	for i := start; i <= 9; i++ {
-		if fmask&uint64(1<<(i-1)) == 0 {
-			continue
-		}
-		lmd5 := t8util.GetPartitionMD5(file, device, int(i))
-		md5, err := t.GetMD5(ctx, md5type, uint16(i))
-		if err != nil {
-			return false, err
-		}
-		eq = eq && bytes.Equal(lmd5, md5)
+	return mismatch == 0, nil
+}
+
+// CheckFlash verifies the written partitions if the verify mode asks for it and reports the result
+func (t *Client) CheckFlash(ctx context.Context, file []byte, device byte, fmask uint64) error {
+	result := ecu.FlashResult{
+		Partitions: ecu.MaskPartitions(fmask),
+		Verify:     t.cfg.Flash.Verify,
+	}
+	if t.cfg.Flash.Verify != ecu.VerifyMD5 {
+		t.cfg.OnFlashResult(result)
+		return nil
 	}
-	return eq, nil
+	status, err := t.VerifyFlash(ctx, file, device, fmask)
+	if err != nil {
+		return err
+	}
+	result.Verified = status
+	t.cfg.OnFlashResult(result)
+	if !status {
+		return errors.New("failed md5 verification")
+	}
+	t.cfg.OnMessage("Verifying md5: sucess")
+	return nil
 }
 
 func (t *Client) DeterminePartitionmask(ctx context.Context, file []byte, device byte, boot, nvdm, z22se bool) (uint64, error) {
@@ -553,6 +568,11 @@ func (t *Client) DeterminePartitionmask(ctx context.Context, file []byte, device
 
 	t.cfg.OnMessage(fmt.Sprintf("Partition's MD5 missmath mask: %b", formatmask))
 
+	if len(t.cfg.Flash.Partitions) > 0 {
+		formatmask = t.cfg.Flash.PartitionMask(formatmask)
+		t.cfg.OnMessage(fmt.Sprintf("Limited to partitions %v, mask: %b", t.cfg.Flash.Partitions, formatmask))
+	}
+
 	if !z22se {
 		if !boot {
 			formatmask &= uint64(0x1FE)
@@ -587,6 +607,8 @@ func (t *Client) WriteFlash(ctx context.Context, device byte, lastAddress int, f
 	var lastBlockNumber int = (lastAddress / 0x80) - 1
 	var byteswapped bool = false
 	var problem bool = false
+	// consecutive failed attempts of the current block
+	var failures int
 
 	t.cfg.OnProgress(-float64(lastBlockNumber))
 	t.cfg.OnProgress(float64(0))
@@ -596,6 +618,9 @@ func (t *Client) WriteFlash(ctx context.Context, device byte, lastAddress int, f
 	}
 
 	for blockNumber <= lastBlockNumber {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		problem = false
 		var currentAddress int = startAddress + (blockNumber * 0x80)
 		data2Send := t8util.GetCurrentBlock(flashData, blockNumber, byteswapped)
@@ -627,24 +652,91 @@ func (t *Client) WriteFlash(ctx context.Context, device byte, lastAddress int, f
 						return err
 					}
 				}
+				// a missing response counts as a failed attempt of the block like a bad one
 				resp, err := t.c.Recv(ctx, time.Millisecond*150, 0x7E8)
-				if err != nil {
-					problem = true
-					continue
-				}
-				if resp.Data[0] != 0x01 && resp.Data[1] != 0x76 {
+				if err != nil || (resp.Data[0] != 0x01 && resp.Data[1] != 0x76) {
 					problem = true
 				}
 			}
 		}
-		if !problem {
-			blockNumber++
-			t.cfg.OnProgress(float64(blockNumber))
+		if problem {
+			failures++
+			if failures >= maxBlockFailures {
+				return fmt.Errorf("failed to write block at 0x%X", currentAddress)
+			}
+			continue
 		}
+		failures = 0
+		blockNumber++
+		t.cfg.OnProgress(float64(blockNumber))
 	}
 	return nil
 }
 
+// MismatchedPartitions returns the partitions of fmask whose MD5 in the ECU doesn't match the file
+func (t *Client) MismatchedPartitions(ctx context.Context, file []byte, device byte, fmask uint64) (uint64, error) {
+	md5type := Command(GetTrionic8MD5)
+	if device == 5 {
+		md5type = GetTrionic8MCPMD5
+	}
+	var mismatch uint64
+	for i := 1; i <= 9; i++ {
+		if fmask&(1<<(i-1)) == 0 {
+			continue
+		}
+		md5, err := t.GetMD5(ctx, md5type, uint16(i))
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(t8util.GetPartitionMD5(file, device, i), md5) {
+			mismatch |= 1 << (i - 1)
+		}
+	}
+	if device == 5 {
+		// the last MCP partition is erased together with the first
+		mismatch |= (mismatch & 1) << 8
+		mismatch &= fmask
+	}
+	return mismatch, nil
+}
+
+// WritePartitions erases and writes the partitions of fmask. If it fails while
+// the bootloader is still running the partitions are compared with the file and
+// only the ones that don't match are written again
+func (t *Client) WritePartitions(ctx context.Context, device byte, lastAddress int, file []byte, fmask uint64) error {
+	attempts := t.cfg.Flash.Attempts(3)
+	for n := uint(1); ; n++ {
+		err := t.EraseFlash(ctx, device, fmask)
+		if err == nil {
+			err = t.WriteFlash(ctx, device, lastAddress, file, fmask)
+		}
+		if err == nil {
+			return nil
+		}
+		if n >= attempts || ctx.Err() != nil {
+			return err
+		}
+		t.cfg.OnError(fmt.Errorf("flash failed: %w", err))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(t.cfg.Flash.Delay(time.Second)):
+		}
+		if !t.Alive(ctx) {
+			return fmt.Errorf("bootloader lost after: %w", err)
+		}
+		fmask, err = t.MismatchedPartitions(ctx, file, device, fmask)
+		if err != nil {
+			return err
+		}
+		if fmask == 0 {
+			t.cfg.OnMessage("All partitions match the file")
+			return nil
+		}
+		t.cfg.OnMessage(fmt.Sprintf("Resuming, rewriting partitions %v", ecu.MaskPartitions(fmask)))
+	}
+}
+
 func (t *Client) EraseFlash(ctx context.Context, device byte, formatMask uint64) error {
 	if !t.legionRunning {
 		return fmt.Errorf("legion not running")
@@ -8,13 +8,11 @@ import (
 	"fmt"
 	"time"
 
-	"fyne.io/fyne/v2"
 	"github.com/roffe/gocan"
 	"github.com/roffe/txlogger/pkg/dtc"
 	"github.com/roffe/txlogger/pkg/ecu"
 	"github.com/roffe/txlogger/pkg/ecu/t8legion"
 	"github.com/roffe/txlogger/pkg/model"
-	"github.com/roffe/txlogger/pkg/widgets/settings"
 )
 
 func init() {
@@ -47,6 +45,10 @@ func (t *Client) ReadDTC(ctx context.Context) ([]dtc.DTC, error) {
 	return nil, errors.New("MCP cannot do this")
 }
 
+func (t *Client) ClearDTC(ctx context.Context) error {
+	return errors.New("MCP cannot do this")
+}
+
 func (t *Client) Info(ctx context.Context) ([]model.HeaderResult, error) {
 	if err := t.legion.Bootstrap(ctx, false); err != nil {
 		return nil, err
@@ -71,6 +73,10 @@ func (t *Client) PrintECUInfo(ctx context.Context) error {
 	return nil
 }
 
+func (t *Client) InBootloader(ctx context.Context) bool {
+	return t.legion.Alive(ctx)
+}
+
 func (t *Client) FlashECU(ctx context.Context, bin []byte) error {
 	if len(bin) != 0x40100 {
 		return errors.New("err: Invalid T8 MCP file size")
@@ -81,8 +87,7 @@ func (t *Client) FlashECU(ctx context.Context, bin []byte) error {
 	if err := t.legion.StartSecondaryBootloader(ctx); err != nil {
 		return err
 	}
-	boot := fyne.CurrentApp().Preferences().BoolWithFallback(settings.PrefsBoot, false)
-	fmask, err := t.legion.DeterminePartitionmask(ctx, bin, t8legion.EcuByte_MCP, boot, true, false)
+	fmask, err := t.legion.DeterminePartitionmask(ctx, bin, t8legion.EcuByte_MCP, t.cfg.Flash.Boot, true, false)
 	if err != nil {
 		return err
 	}
@@ -90,25 +95,16 @@ func (t *Client) FlashECU(ctx context.Context, bin []byte) error {
 		t.cfg.OnMessage("Noting to flash, ecu and local bin are same.. returning")
 		return nil
 	}
-	err = t.legion.EraseFlash(ctx, t8legion.EcuByte_MCP, fmask)
-	if err != nil {
-		return err
-	}
 	start := time.Now()
-	err = t.legion.WriteFlash(ctx, t8legion.EcuByte_MCP, 0x40100, bin, fmask)
+	err = t.legion.WritePartitions(ctx, t8legion.EcuByte_MCP, 0x40100, bin, fmask)
 	if err != nil {
 		return err
 	}
 	t.cfg.OnMessage("Done, took: " + time.Since(start).String())
 
-	status, err := t.legion.VerifyFlash(ctx, bin, t8legion.EcuByte_MCP, fmask)
-	if err != nil {
+	if err := t.legion.CheckFlash(ctx, bin, t8legion.EcuByte_MCP, fmask); err != nil {
 		return err
 	}
-	if !status {
-		return errors.New("failed md5 verification")
-	}
-	t.cfg.OnMessage("Verifying md5: sucess")
 
 	err = t.legion.MarryMCP(ctx)
 
@@ -132,17 +128,19 @@ func (t *Client) DumpECU(ctx context.Context) ([]byte, error) {
 		return nil, err
 	}
 
-	ecumd5bytes, err := t.legion.IDemand(ctx, t8legion.GetTrionic8MCPMD5, 0x00)
-	if err != nil {
-		return nil, err
-	}
-	calculatedMD5 := md5.Sum(bin)
+	if t.cfg.Flash.Verify == ecu.VerifyMD5 {
+		ecumd5bytes, err := t.legion.IDemand(ctx, t8legion.GetTrionic8MCPMD5, 0x00)
+		if err != nil {
+			return nil, err
+		}
+		calculatedMD5 := md5.Sum(bin)
 
-	t.cfg.OnMessage(fmt.Sprintf("Remote md5 : %X", ecumd5bytes))
-	t.cfg.OnMessage(fmt.Sprintf("Local md5  : %X", calculatedMD5))
+		t.cfg.OnMessage(fmt.Sprintf("Remote md5 : %X", ecumd5bytes))
+		t.cfg.OnMessage(fmt.Sprintf("Local md5  : %X", calculatedMD5))
 
-	if !bytes.Equal(ecumd5bytes, calculatedMD5[:]) {
-		return nil, errors.New("md5 Verification failed")
+		if !bytes.Equal(ecumd5bytes, calculatedMD5[:]) {
+			return nil, errors.New("md5 Verification failed")
+		}
 	}
 
 	t.cfg.OnMessage("Done, took: " + time.Since(start).String())
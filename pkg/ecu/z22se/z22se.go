@@ -13,7 +13,6 @@ import (
 	"github.com/avast/retry-go/v4"
 	"github.com/roffe/gocan"
 	"github.com/roffe/gocan/pkg/gmlan"
-	"github.com/roffe/txlogger/pkg/dtc"
 	"github.com/roffe/txlogger/pkg/ecu"
 	"github.com/roffe/txlogger/pkg/ecu/t8legion"
 	"github.com/roffe/txlogger/pkg/ecu/t8sec"
@@ -36,11 +35,6 @@ type Client struct {
 	cfg            *ecu.Config
 }
 
-// ReadDTC implements [ecu.Client].
-func (t *Client) ReadDTC(context.Context) ([]dtc.DTC, error) {
-	return nil, nil
-}
-
 func New(c *gocan.Client, cfg *ecu.Config) ecu.Client {
 	t := &Client{
 		c:              c,
@@ -61,8 +55,8 @@ func (t *Client) ResetECU(ctx context.Context) error {
 		err := retry.Do(func() error {
 			return t.legion.Exit(ctx)
 		},
-			retry.Attempts(3),
-			retry.Delay(400*time.Millisecond),
+			retry.Attempts(t.cfg.Flash.Attempts(3)),
+			retry.Delay(t.cfg.Flash.Delay(400*time.Millisecond)),
 			retry.Context(ctx),
 			retry.LastErrorOnly(true),
 		)
@@ -86,19 +80,21 @@ func (t *Client) DumpECU(ctx context.Context) ([]byte, error) {
 		return nil, err
 	}
 
-	t.cfg.OnMessage("Verifying md5..")
+	if t.cfg.Flash.Verify == ecu.VerifyMD5 {
+		t.cfg.OnMessage("Verifying md5..")
 
-	ecuMD5bytes, err := t.legion.IDemand(ctx, t8legion.GetTrionic8MD5, 0x00)
-	if err != nil {
-		return nil, err
-	}
-	calculatedMD5 := md5.Sum(bin)
+		ecuMD5bytes, err := t.legion.IDemand(ctx, t8legion.GetTrionic8MD5, 0x00)
+		if err != nil {
+			return nil, err
+		}
+		calculatedMD5 := md5.Sum(bin)
 
-	t.cfg.OnMessage(fmt.Sprintf("Remote MD5 : %X", ecuMD5bytes))
-	t.cfg.OnMessage(fmt.Sprintf("Local MD5  : %X", calculatedMD5))
+		t.cfg.OnMessage(fmt.Sprintf("Remote MD5 : %X", ecuMD5bytes))
+		t.cfg.OnMessage(fmt.Sprintf("Local MD5  : %X", calculatedMD5))
 
-	if !bytes.Equal(ecuMD5bytes, calculatedMD5[:]) {
-		return nil, errors.New("md5 Verification failed")
+		if !bytes.Equal(ecuMD5bytes, calculatedMD5[:]) {
+			return nil, errors.New("md5 Verification failed")
+		}
 	}
 
 	t.cfg.OnMessage("Done, took: " + time.Since(start).String())
@@ -106,6 +102,10 @@ func (t *Client) DumpECU(ctx context.Context) ([]byte, error) {
 	return bin, nil
 }
 
+func (t *Client) InBootloader(ctx context.Context) bool {
+	return t.legion.Alive(ctx)
+}
+
 func (t *Client) FlashECU(ctx context.Context, bin []byte) error {
 	if len(bin) != 0x100000 {
 		return errors.New("err: Invalid Z22SE file size")
@@ -124,25 +124,16 @@ func (t *Client) FlashECU(ctx context.Context, bin []byte) error {
 		return nil
 	}
 
-	err = t.legion.EraseFlash(ctx, t8legion.EcuByte_T8, fmask)
-	if err != nil {
-		return err
-	}
 	start := time.Now()
-	err = t.legion.WriteFlash(ctx, t8legion.EcuByte_T8, 0x100000, bin, fmask)
+	err = t.legion.WritePartitions(ctx, t8legion.EcuByte_T8, 0x100000, bin, fmask)
 	if err != nil {
 		return err
 	}
 	t.cfg.OnMessage("Done, took: " + time.Since(start).String())
 
-	status, err := t.legion.VerifyFlash(ctx, bin, t8legion.EcuByte_T8, fmask)
-	if err != nil {
+	if err := t.legion.CheckFlash(ctx, bin, t8legion.EcuByte_T8, fmask); err != nil {
 		return err
 	}
-	if !status {
-		return errors.New("failed md5 verification")
-	}
-	t.cfg.OnMessage("Verifying md5: sucess")
 
 	return nil
 }
@@ -0,0 +1,16 @@
+package z22semcp
+
+import (
+	"context"
+
+	"github.com/roffe/txlogger/pkg/dtc"
+	"github.com/roffe/txlogger/pkg/ecu/gmlandtc"
+)
+
+func (t *Client) ReadDTC(ctx context.Context) ([]dtc.DTC, error) {
+	return gmlandtc.Read(ctx, t.gm, dtc.ECU_Z22SE)
+}
+
+func (t *Client) ClearDTC(ctx context.Context) error {
+	return gmlandtc.Clear(ctx, t.gm, 0x7E0)
+}
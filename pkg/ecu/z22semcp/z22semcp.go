@@ -9,7 +9,7 @@ import (
 	"time"
 
 	"github.com/roffe/gocan"
-	"github.com/roffe/txlogger/pkg/dtc"
+	"github.com/roffe/gocan/pkg/gmlan"
 	"github.com/roffe/txlogger/pkg/ecu"
 	"github.com/roffe/txlogger/pkg/ecu/t8legion"
 	"github.com/roffe/txlogger/pkg/model"
@@ -20,7 +20,7 @@ func init() {
 		Name:    "Z22SE MCP",
 		NewFunc: New,
 		CANRate: 500,
-		Filter:  []uint32{0x7E8},
+		Filter:  []uint32{0x5E8, 0x7E8},
 	})
 }
 
@@ -29,6 +29,7 @@ type Client struct {
 	cfg            *ecu.Config
 	defaultTimeout time.Duration
 	legion         *t8legion.Client
+	gm             *gmlan.Client
 }
 
 func New(c *gocan.Client, cfg *ecu.Config) ecu.Client {
@@ -37,14 +38,11 @@ func New(c *gocan.Client, cfg *ecu.Config) ecu.Client {
 		cfg:            ecu.LoadConfig(cfg),
 		defaultTimeout: 150 * time.Millisecond,
 		legion:         t8legion.New(c, cfg, 0x7e0, 0x7e8),
+		gm:             gmlan.New(c, 0x7e0, 0x5e8, 0x7e8),
 	}
 	return t
 }
 
-func (t *Client) ReadDTC(ctx context.Context) ([]dtc.DTC, error) {
-	return nil, errors.New("MCP cannot do this")
-}
-
 func (t *Client) Info(ctx context.Context) ([]model.HeaderResult, error) {
 	return nil, nil
 }
@@ -53,6 +51,10 @@ func (t *Client) PrintECUInfo(ctx context.Context) error {
 	return nil
 }
 
+func (t *Client) InBootloader(ctx context.Context) bool {
+	return t.legion.Alive(ctx)
+}
+
 func (t *Client) FlashECU(ctx context.Context, bin []byte) error {
 	if len(bin) != 0x40100 {
 		return errors.New("err: Invalid Z22SE MCP file size")
@@ -71,25 +73,16 @@ func (t *Client) FlashECU(ctx context.Context, bin []byte) error {
 		t.cfg.OnMessage("Noting to flash, ecu and local bin are same.. returning")
 		return nil
 	}
-	err = t.legion.EraseFlash(ctx, t8legion.EcuByte_MCP, fmask)
-	if err != nil {
-		return err
-	}
 	start := time.Now()
-	err = t.legion.WriteFlash(ctx, t8legion.EcuByte_MCP, 0x40100, bin, fmask)
+	err = t.legion.WritePartitions(ctx, t8legion.EcuByte_MCP, 0x40100, bin, fmask)
 	if err != nil {
 		return err
 	}
 	t.cfg.OnMessage("Done, took: " + time.Since(start).String())
 
-	status, err := t.legion.VerifyFlash(ctx, bin, t8legion.EcuByte_MCP, fmask)
-	if err != nil {
+	if err := t.legion.CheckFlash(ctx, bin, t8legion.EcuByte_MCP, fmask); err != nil {
 		return err
 	}
-	if !status {
-		return errors.New("failed md5 verification")
-	}
-	t.cfg.OnMessage("Verifying md5: sucess")
 	return nil
 }
 
@@ -110,17 +103,19 @@ func (t *Client) DumpECU(ctx context.Context) ([]byte, error) {
 		return nil, err
 	}
 
-	ecumd5bytes, err := t.legion.IDemand(ctx, t8legion.GetTrionic8MCPMD5, 0x00)
-	if err != nil {
-		return nil, err
-	}
-	calculatedMD5 := md5.Sum(bin)
+	if t.cfg.Flash.Verify == ecu.VerifyMD5 {
+		ecumd5bytes, err := t.legion.IDemand(ctx, t8legion.GetTrionic8MCPMD5, 0x00)
+		if err != nil {
+			return nil, err
+		}
+		calculatedMD5 := md5.Sum(bin)
 
-	t.cfg.OnMessage(fmt.Sprintf("Remote md5 : %X", ecumd5bytes))
-	t.cfg.OnMessage(fmt.Sprintf("Local md5  : %X", calculatedMD5))
+		t.cfg.OnMessage(fmt.Sprintf("Remote md5 : %X", ecumd5bytes))
+		t.cfg.OnMessage(fmt.Sprintf("Local md5  : %X", calculatedMD5))
 
-	if !bytes.Equal(ecumd5bytes, calculatedMD5[:]) {
-		return nil, errors.New("md5 Verification failed")
+		if !bytes.Equal(ecumd5bytes, calculatedMD5[:]) {
+			return nil, errors.New("md5 Verification failed")
+		}
 	}
 
 	t.cfg.OnMessage("Done, took: " + time.Since(start).String())
@@ -1,5 +1,14 @@
 package eventbus
 
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
 type EventAggregatorFunc func(c DiffPublisher, name string, value float64)
 
 type DiffPublisher interface {
@@ -41,3 +50,66 @@ func DIFFAggregator(first, second, output string) *EventAggregator {
 		},
 	}
 }
+
+// DerivedChannel is a virtual topic calculated from other topics, defined as "name = expression"
+type DerivedChannel struct {
+	Name string
+	Expr *Expression
+}
+
+// ParseDerivedChannel parses a channel definition such as "AFR = Lambda.External * 14.7"
+func ParseDerivedChannel(def string) (*DerivedChannel, error) {
+	name, expr, found := strings.Cut(def, "=")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return nil, fmt.Errorf("invalid channel %q, expected name = expression", def)
+	}
+	// "a == b" without a name
+	if strings.HasPrefix(expr, "=") {
+		return nil, fmt.Errorf("invalid channel %q, expected name = expression", def)
+	}
+	e, err := ParseExpression(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(e.Topics()) == 0 {
+		return nil, fmt.Errorf("%s: expression does not use any topics", name)
+	}
+	if slices.Contains(e.Topics(), name) {
+		return nil, fmt.Errorf("%s: channel can't reference itself", name)
+	}
+	return &DerivedChannel{Name: name, Expr: e}, nil
+}
+
+// ExpressionAggregator publishes the derived channel every time all of its topics has been updated
+func ExpressionAggregator(dc *DerivedChannel) *EventAggregator {
+	topics := dc.Expr.Topics()
+	values := make(map[string]float64, len(topics))
+	updated := make(map[string]bool, len(topics))
+	var errLogged bool
+
+	return &EventAggregator{
+		topics: topics,
+		fun: func(c DiffPublisher, name string, value float64) {
+			values[name] = value
+			updated[name] = true
+			if len(updated) < len(topics) {
+				return
+			}
+			clear(updated)
+			res, err := dc.Expr.Eval(values, time.Now())
+			if err == nil && (math.IsNaN(res) || math.IsInf(res, 0)) {
+				err = fmt.Errorf("result is %g", res)
+			}
+			if err != nil {
+				if !errLogged {
+					log.Printf("derived channel %s: %v", dc.Name, err)
+					errLogged = true
+				}
+				return
+			}
+			errLogged = false
+			c.Publish(dc.Name, res)
+		},
+	}
+}
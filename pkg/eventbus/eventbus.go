@@ -1,7 +1,10 @@
 package eventbus
 
 import (
+	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"sync"
 )
 
@@ -35,6 +38,10 @@ type Controller struct {
 	aggregatorIndex map[string][]*EventAggregator
 	aggregatorLock  sync.RWMutex
 
+	derived      []*EventAggregator
+	derivedNames []string
+	derivedLock  sync.Mutex
+
 	closeOnce sync.Once
 	quit      chan struct{}
 
@@ -108,13 +115,13 @@ func (e *Controller) handleMessage(msg *EBusMessage) {
 	}
 
 	// Process aggregators
-	//e.aggregatorLock.RLock()
+	e.aggregatorLock.RLock()
 	if aggregators, exists := e.aggregatorIndex[msg.Topic]; exists {
 		for _, agg := range aggregators {
 			agg.fun(e, msg.Topic, msg.Data)
 		}
 	}
-	//e.aggregatorLock.RUnlock()
+	e.aggregatorLock.RUnlock()
 }
 
 func (e *Controller) handleSubscription(sub newSub) {
@@ -179,6 +186,58 @@ func (e *Controller) RegisterAggregator(aggs ...*EventAggregator) {
 	}
 }
 
+func (e *Controller) UnregisterAggregator(aggs ...*EventAggregator) {
+	e.aggregatorLock.Lock()
+	defer e.aggregatorLock.Unlock()
+	for _, agg := range aggs {
+		for _, topic := range agg.GetTopics() {
+			index := slices.DeleteFunc(e.aggregatorIndex[topic], func(a *EventAggregator) bool {
+				return a == agg
+			})
+			if len(index) == 0 {
+				delete(e.aggregatorIndex, topic)
+			} else {
+				e.aggregatorIndex[topic] = index
+			}
+		}
+	}
+}
+
+// SetDerivedChannels replaces the derived channels, nothing is changed if any of the definitions fails to parse
+func (e *Controller) SetDerivedChannels(defs []string) error {
+	var aggs []*EventAggregator
+	var names []string
+	for _, def := range defs {
+		if strings.TrimSpace(def) == "" {
+			continue
+		}
+		dc, err := ParseDerivedChannel(def)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(names, dc.Name) {
+			return fmt.Errorf("duplicate channel %s", dc.Name)
+		}
+		aggs = append(aggs, ExpressionAggregator(dc))
+		names = append(names, dc.Name)
+	}
+
+	e.derivedLock.Lock()
+	defer e.derivedLock.Unlock()
+	e.UnregisterAggregator(e.derived...)
+	e.RegisterAggregator(aggs...)
+	e.derived = aggs
+	e.derivedNames = names
+	return nil
+}
+
+// DerivedChannels returns the names of the derived channels
+func (e *Controller) DerivedChannels() []string {
+	e.derivedLock.Lock()
+	defer e.derivedLock.Unlock()
+	return slices.Clone(e.derivedNames)
+}
+
 func (e *Controller) Publish(topic string, data float64) {
 	select {
 	case e.incoming <- &EBusMessage{Topic: topic, Data: data}:
@@ -0,0 +1,617 @@
+package eventbus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expression is a parsed derived channel expression.
+//
+// Supported syntax:
+//
+//	numbers, topic names (Lambda.External) or quoted topic names ("My topic")
+//	+ - * / ^ and unary minus
+//	< <= > >= == != evaluates to 1 or 0
+//	&& || are true for non zero values and evaluates to 1 or 0
+//	abs(x) sqrt(x) min(a, b, ...) max(a, b, ...) if(cond, a, b)
+//	avg(x, n)    moving average over the last n samples, 1 <= n <= 10000
+//	minhold(x)   lowest value seen
+//	maxhold(x)   highest value seen
+//	rate(x)      rate of change per second
+type Expression struct {
+	root   exprNode
+	topics []string
+}
+
+// Topics returns the topics referenced by the expression in order of appearance
+func (e *Expression) Topics() []string {
+	return e.topics
+}
+
+// Eval evaluates the expression, ts is used by rate()
+func (e *Expression) Eval(values map[string]float64, ts time.Time) (float64, error) {
+	return e.root.eval(values, ts)
+}
+
+// Reset clears the state of moving averages, holds and rates
+func (e *Expression) Reset() {
+	e.root.reset()
+}
+
+type exprNode interface {
+	eval(values map[string]float64, ts time.Time) (float64, error)
+	reset()
+}
+
+type numberNode float64
+
+func (n numberNode) eval(map[string]float64, time.Time) (float64, error) { return float64(n), nil }
+func (n numberNode) reset()                                              {}
+
+type topicNode string
+
+func (t topicNode) eval(values map[string]float64, _ time.Time) (float64, error) {
+	v, ok := values[string(t)]
+	if !ok {
+		return 0, fmt.Errorf("no value for %s", string(t))
+	}
+	return v, nil
+}
+func (t topicNode) reset() {}
+
+type unaryNode struct {
+	x exprNode
+}
+
+func (u *unaryNode) eval(values map[string]float64, ts time.Time) (float64, error) {
+	v, err := u.x.eval(values, ts)
+	return -v, err
+}
+func (u *unaryNode) reset() { u.x.reset() }
+
+type binaryNode struct {
+	op   string
+	a, b exprNode
+}
+
+func (n *binaryNode) eval(values map[string]float64, ts time.Time) (float64, error) {
+	a, err := n.a.eval(values, ts)
+	if err != nil {
+		return 0, err
+	}
+	b, err := n.b.eval(values, ts)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	case "^":
+		return math.Pow(a, b), nil
+	case "<":
+		return boolValue(a < b), nil
+	case "<=":
+		return boolValue(a <= b), nil
+	case ">":
+		return boolValue(a > b), nil
+	case ">=":
+		return boolValue(a >= b), nil
+	case "==":
+		return boolValue(a == b), nil
+	case "!=":
+		return boolValue(a != b), nil
+	case "&&":
+		return boolValue(a != 0 && b != 0), nil
+	case "||":
+		return boolValue(a != 0 || b != 0), nil
+	}
+	return 0, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func (n *binaryNode) reset() {
+	n.a.reset()
+	n.b.reset()
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type callNode struct {
+	name string
+	args []exprNode
+
+	// state for the stateful functions, every call site has its own
+	window   []float64
+	pos      int
+	sum      float64
+	held     bool
+	hold     float64
+	lastV    float64
+	lastTS   time.Time
+	lastRate float64
+}
+
+var functionArgs = map[string][2]int{ // min, max number of arguments, -1 for no max
+	"abs":     {1, 1},
+	"sqrt":    {1, 1},
+	"min":     {1, -1},
+	"max":     {1, -1},
+	"if":      {3, 3},
+	"avg":     {2, 2},
+	"minhold": {1, 1},
+	"maxhold": {1, 1},
+	"rate":    {1, 1},
+}
+
+func (c *callNode) eval(values map[string]float64, ts time.Time) (float64, error) {
+	if c.name == "if" {
+		cond, err := c.args[0].eval(values, ts)
+		if err != nil {
+			return 0, err
+		}
+		if cond != 0 {
+			return c.args[1].eval(values, ts)
+		}
+		return c.args[2].eval(values, ts)
+	}
+
+	if c.name == "avg" {
+		return c.avg(values, ts)
+	}
+
+	args := make([]float64, len(c.args))
+	for i, a := range c.args {
+		v, err := a.eval(values, ts)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+
+	switch c.name {
+	case "abs":
+		return math.Abs(args[0]), nil
+	case "sqrt":
+		return math.Sqrt(args[0]), nil
+	case "min":
+		res := args[0]
+		for _, v := range args[1:] {
+			res = math.Min(res, v)
+		}
+		return res, nil
+	case "max":
+		res := args[0]
+		for _, v := range args[1:] {
+			res = math.Max(res, v)
+		}
+		return res, nil
+	case "minhold":
+		if !c.held || args[0] < c.hold {
+			c.hold, c.held = args[0], true
+		}
+		return c.hold, nil
+	case "maxhold":
+		if !c.held || args[0] > c.hold {
+			c.hold, c.held = args[0], true
+		}
+		return c.hold, nil
+	case "rate":
+		var rate float64
+		if !c.lastTS.IsZero() {
+			if dt := ts.Sub(c.lastTS).Seconds(); dt > 0 {
+				rate = (args[0] - c.lastV) / dt
+			} else {
+				rate = c.lastRate
+			}
+		}
+		c.lastV, c.lastTS, c.lastRate = args[0], ts, rate
+		return rate, nil
+	}
+	return 0, fmt.Errorf("unknown function %s", c.name)
+}
+
+func (c *callNode) avg(values map[string]float64, ts time.Time) (float64, error) {
+	v, err := c.args[0].eval(values, ts)
+	if err != nil {
+		return 0, err
+	}
+	if c.window == nil {
+		n, err := c.args[1].eval(values, ts)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkAvgSamples(n); err != nil {
+			return 0, err
+		}
+		c.window = make([]float64, 0, int(n))
+	}
+	if len(c.window) < cap(c.window) {
+		c.window = append(c.window, v)
+	} else {
+		c.sum -= c.window[c.pos]
+		c.window[c.pos] = v
+		c.pos = (c.pos + 1) % len(c.window)
+	}
+	c.sum += v
+	return c.sum / float64(len(c.window)), nil
+}
+
+// maxAvgSamples limits the window of avg(), the window is allocated up front
+const maxAvgSamples = 10000
+
+func checkAvgSamples(n float64) error {
+	if n < 1 || n != math.Trunc(n) || n > maxAvgSamples {
+		return fmt.Errorf("avg: sample count must be a whole number between 1 and %d, got %g", maxAvgSamples, n)
+	}
+	return nil
+}
+
+func (c *callNode) reset() {
+	c.window = nil
+	c.pos = 0
+	c.sum = 0
+	c.held = false
+	c.hold = 0
+	c.lastV = 0
+	c.lastTS = time.Time{}
+	c.lastRate = 0
+	for _, a := range c.args {
+		a.reset()
+	}
+}
+
+// ParseExpression parses an expression, see Expression for the syntax
+func ParseExpression(s string) (*Expression, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, seen: make(map[string]bool)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return &Expression{root: root, topics: p.topics}, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			// exponent, 1e-3
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					i = j
+					for i < len(runes) && unicode.IsDigit(runes[i]) {
+						i++
+					}
+				}
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i]), start})
+		case r == '"':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated quote at position %d", start)
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start+1 : i]), start})
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case strings.ContainsRune("+-*/^", r):
+			tokens = append(tokens, token{tokOperator, string(r), i})
+			i++
+		case (r == '&' || r == '|') && i+1 < len(runes) && runes[i+1] == r:
+			tokens = append(tokens, token{tokOperator, string(runes[i : i+2]), i})
+			i += 2
+		case strings.ContainsRune("<>=!", r):
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, token{tokOperator, string(runes[i : i+2]), i})
+				i += 2
+				continue
+			}
+			if r == '=' || r == '!' {
+				return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+			}
+			tokens = append(tokens, token{tokOperator, string(r), i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+		}
+	}
+	tokens = append(tokens, token{tokEOF, "end of expression", len(runes)})
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+	topics []string
+	seen   map[string]bool
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) acceptOperator(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOperator("||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "||", a: left, b: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOperator("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "&&", a: left, b: right}
+	}
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOperator("<", "<=", ">", ">=", "==", "!=")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, a: left, b: right}
+	}
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOperator("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, a: left, b: right}
+	}
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOperator("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, a: left, b: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if _, ok := p.acceptOperator("-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{x: x}, nil
+	}
+	if _, ok := p.acceptOperator("+"); ok {
+		return p.parseUnary()
+	}
+	return p.parsePower()
+}
+
+// power is right associative and binds tighter than unary minus, -2^2 = -4
+func (p *exprParser) parsePower() (exprNode, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.acceptOperator("^"); ok {
+		exp, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: "^", a: base, b: exp}, nil
+	}
+	return base, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return numberNode(v), nil
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.parseCall(tok)
+		}
+		if !p.seen[tok.text] {
+			p.seen[tok.text] = true
+			p.topics = append(p.topics, tok.text)
+		}
+		return topicNode(tok.text), nil
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("expected ) at position %d", closing.pos)
+		}
+		return x, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+func (p *exprParser) parseCall(name token) (exprNode, error) {
+	fn := strings.ToLower(name.text)
+	limits, ok := functionArgs[fn]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at position %d", name.text, name.pos)
+	}
+	p.next() // (
+	call := &callNode{name: fn}
+	if p.peek().kind != tokRParen {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if closing := p.next(); closing.kind != tokRParen {
+		return nil, fmt.Errorf("expected ) at position %d", closing.pos)
+	}
+	if len(call.args) < limits[0] || (limits[1] >= 0 && len(call.args) > limits[1]) {
+		return nil, fmt.Errorf("wrong number of arguments to %s", fn)
+	}
+	if fn == "avg" {
+		n, ok := call.args[1].(numberNode)
+		if !ok {
+			return nil, errors.New("avg: sample count must be a number")
+		}
+		if err := checkAvgSamples(float64(n)); err != nil {
+			return nil, err
+		}
+	}
+	return call, nil
+}
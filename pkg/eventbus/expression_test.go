@@ -0,0 +1,238 @@
+package eventbus
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpressionEval(t *testing.T) {
+	values := map[string]float64{
+		"rpm":             3000,
+		"Lambda.External": 0.85,
+		"My topic":        2,
+		"zero":            0,
+	}
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		// precedence and associativity
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"12 / 4 / 3", 1},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"(-2) ^ 2", 4},
+		{"2 * -3", -6},
+		{"--3", 3},
+		{"+3", 3},
+		{"1 + 2 < 4", 1},
+		{"1 + 2 * 3 == 7", 1},
+		{"1.5e3", 1500},
+		{".5", 0.5},
+
+		// comparisons
+		{"1 < 2", 1},
+		{"2 < 2", 0},
+		{"2 <= 2", 1},
+		{"3 > 2", 1},
+		{"2 >= 3", 0},
+		{"2 == 2", 1},
+		{"2 != 2", 0},
+
+		// logical operators, && binds tighter than ||
+		{"1 && 0", 0},
+		{"1 && 2", 1},
+		{"0 || 0", 0},
+		{"0 || -1", 1},
+		{"1 || 0 && 0", 1},
+		{"(1 || 0) && 0", 0},
+		{"rpm > 2000 && Lambda.External < 0.9", 1},
+		{"rpm > 4000 || zero", 0},
+
+		// topics
+		{"rpm / 1000", 3},
+		{`"My topic" * 3`, 6},
+
+		// functions
+		{"abs(-4)", 4},
+		{"sqrt(16)", 4},
+		{"min(3, 1, 2)", 1},
+		{"max(3, 1, 2)", 3},
+		{"min(5)", 5},
+		{"MAX(1, rpm)", 3000},
+		{"if(rpm > 2000, 1, 2)", 1},
+		{"if(zero, 1, 2)", 2},
+		{"if(zero, missing, 2)", 2},
+		{"max(abs(-2), sqrt(9)) * 2", 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := ParseExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpression: %v", err)
+			}
+			got, err := e.Eval(values, time.Now())
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionTopics(t *testing.T) {
+	e, err := ParseExpression(`rpm * 2 + "My topic" - rpm + avg(Lambda.External, 3)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"rpm", "My topic", "Lambda.External"}
+	if got := e.Topics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExpressionParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "unexpected"},
+		{"1 +", "unexpected"},
+		{"(1 + 2", "expected )"},
+		{"1 + 2)", "unexpected"},
+		{"1 = 2", "unexpected"},
+		{"!1", "unexpected"},
+		{"1 & 2", "unexpected"},
+		{"1 # 2", "unexpected"},
+		{`"rpm`, "unterminated quote"},
+		{"1.2.3", "invalid number"},
+		{"foo(1)", "unknown function"},
+		{"abs()", "wrong number of arguments"},
+		{"abs(1, 2)", "wrong number of arguments"},
+		{"if(1, 2)", "wrong number of arguments"},
+		{"min()", "wrong number of arguments"},
+		{"abs(1", "expected )"},
+		{"avg(rpm, rpm)", "sample count must be a number"},
+		{"avg(rpm, -3)", "sample count must be a number"},
+		{"avg(rpm, 0)", "sample count must be a whole number"},
+		{"avg(rpm, 2.5)", "sample count must be a whole number"},
+		{"avg(rpm, 1e9)", "sample count must be a whole number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseExpression(tt.expr)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionEvalErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"missing + 1", "no value for missing"},
+		{"1 / zero", "division by zero"},
+		{"abs(missing)", "no value for missing"},
+		{"if(missing, 1, 2)", "no value for missing"},
+		{"avg(missing, 2)", "no value for missing"},
+	}
+	values := map[string]float64{"zero": 0}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := ParseExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpression: %v", err)
+			}
+			_, err = e.Eval(values, time.Now())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionState(t *testing.T) {
+	tests := []struct {
+		expr    string
+		samples []float64
+		want    []float64
+	}{
+		{"avg(x, 3)", []float64{3, 6, 9, 12, 0}, []float64{3, 4.5, 6, 9, 7}},
+		{"avg(x, 1)", []float64{1, 5, 2}, []float64{1, 5, 2}},
+		{"minhold(x)", []float64{5, 7, 3, 4, -1}, []float64{5, 5, 3, 3, -1}},
+		{"maxhold(x)", []float64{5, 7, 3, 9, 1}, []float64{5, 7, 7, 9, 9}},
+		// every call site keeps its own state
+		{"avg(x, 2) - avg(x, 4)", []float64{4, 8, 0, 4}, []float64{0, 0, 0, -2}},
+		// the state is only updated for the branch taken
+		{"if(x > 0, maxhold(x), 0)", []float64{2, -5, 1, 3}, []float64{2, 0, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := ParseExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpression: %v", err)
+			}
+			run := func() {
+				ts := time.Now()
+				for i, v := range tt.samples {
+					got, err := e.Eval(map[string]float64{"x": v}, ts)
+					if err != nil {
+						t.Fatalf("sample %d: %v", i, err)
+					}
+					if math.Abs(got-tt.want[i]) > 1e-9 {
+						t.Errorf("sample %d: got %v, want %v", i, got, tt.want[i])
+					}
+				}
+			}
+			run()
+			// after a reset the expression behaves as freshly parsed
+			e.Reset()
+			run()
+		})
+	}
+}
+
+func TestExpressionRate(t *testing.T) {
+	e, err := ParseExpression("rate(x)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	steps := []struct {
+		x    float64
+		ts   time.Time
+		want float64
+	}{
+		{10, start, 0},
+		{20, start.Add(500 * time.Millisecond), 20},
+		{20, start.Add(time.Second), 0},
+		// no time has passed, keep the previous rate
+		{25, start.Add(time.Second), 0},
+		{15, start.Add(2 * time.Second), -10},
+	}
+	for i, s := range steps {
+		got, err := e.Eval(map[string]float64{"x": s.x}, s.ts)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if math.Abs(got-s.want) > 1e-9 {
+			t.Errorf("step %d: got %v, want %v", i, got, s.want)
+		}
+	}
+}
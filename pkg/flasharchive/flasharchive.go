@@ -0,0 +1,331 @@
+// Package flasharchive keeps dumps taken before flashing together with the
+// ECU information they were read with, and a journal of every flash session.
+//
+// Backups are stored as <dir>/<ecu>/<time>_<vin>.bin with the metadata in a
+// .json file next to it, the journal is one JSON object per line in
+// <dir>/journal.jsonl. The session being flashed is kept in <dir>/pending.json
+// until it is journaled so a flash interrupted by a crash can be found
+package flasharchive
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/common"
+	"github.com/roffe/txlogger/pkg/model"
+)
+
+const (
+	journalFile = "journal.jsonl"
+	pendingFile = "pending.json"
+	timeFormat  = "20060102-150405"
+)
+
+type Info struct {
+	Desc  string `json:"desc"`
+	Value string `json:"value"`
+}
+
+type Backup struct {
+	ECU  string    `json:"ecu"`
+	VIN  string    `json:"vin,omitempty"`
+	Time time.Time `json:"time"`
+	// ECU information read before the dump
+	Info []Info `json:"info,omitempty"`
+	Size int    `json:"size"`
+	MD5  string `json:"md5"`
+	// Path of the bin file
+	File string `json:"-"`
+}
+
+func (b *Backup) String() string {
+	vin := b.VIN
+	if vin == "" {
+		vin = "unknown VIN"
+	}
+	return fmt.Sprintf("%s %s %s", b.Time.Format("2006-01-02 15:04:05"), vin, b.MD5)
+}
+
+// Entry is one flash session in the journal
+type Entry struct {
+	Time time.Time `json:"time"`
+	ECU  string    `json:"ecu"`
+	VIN  string    `json:"vin,omitempty"`
+	// Flashed file and its size and MD5
+	File string `json:"file"`
+	Size int    `json:"size"`
+	MD5  string `json:"md5"`
+	// Checksum status of the file before flashing, empty if not checked
+	Checksum   string `json:"checksum,omitempty"`
+	Boot       bool   `json:"boot,omitempty"`
+	NVDM       bool   `json:"nvdm,omitempty"`
+	Partitions []int  `json:"partitions,omitempty"`
+	Verify     string `json:"verify"`
+	Verified   bool   `json:"verified"`
+	// Backup taken or found before flashing
+	Backup string `json:"backup,omitempty"`
+	// The flashed file was a backup from the archive
+	Restore bool `json:"restore,omitempty"`
+	// The flash finished an interrupted flash of the same file
+	Resume   bool          `json:"resume,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+type Archive struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func New(dir string) *Archive {
+	return &Archive{dir: dir}
+}
+
+func (a *Archive) Dir() string {
+	return a.dir
+}
+
+// VIN returns the VIN from ECU information, empty if there is none
+func VIN(info []model.HeaderResult) string {
+	for _, h := range info {
+		if strings.Contains(strings.ToUpper(h.Desc), "VIN") {
+			return strings.TrimSpace(h.Value)
+		}
+	}
+	return ""
+}
+
+func MD5(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveBackup stores a dump with the ECU information it was read with
+func (a *Archive) SaveBackup(ecuName string, info []model.HeaderResult, bin []byte, t time.Time) (*Backup, error) {
+	b := &Backup{
+		ECU:  ecuName,
+		VIN:  VIN(info),
+		Time: t,
+		Size: len(bin),
+		MD5:  MD5(bin),
+	}
+	for _, h := range info {
+		b.Info = append(b.Info, Info{Desc: h.Desc, Value: h.Value})
+	}
+
+	dir := filepath.Join(a.dir, common.SanitizeFilename(ecuName))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	vin := b.VIN
+	if vin == "" {
+		vin = "unknown"
+	}
+	base := filepath.Join(dir, t.Format(timeFormat)+"_"+common.SanitizeFilename(vin))
+	b.File = base + ".bin"
+
+	meta, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(b.File, bin, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(base+".json", meta, 0644); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Backups returns the backups of an ECU, newest first
+func (a *Archive) Backups(ecuName string) ([]*Backup, error) {
+	matches, err := filepath.Glob(filepath.Join(a.dir, common.SanitizeFilename(ecuName), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var backups []*Backup
+	for _, m := range matches {
+		data, err := os.ReadFile(m)
+		if err != nil {
+			return nil, err
+		}
+		b := new(Backup)
+		if err := json.Unmarshal(data, b); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(m), err)
+		}
+		b.File = strings.TrimSuffix(m, ".json") + ".bin"
+		if _, err := os.Stat(b.File); err != nil {
+			continue
+		}
+		backups = append(backups, b)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// FindBackup returns the newest backup of the ECU with the VIN holding what is in the
+// ECU now, nil if there is none and a new dump is needed. A backup is current if
+// nothing has been journaled as flashed since it was taken, or if it has the MD5 of
+// the last successful flash. Flashes journaled without a VIN could have been to this
+// car and are counted too. Backups without a VIN can't be matched to a car and are never returned
+func (a *Archive) FindBackup(ecuName, vin string) (*Backup, error) {
+	if vin == "" {
+		return nil, nil
+	}
+	backups, err := a.Backups(ecuName)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := a.Journal()
+	if err != nil {
+		return nil, err
+	}
+	var last *Entry
+	for i, e := range entries {
+		if e.ECU == ecuName && (e.VIN == vin || e.VIN == "") {
+			last = &entries[i]
+			break
+		}
+	}
+	for _, b := range backups {
+		if b.VIN != vin {
+			continue
+		}
+		// the flash that took or used a backup started before it was saved
+		if last == nil || (!last.Time.After(b.Time) && last.Backup != b.File) {
+			return b, nil
+		}
+		if last.Error == "" && b.MD5 == last.MD5 {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+// Log appends a flash session to the journal
+func (a *Archive) Log(e Entry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(a.dir, journalFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(e); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Journal returns the flash sessions, newest first
+func (a *Archive) Journal() ([]Entry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.Open(filepath.Join(a.dir, journalFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s: %w", journalFile, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// SetPending records the session being flashed, nil clears it
+func (a *Archive) SetPending(e *Entry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	filename := filepath.Join(a.dir, pendingFile)
+	if e == nil {
+		if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Interrupted returns the latest flash of the ECU if it failed, nil if there is none.
+// A session still pending from a program that exited is journaled as interrupted first
+func (a *Archive) Interrupted(ecuName string) (*Entry, error) {
+	a.mu.Lock()
+	data, err := os.ReadFile(filepath.Join(a.dir, pendingFile))
+	a.mu.Unlock()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		e := new(Entry)
+		if err := json.Unmarshal(data, e); err != nil {
+			return nil, fmt.Errorf("%s: %w", pendingFile, err)
+		}
+		if e.ECU == ecuName {
+			// the session never finished, move it to the journal
+			e.Error = "interrupted"
+			if err := a.Log(*e); err != nil {
+				return nil, err
+			}
+			if err := a.SetPending(nil); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+	}
+
+	entries, err := a.Journal()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.ECU != ecuName {
+			continue
+		}
+		if e.Error == "" {
+			return nil, nil
+		}
+		return &e, nil
+	}
+	return nil, nil
+}
@@ -85,6 +85,19 @@ func Interpolate64S(xAxis, yAxis, data []float64, xValue, yValue float64) (float
 	return float64(xIdx-1) + xFrac, float64(yIdx-1) + yFrac, nil
 }
 
+// NearestIndex64 returns the index of the axis value closest to value, values
+// outside the axis goes to the first or last index
+func NearestIndex64(axis []float64, value float64) int {
+	if len(axis) == 1 {
+		return 0
+	}
+	idx, frac := findIndexAndFrac64(axis, value)
+	if frac < 0.5 {
+		return idx - 1
+	}
+	return idx
+}
+
 // Finds the index and fraction of the nearest value in the given array
 func findIndexAndFrac64(axis []float64, value float64) (int, float64) {
 	n := len(axis)
@@ -2,8 +2,10 @@ package ipc
 
 import (
 	"encoding/gob"
+	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net"
@@ -14,44 +16,164 @@ import (
 	"time"
 
 	"fyne.io/fyne/v2"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/widgets/logplayer"
 	"github.com/roffe/txlogger/pkg/windows"
 )
 
+// Commands understood by the router created by CreateIPCRouter
+const (
+	CmdPing              = "ping"
+	CmdOpen              = "open"                  // Data: filename of a bin or logfile
+	CmdOpenLogAtPosition = "open-log-at-position"  // Data: JSON encoded OpenLogRequest
+	CmdLoadSymbols       = "load-symbols-from-bin" // Data: filename of a bin
+	CmdStartLogging      = "start-logging"
+	CmdStopLogging       = "stop-logging"
+	CmdStatus            = "status" // Reply Data: JSON encoded windows.Status
+)
+
+// Reply types
+const (
+	TypePong   = "pong"
+	TypeOK     = "ok"
+	TypeError  = "error"
+	TypeStatus = "status"
+)
+
+type OpenLogRequest struct {
+	Filename string `json:"filename"`
+	Position int    `json:"position"`
+}
+
 func CreateIPCRouter(mw *windows.MainWindow) Router {
 	return Router{
-		"ping": func(data string) *Message {
-			return &Message{Type: "pong", Data: ""}
+		CmdPing: func(data string) *Message {
+			return &Message{Type: TypePong, Data: ""}
 		},
-		"open": func(filename string) *Message {
+		CmdOpen: func(filename string) *Message {
 			fyne.DoAndWait(mw.Window.RequestFocus)
-			if strings.HasSuffix(filename, ".bin") {
-				mw.LoadSymbolsFromFile(filename)
+			if strings.HasSuffix(strings.ToLower(filename), ".bin") {
+				if err := loadSymbols(mw, filename); err != nil {
+					return errorMessage(err)
+				}
 			}
-			if isLogfile(filename) {
-				f, err := os.Open(filename)
-				if err != nil {
-					mw.Error(err)
+			if logfile.IsLogfile(filename) {
+				if _, err := openLog(mw, filename); err != nil {
+					return errorMessage(err)
 				}
-				defer f.Close()
-				sz := mw.Canvas().Size()
-				mw.LoadLogfile(filename, f, fyne.Position{X: sz.Width / 2, Y: sz.Height / 2})
 			}
-			return nil
+			return okMessage()
+		},
+		CmdOpenLogAtPosition: func(data string) *Message {
+			var req OpenLogRequest
+			if err := json.Unmarshal([]byte(data), &req); err != nil {
+				return errorMessage(fmt.Errorf("invalid request: %w", err))
+			}
+			if !logfile.IsLogfile(req.Filename) {
+				return errorMessage(fmt.Errorf("unsupported logfile: %s", req.Filename))
+			}
+			fyne.DoAndWait(mw.Window.RequestFocus)
+			lp, err := openLog(mw, req.Filename)
+			if err != nil {
+				return errorMessage(err)
+			}
+			lp.Seek(req.Position)
+			return okMessage()
+		},
+		CmdLoadSymbols: func(filename string) *Message {
+			if err := loadSymbols(mw, filename); err != nil {
+				return errorMessage(err)
+			}
+			return okMessage()
+		},
+		CmdStartLogging: func(_ string) *Message {
+			var err error
+			fyne.DoAndWait(func() {
+				err = mw.StartLogging()
+			})
+			if err != nil {
+				return errorMessage(err)
+			}
+			return okMessage()
+		},
+		CmdStopLogging: func(_ string) *Message {
+			fyne.DoAndWait(mw.StopLogging)
+			return okMessage()
+		},
+		CmdStatus: func(_ string) *Message {
+			var st *windows.Status
+			fyne.DoAndWait(func() {
+				st = mw.Status()
+			})
+			b, err := json.Marshal(st)
+			if err != nil {
+				return errorMessage(err)
+			}
+			return &Message{Type: TypeStatus, Data: string(b)}
 		},
 	}
 }
 
-// var logfileExtensions = [...]string{".t5l", ".t7l", ".t8l", ".csv"}
-var logfileExtensions = [...]string{".csv"}
+func loadSymbols(mw *windows.MainWindow, filename string) error {
+	if _, err := os.Stat(filename); err != nil {
+		return err
+	}
+	var err error
+	fyne.DoAndWait(func() {
+		err = mw.LoadSymbolsFromFile(filename)
+	})
+	if err != nil {
+		mw.Error(err)
+	}
+	return err
+}
 
-func isLogfile(name string) bool {
-	filename := strings.ToLower(name)
-	for _, ext := range logfileExtensions {
-		if strings.HasSuffix(filename, ext) {
-			return true
-		}
+func openLog(mw *windows.MainWindow, filename string) (*logplayer.Logplayer, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		mw.Error(err)
+		return nil, err
 	}
-	return false
+	defer f.Close()
+	var lp *logplayer.Logplayer
+	fyne.DoAndWait(func() {
+		sz := mw.Canvas().Size()
+		lp = mw.LoadLogfile(filename, f, fyne.Position{X: sz.Width / 2, Y: sz.Height / 2})
+	})
+	if lp == nil {
+		return nil, fmt.Errorf("failed to open log file: %s", filename)
+	}
+	return lp, nil
+}
+
+func okMessage() *Message {
+	return &Message{Type: TypeOK}
+}
+
+func errorMessage(err error) *Message {
+	return &Message{Type: TypeError, Data: err.Error()}
+}
+
+// Send sends a command to a running txlogger instance and waits for the reply
+func Send(msg Message) (*Message, error) {
+	c, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	if err := gob.NewEncoder(c).Encode(msg); err != nil {
+		return nil, err
+	}
+
+	var reply Message
+	if err := gob.NewDecoder(c).Decode(&reply); err != nil {
+		return nil, err
+	}
+	if reply.Type == TypeError {
+		return &reply, errors.New(reply.Data)
+	}
+	return &reply, nil
 }
 
 func sendShow() {
@@ -70,12 +192,21 @@ func sendShow() {
 	defer c.Close()
 	enc := gob.NewEncoder(c)
 	if filename := flag.Arg(0); filename != "" {
-		err = enc.Encode(Message{Type: "open", Data: filename})
+		err = enc.Encode(Message{Type: CmdOpen, Data: filename})
 	} else {
-		err = enc.Encode(Message{Type: "open", Data: ""})
+		err = enc.Encode(Message{Type: CmdOpen, Data: ""})
 	}
 	if err != nil {
 		log.Println(err)
+		return
+	}
+	var reply Message
+	if err := gob.NewDecoder(c).Decode(&reply); err != nil {
+		log.Println(err)
+		return
+	}
+	if reply.Type == TypeError {
+		log.Println("txlogger failed to open file:", reply.Data)
 	}
 }
 
@@ -154,14 +285,17 @@ func handleConn(conn net.Conn, r Router) {
 	log.Println(msg)
 
 	handler, ok := r[msg.Type]
-	if ok {
-		if msg := handler(msg.Data); msg != nil {
-			if err := ge.Encode(*msg); err != nil {
-				log.Println(err)
-				return
-			}
-			time.Sleep(100 * time.Millisecond)
+	if !ok {
+		handler = func(string) *Message {
+			return errorMessage(fmt.Errorf("unknown command: %s", msg.Type))
+		}
+	}
+	if msg := handler(msg.Data); msg != nil {
+		if err := ge.Encode(*msg); err != nil {
+			log.Println(err)
+			return
 		}
+		time.Sleep(100 * time.Millisecond)
 	}
 }
 
@@ -185,7 +319,7 @@ func ping() bool {
 	gdec := gob.NewDecoder(c)
 	gb := gob.NewEncoder(c)
 
-	err = gb.Encode(Message{Type: "ping", Data: ""})
+	err = gb.Encode(Message{Type: CmdPing, Data: ""})
 	if err != nil {
 		log.Println(err)
 		return false
@@ -198,7 +332,7 @@ func ping() bool {
 		return false
 	}
 
-	if msg.Type == "pong" {
+	if msg.Type == TypePong {
 		return true
 	}
 
@@ -0,0 +1,128 @@
+// Package journal records every map cell changed during a session and
+// whether the change has reached the ECU and the binary file
+package journal
+
+import (
+	"sync"
+	"time"
+)
+
+type Entry struct {
+	Time time.Time
+	Map  string
+	// Cell index and position, y=0 is the lowest row
+	Index, X, Y int
+	Old, New    float64
+	// Undo, Redo or empty for a normal edit
+	Action string
+	// The new value has been written to the ECU and/or the binary file
+	ECU, File bool
+}
+
+type Journal struct {
+	mu       sync.Mutex
+	entries  []*Entry
+	onChange []func()
+}
+
+func New() *Journal {
+	return &Journal{}
+}
+
+// Add records changed cells of a map with cols columns
+func (j *Journal) Add(mapName string, cols int, action string, cells []int, old, new []float64) {
+	if len(cells) == 0 {
+		return
+	}
+	cols = max(cols, 1)
+	now := time.Now()
+	j.mu.Lock()
+	for i, idx := range cells {
+		j.entries = append(j.entries, &Entry{
+			Time:   now,
+			Map:    mapName,
+			Index:  idx,
+			X:      idx % cols,
+			Y:      idx / cols,
+			Old:    old[i],
+			New:    new[i],
+			Action: action,
+		})
+	}
+	j.mu.Unlock()
+	j.changed()
+}
+
+// MarkECU marks the latest change of each cell as written to the ECU, nil cells marks the whole map
+func (j *Journal) MarkECU(mapName string, cells []int) {
+	j.mark(mapName, cells, func(e *Entry) { e.ECU = true })
+}
+
+// MarkFile marks the latest change of each cell as saved to the binary, nil cells marks the whole map
+func (j *Journal) MarkFile(mapName string, cells []int) {
+	j.mark(mapName, cells, func(e *Entry) { e.File = true })
+}
+
+func (j *Journal) mark(mapName string, cells []int, set func(*Entry)) {
+	j.mu.Lock()
+	want := make(map[int]bool, len(cells))
+	for _, idx := range cells {
+		want[idx] = true
+	}
+	seen := make(map[int]bool)
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		e := j.entries[i]
+		if e.Map != mapName || seen[e.Index] || (cells != nil && !want[e.Index]) {
+			continue
+		}
+		seen[e.Index] = true
+		set(e)
+	}
+	j.mu.Unlock()
+	j.changed()
+}
+
+// Entries returns a copy of all entries, oldest first
+func (j *Journal) Entries() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := make([]Entry, len(j.entries))
+	for i, e := range j.entries {
+		out[i] = *e
+	}
+	return out
+}
+
+func (j *Journal) Clear() {
+	j.mu.Lock()
+	j.entries = nil
+	j.mu.Unlock()
+	j.changed()
+}
+
+// OnChange registers a function called after every change to the journal from the goroutine making the change
+func (j *Journal) OnChange(f func()) func() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.onChange = append(j.onChange, f)
+	idx := len(j.onChange) - 1
+	return func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.onChange[idx] = nil
+	}
+}
+
+func (j *Journal) changed() {
+	j.mu.Lock()
+	listeners := make([]func(), 0, len(j.onChange))
+	for _, f := range j.onChange {
+		if f != nil {
+			listeners = append(listeners, f)
+		}
+	}
+	j.mu.Unlock()
+	for _, f := range listeners {
+		f()
+	}
+}
@@ -1,11 +1,14 @@
 package logfile
 
 import (
+	"slices"
+	"sort"
 	"time"
 )
 
 type BaseLogfile struct {
 	records []Record
+	markers []Marker
 	length  int
 	pos     int
 	end     int
@@ -15,6 +18,10 @@ func (l *BaseLogfile) Get() Record {
 	return l.records[max(l.pos, 0)]
 }
 
+func (l *BaseLogfile) At(i int) Record {
+	return l.records[i]
+}
+
 // Next returns the current record and advances the position to the next record.
 func (l *BaseLogfile) Next() Record {
 	l.pos++
@@ -78,8 +85,27 @@ func (l *BaseLogfile) Length() time.Duration {
 	return 0
 }
 
+func (l *BaseLogfile) Markers() []Marker {
+	return slices.Clone(l.markers)
+}
+
+func (l *BaseLogfile) SetMarkers(markers []Marker) {
+	l.markers = slices.Clone(markers)
+	slices.SortStableFunc(l.markers, func(a, b Marker) int {
+		return a.Time.Compare(b.Time)
+	})
+}
+
+func (l *BaseLogfile) Index(t time.Time) int {
+	i := sort.Search(l.length, func(i int) bool {
+		return !l.records[i].Time.Before(t)
+	})
+	return max(min(i, l.end), 0)
+}
+
 func (l *BaseLogfile) Close() {
 	l.records = nil
+	l.markers = nil
 	l.length = 0
 	l.pos = -1
 }
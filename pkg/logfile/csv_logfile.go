@@ -1,12 +1,14 @@
 package logfile
 
 import (
+	"bytes"
 	"encoding/csv"
 	"io"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/logformat"
 )
 
 var _ Logfile = (*CSVLogfile)(nil)
@@ -27,7 +29,20 @@ func NewFromCSVLogfile(reader io.Reader) (Logfile, error) {
 }
 
 func (l *CSVLogfile) parseCSVLogfile(reader io.Reader) error {
-	r := csv.NewReader(reader)
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	// markers are written as comments, the csv reader skips them so they are collected first
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if marker, ok := parseCSVMarkerLine(string(bytes.TrimRight(line, "\r"))); ok {
+			l.markers = append(l.markers, marker)
+		}
+	}
+	l.SetMarkers(l.markers)
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
 
 	records, err := r.ReadAll()
 	if err != nil {
@@ -35,13 +50,22 @@ func (l *CSVLogfile) parseCSVLogfile(reader io.Reader) error {
 	}
 
 	for i := 1; i < len(records); i++ {
-		ts, err := time.Parse(datalogger.ISONICO, records[i][0])
+		ts, err := time.Parse(logformat.ISONICO, records[i][0])
 		if err != nil {
 			return err
 		}
 		rec := NewRecord(ts)
 
 		for j := 1; j < len(records[i]); j++ {
+			// empty fields are symbols not read in this record, they keep the previous value
+			if records[i][j] == "" {
+				if len(l.records) > 0 {
+					if val, ok := l.records[len(l.records)-1].Values[records[0][j]]; ok {
+						rec.SetValue(records[0][j], val)
+					}
+				}
+				continue
+			}
 			val, err := strconv.ParseFloat(records[i][j], 64)
 			if err != nil {
 				return err
@@ -58,7 +82,7 @@ func (l *CSVLogfile) parseCSVLogfile(reader io.Reader) error {
 		}
 
 		if i < len(records)-1 {
-			ts2, err := time.Parse(datalogger.ISONICO, records[i+1][0])
+			ts2, err := time.Parse(logformat.ISONICO, records[i+1][0])
 			if err != nil {
 				return err
 			}
@@ -73,3 +97,25 @@ func (l *CSVLogfile) parseCSVLogfile(reader io.Reader) error {
 
 	return nil
 }
+
+// parseCSVMarkerLine parses a comment written by logformat.CSVMarkerLine, other comments are ignored
+func parseCSVMarkerLine(line string) (Marker, bool) {
+	rest, ok := strings.CutPrefix(line, "# ")
+	if !ok {
+		return Marker{}, false
+	}
+	// the timestamp has a space between the date and the time
+	parts := strings.SplitN(rest, " ", 3)
+	if len(parts) < 2 {
+		return Marker{}, false
+	}
+	ts, err := time.Parse(logformat.ISONICO, parts[0]+" "+parts[1])
+	if err != nil {
+		return Marker{}, false
+	}
+	m := Marker{Time: ts}
+	if len(parts) == 3 {
+		m.Text = parts[2]
+	}
+	return m, true
+}
@@ -3,6 +3,7 @@ package logfile
 import (
 	"fmt"
 	"io"
+	"math"
 	"path"
 	"strings"
 	"time"
@@ -10,6 +11,8 @@ import (
 
 type Logfile interface {
 	Get() Record
+	// At returns record i without moving the position
+	At(i int) Record
 	Next() Record
 	Prev() Record
 	Seek(int)
@@ -17,6 +20,11 @@ type Logfile interface {
 	Len() int
 	Start() time.Time
 	End() time.Time
+	// Markers returns the markers of the log sorted by time
+	Markers() []Marker
+	SetMarkers([]Marker)
+	// Index returns the position of the first record at or after t
+	Index(t time.Time) int
 	Close()
 }
 
@@ -34,16 +42,62 @@ type Record struct {
 	EOF           bool
 }
 
+// Marker is a text note at a point in time, written while logging or added as an annotation when viewing the log
+type Marker struct {
+	Time time.Time
+	Text string
+}
+
 func (r Record) SetValue(key string, value float64) {
 	r.Values[key] = value
 }
 
+// Values returns one value per record for every channel in the log, channels
+// missing from a record are NaN. The position of lf is not changed
+func Values(lf Logfile) map[string][]float64 {
+	n := lf.Len()
+	values := make(map[string][]float64)
+	for i := 0; i < n; i++ {
+		for k := range lf.At(i).Values {
+			if _, ok := values[k]; !ok {
+				values[k] = make([]float64, n)
+			}
+		}
+	}
+	for k, v := range values {
+		for i := range v {
+			if f, ok := lf.At(i).Values[k]; ok {
+				v[i] = f
+			} else {
+				v[i] = math.NaN()
+			}
+		}
+	}
+	return values
+}
+
+// Extensions lists the file extensions understood by Open
+var Extensions = []string{".csv", ".t5l", ".t7l", ".t8l", ".txb"}
+
+// IsLogfile reports whether filename has an extension Open can parse
+func IsLogfile(filename string) bool {
+	ext := strings.ToLower(path.Ext(filename))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
 func Open(filename string, reader io.Reader) (Logfile, error) {
 	switch strings.ToLower(path.Ext(filename)) {
 	case ".csv":
 		return NewFromCSVLogfile(reader)
 	case ".t5l", ".t7l", ".t8l":
 		return NewFromTxLogfile(reader)
+	case ".txb":
+		return NewFromTXBLogfile(reader)
 	default:
 		return nil, fmt.Errorf("Unsupported filetype")
 	}
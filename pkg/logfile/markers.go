@@ -0,0 +1,168 @@
+package logfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/logformat"
+)
+
+// SaveMarkers replaces the markers stored in the log file with markers. Each marker
+// is placed before the first record at or after its time so the file reads in order
+func SaveMarkers(filename string, markers []Marker) error {
+	var (
+		isMarker   func(string) bool
+		lineTime   func(string) (time.Time, bool)
+		markerLine func(Marker) string
+	)
+	switch ext := strings.ToLower(path.Ext(filename)); ext {
+	case ".txb":
+		return saveTXBMarkers(filename, markers)
+	case ".csv":
+		isMarker = func(line string) bool {
+			_, ok := parseCSVMarkerLine(line)
+			return ok
+		}
+		lineTime = func(line string) (time.Time, bool) {
+			// the timestamp is quoted since it has a comma before the milliseconds
+			fields, err := csv.NewReader(strings.NewReader(line)).Read()
+			if err != nil {
+				return time.Time{}, false
+			}
+			t, err := time.Parse(logformat.ISONICO, fields[0])
+			return t, err == nil
+		}
+		markerLine = func(m Marker) string {
+			return logformat.CSVMarkerLine(m.Text, m.Time)
+		}
+	case ".t5l", ".t7l", ".t8l":
+		isMarker = func(line string) bool {
+			_, ok := parseMarkerLine(line)
+			return ok
+		}
+		lineTime = func(line string) (time.Time, bool) {
+			field, _, _ := strings.Cut(line, "|")
+			for _, format := range timeFormats {
+				if t, err := time.Parse(format, field); err == nil {
+					return t, true
+				}
+			}
+			return time.Time{}, false
+		}
+		markerLine = func(m Marker) string {
+			return logformat.TXLMarkerLine(m.Text, m.Time)
+		}
+	default:
+		return fmt.Errorf("markers are not supported in %s files", ext)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	markers = sortedMarkers(markers)
+
+	var out bytes.Buffer
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if isMarker(line) {
+			continue
+		}
+		if t, ok := lineTime(line); ok {
+			for len(markers) > 0 && !markers[0].Time.After(t) {
+				out.WriteString(markerLine(markers[0]) + "\n")
+				markers = markers[1:]
+			}
+		}
+		out.WriteString(line + "\n")
+	}
+	for _, m := range markers {
+		out.WriteString(markerLine(m) + "\n")
+	}
+
+	return replaceFile(filename, out.Bytes())
+}
+
+// saveTXBMarkers rewrites the records of a TXB log with the markers placed before the
+// first record at or after their time. The deltas are recalculated since they count
+// from the previous record or marker, a version 1 log is upgraded to the version with markers
+func saveTXBMarkers(filename string, markers []Marker) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	r := bytes.NewReader(data)
+	start, channels, err := readTXBHeader(r)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	header := bytes.Clone(data[:len(data)-r.Len()])
+	binary.BigEndian.PutUint16(header[len(logformat.TXBMagic):], logformat.TXBVersion)
+	out.Write(header)
+
+	markers = sortedMarkers(markers)
+	last := start
+	var buf []byte
+	delta := func(ts time.Time) uint32 {
+		d := min(max(ts.Sub(last).Milliseconds(), 0), logformat.TXBMaxDelta)
+		last = last.Add(time.Duration(d) * time.Millisecond)
+		return uint32(d)
+	}
+	writeMarker := func(m Marker) {
+		buf = binary.BigEndian.AppendUint32(buf[:0], logformat.TXBMarkerRecord)
+		buf = binary.BigEndian.AppendUint32(buf, delta(m.Time))
+		text := logformat.MarkerText(m.Text)
+		if len(text) > math.MaxUint16 {
+			text = text[:math.MaxUint16]
+		}
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(text)))
+		buf = append(buf, text...)
+		out.Write(buf)
+	}
+	onRecord := func(ts time.Time, values []byte) {
+		for len(markers) > 0 && !markers[0].Time.After(ts) {
+			writeMarker(markers[0])
+			markers = markers[1:]
+		}
+		buf = binary.BigEndian.AppendUint32(buf[:0], delta(ts))
+		buf = append(buf, values...)
+		out.Write(buf)
+	}
+	// the markers already in the file are replaced
+	if err := readTXBEntries(r, start, len(channels), onRecord, func(Marker) {}); err != nil {
+		return err
+	}
+	for _, m := range markers {
+		writeMarker(m)
+	}
+
+	return replaceFile(filename, out.Bytes())
+}
+
+func sortedMarkers(markers []Marker) []Marker {
+	markers = slices.Clone(markers)
+	slices.SortStableFunc(markers, func(a, b Marker) int {
+		return a.Time.Compare(b.Time)
+	})
+	return markers
+}
+
+// replaceFile writes data to a temporary file renamed over filename so the log is never left half written
+func replaceFile(filename string, data []byte) error {
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/roffe/txlogger/pkg/logformat"
 )
 
 var _ Logfile = (*TxLogfile)(nil)
@@ -65,23 +67,54 @@ func (l *TxLogfile) parseTxLogfile(reader io.Reader) error {
 		return err
 	}
 
-	l.records = make([]Record, noLines)
+	l.records = make([]Record, 0, noLines)
 	for pos := 0; pos < noLines; pos++ {
-		if record, err := parseLine(lines[pos], timeFormat); err == nil {
-			if pos+1 < noLines {
-				record.DelayTillNext = getDelayTillNext(lines[pos+1], timeFormat, record.Time)
-			}
-			l.records[pos] = record
-		} else {
+		if marker, ok := parseMarkerLine(lines[pos]); ok {
+			l.markers = append(l.markers, marker)
+			continue
+		}
+		if strings.Contains(lines[pos], "|"+logformat.UnitsKey+"=") {
+			continue
+		}
+		record, err := parseLine(lines[pos], timeFormat)
+		if err != nil {
 			log.Println(err)
+			continue
 		}
+		// symbols left out of a line was not read in that record, they keep the previous value
+		if n := len(l.records); n > 0 {
+			prev := &l.records[n-1]
+			prev.DelayTillNext = record.Time.Sub(prev.Time).Milliseconds()
+			for k, v := range prev.Values {
+				if _, ok := record.Values[k]; !ok {
+					record.SetValue(k, v)
+				}
+			}
+		}
+		l.records = append(l.records, record)
 	}
+	l.SetMarkers(l.markers)
 
 	l.length = len(l.records)
 	l.end = l.length - 1
 	return nil
 }
 
+// parseMarkerLine parses a line written by logformat.TXLMarkerLine
+func parseMarkerLine(line string) (Marker, bool) {
+	ts, text, found := strings.Cut(line, "|"+logformat.TXLMarkerKey+"=")
+	if !found {
+		return Marker{}, false
+	}
+	text, _, _ = strings.Cut(text, "|")
+	for _, format := range timeFormats {
+		if t, err := time.Parse(format, ts); err == nil {
+			return Marker{Time: t, Text: text}, true
+		}
+	}
+	return Marker{}, false
+}
+
 func parseLine(line, timeFormat string) (Record, error) {
 	parsedTime, rawValues, err := splitTxLogLine(line, timeFormat)
 	if err != nil {
@@ -101,20 +134,6 @@ func parseLine(line, timeFormat string) (Record, error) {
 	return record, nil
 }
 
-func getDelayTillNext(line, timeFormat string, currentDelay time.Time) int64 {
-	pipeIndex := strings.Index(line, "|")
-	if pipeIndex != -1 {
-		textBeforePipe := line[:pipeIndex]
-		parsedTime, err := time.Parse(timeFormat, textBeforePipe)
-		if err != nil {
-			log.Println(err)
-			return 0
-		}
-		return parsedTime.Sub(currentDelay).Milliseconds()
-	}
-	return 0
-}
-
 func parseCommaValue(valueString string) (string, float64, error) {
 	parts := strings.Split(valueString, "=")
 	val, err := strconv.ParseFloat(strings.Replace(parts[1], ",", ".", 1), 64)
@@ -0,0 +1,173 @@
+package logfile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/logformat"
+)
+
+var _ Logfile = (*TXBLogfile)(nil)
+
+type TXBLogfile struct {
+	BaseLogfile
+	Channels []logformat.TXBChannel
+}
+
+func NewFromTXBLogfile(reader io.Reader) (Logfile, error) {
+	txb := &TXBLogfile{}
+	txb.pos = -1
+	if err := txb.parseTXBLogfile(bufio.NewReader(reader)); err != nil {
+		return nil, err
+	}
+	return txb, nil
+}
+
+func (l *TXBLogfile) parseTXBLogfile(r io.Reader) error {
+	start, channels, err := readTXBHeader(r)
+	if err != nil {
+		return err
+	}
+	l.Channels = channels
+
+	onRecord := func(ts time.Time, values []byte) {
+		rec := NewRecord(ts)
+		for i, ch := range l.Channels {
+			raw := int32(binary.BigEndian.Uint32(values[i*4:]))
+			rec.SetValue(ch.Name, roundFactor(float64(raw)*ch.Factor, ch.Factor))
+		}
+		if n := len(l.records); n > 0 {
+			l.records[n-1].DelayTillNext = ts.Sub(l.records[n-1].Time).Milliseconds()
+		}
+		l.records = append(l.records, rec)
+	}
+	onMarker := func(m Marker) {
+		l.markers = append(l.markers, m)
+	}
+	if err := readTXBEntries(r, start, len(l.Channels), onRecord, onMarker); err != nil {
+		return err
+	}
+
+	l.length = len(l.records)
+	l.end = l.length - 1
+	l.SetMarkers(l.markers)
+	return nil
+}
+
+// readTXBHeader reads the header up to the first record
+func readTXBHeader(r io.Reader) (time.Time, []logformat.TXBChannel, error) {
+	magic := make([]byte, len(logformat.TXBMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return time.Time{}, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(magic) != logformat.TXBMagic {
+		return time.Time{}, nil, errors.New("not a TXB logfile")
+	}
+
+	var header struct {
+		Version  uint16
+		Start    int64
+		Channels uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return time.Time{}, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	// version 1 is the same format without markers
+	if header.Version < 1 || header.Version > logformat.TXBVersion {
+		return time.Time{}, nil, fmt.Errorf("unsupported TXB version: %d", header.Version)
+	}
+
+	channels := make([]logformat.TXBChannel, 0, header.Channels)
+	for i := 0; i < int(header.Channels); i++ {
+		name, err := readString(r)
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("failed to read channel %d: %w", i, err)
+		}
+		unit, err := readString(r)
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("failed to read channel %d: %w", i, err)
+		}
+		var factor float64
+		if err := binary.Read(r, binary.BigEndian, &factor); err != nil {
+			return time.Time{}, nil, fmt.Errorf("failed to read channel %d: %w", i, err)
+		}
+		channels = append(channels, logformat.TXBChannel{Name: name, Unit: unit, Factor: factor})
+	}
+	return time.UnixMilli(header.Start), channels, nil
+}
+
+// readTXBEntries reads the records and markers following the header until EOF.
+// onRecord gets the raw values of a record, the slice is reused for the next record
+func readTXBEntries(r io.Reader, start time.Time, channels int, onRecord func(time.Time, []byte), onMarker func(Marker)) error {
+	ts := start
+	record := make([]byte, 4+4*channels)
+	for {
+		// a truncated last record is expected if logging was aborted
+		if _, err := io.ReadFull(r, record[:4]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		if binary.BigEndian.Uint32(record) == logformat.TXBMarkerRecord {
+			marker, err := readTXBMarker(r, ts)
+			if err != nil {
+				if err == io.EOF || err == io.ErrUnexpectedEOF {
+					return nil
+				}
+				return err
+			}
+			ts = marker.Time
+			onMarker(marker)
+			continue
+		}
+		if _, err := io.ReadFull(r, record[4:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		ts = ts.Add(time.Duration(binary.BigEndian.Uint32(record)) * time.Millisecond)
+		onRecord(ts, record[4:])
+	}
+}
+
+// readTXBMarker reads a marker record after its TXBMarkerRecord escape, prev is the time of the previous record or marker
+func readTXBMarker(r io.Reader, prev time.Time) (Marker, error) {
+	var delta uint32
+	if err := binary.Read(r, binary.BigEndian, &delta); err != nil {
+		return Marker{}, err
+	}
+	text, err := readString(r)
+	if err != nil {
+		return Marker{}, err
+	}
+	return Marker{Time: prev.Add(time.Duration(delta) * time.Millisecond), Text: text}, nil
+}
+
+// remove floating point noise introduced by the multiplication, 0.1 * 3 = 0.30000000000000004
+func roundFactor(value, factor float64) float64 {
+	if factor <= 0 {
+		return value
+	}
+	decimals := max(0, math.Ceil(-math.Log10(factor)))
+	pow := math.Pow(10, decimals)
+	return math.Round(value*pow) / pow
+}
+
+func readString(r io.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,102 @@
+// Package logformat holds the parts of the log file formats shared by the
+// writers in datalogger and the readers in logfile
+package logformat
+
+import (
+	"strings"
+	"time"
+)
+
+// ISONICO is the timestamp format of CSV logs
+const ISONICO = "2006-01-02 15:04:05,999"
+
+// TXLTimeFormat is the timestamp format of TXL logs
+const TXLTimeFormat = "02-01-2006 15:04:05.999"
+
+// TXLMarkerKey holds the marker text on marker lines
+const TXLMarkerKey = "MARKER"
+
+// UnitsKey starts the line listing the units of the logged channels in TXL and CSV logs
+const UnitsKey = "UNITS"
+
+// TXB is a compact binary log format.
+//
+// Header:
+//
+//	magic     [4]byte "TXB\x00"
+//	version   uint16
+//	start     int64 unix milliseconds
+//	channels  uint16
+//	per channel:
+//		name   uint16 length + bytes
+//		unit   uint16 length + bytes
+//		factor float64
+//
+// Records follow the header until EOF, each record is fixed width:
+//
+//	delta     uint32 milliseconds since previous record (or start for the first one)
+//	values    int32 raw value per channel, value = raw * factor
+//
+// Records are always complete, symbols not read in a tick are stored with their last read value.
+//
+// Since version 2 a marker can be stored between records, it starts with TXBMarkerRecord
+// where a record has its delta:
+//
+//	escape    uint32 TXBMarkerRecord
+//	delta     uint32 milliseconds since previous record or marker
+//	text      uint16 length + bytes
+//
+// The delta of a record is limited to TXBMaxDelta so it is never mistaken for a marker.
+//
+// All integers are stored big endian.
+const (
+	TXBMagic   = "TXB\x00"
+	TXBVersion = 2
+
+	TXBMarkerRecord = 0xFFFFFFFF
+	TXBMaxDelta     = TXBMarkerRecord - 1
+
+	// Correctionfactor used for sysvars that does not come from the ECU symbol table
+	TXBSysvarFactor = 0.001
+)
+
+type TXBChannel struct {
+	Name   string
+	Unit   string
+	Factor float64
+}
+
+// MarkerText makes text safe to store on a single line in any of the log formats
+func MarkerText(text string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ", "|", "/").Replace(text)
+}
+
+// TXLMarkerLine returns the line storing a marker in a TXL log
+func TXLMarkerLine(text string, ts time.Time) string {
+	return ts.Format(TXLTimeFormat) + "|" + TXLMarkerKey + "=" + MarkerText(text) + "|IMPORTANTLINE=1|"
+}
+
+// UnitsText lists the units of channels as name:unit separated by ;
+func UnitsText(names, units []string) string {
+	r := strings.NewReplacer("\r", " ", "\n", " ", "|", "/", ";", ",", ":", " ")
+	pairs := make([]string, len(names))
+	for i := range names {
+		pairs[i] = r.Replace(names[i]) + ":" + r.Replace(units[i])
+	}
+	return strings.Join(pairs, ";")
+}
+
+// TXLUnitsLine returns the line storing the units of the channels in a TXL log, it is written before the first record
+func TXLUnitsLine(names, units []string, ts time.Time) string {
+	return ts.Format(TXLTimeFormat) + "|" + UnitsKey + "=" + UnitsText(names, units) + "|IMPORTANTLINE=0|"
+}
+
+// CSVUnitsLine returns the comment line storing the units of the channels in a CSV log, it is written before the header
+func CSVUnitsLine(names, units []string) string {
+	return "# " + UnitsKey + " " + UnitsText(names, units)
+}
+
+// CSVMarkerLine returns the comment line storing a marker in a CSV log
+func CSVMarkerLine(text string, ts time.Time) string {
+	return "# " + ts.Format(ISONICO) + " " + MarkerText(text)
+}
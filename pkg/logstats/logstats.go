@@ -0,0 +1,366 @@
+// Package logstats splits a log into driving segments such as WOT pulls, idle,
+// cruise and fuel cut and summarizes the channels of each segment.
+//
+// Every record is classified from rpm, pedal and vehicle speed, consecutive
+// records of the same kind form a segment. Short interruptions shorter than
+// Config.Gap does not end a segment and segments shorter than the minimum
+// duration of their kind are dropped
+package logstats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindWOT     Kind = "WOT"
+	KindIdle    Kind = "Idle"
+	KindCruise  Kind = "Cruise"
+	KindFuelCut Kind = "Fuel cut"
+)
+
+// Profile holds the channels used for classification per ECU type
+type Profile struct {
+	RPM   string
+	Pedal string
+	Boost string
+	Speed string
+	Knock string
+}
+
+var Profiles = map[string]Profile{
+	"T5": {
+		RPM:   "Rpm",
+		Pedal: "Medeltrot",
+		Boost: "P_medel",
+		Speed: "Bil_hast",
+		Knock: "Knock_offset1234",
+	},
+	"T7": {
+		RPM:   "ActualIn.n_Engine",
+		Pedal: "Out.X_AccPedal",
+		Boost: "ActualIn.p_AirInlet",
+		Speed: "In.v_Vehicle",
+		Knock: "KnkDet.KnockCyl",
+	},
+	"T8": {
+		RPM:   "ActualIn.n_Engine",
+		Pedal: "Out.X_AccPos",
+		Boost: "In.p_AirInlet",
+		Speed: "In.v_Vehicle",
+		Knock: "KnkDet.KnockCyl",
+	},
+}
+
+// DetectProfile returns the profile whose rpm and pedal channels are in the log
+func DetectProfile(values map[string][]float64) (string, Profile, bool) {
+	for _, ecu := range []string{"T5", "T7", "T8"} {
+		p := Profiles[ecu]
+		_, hasRPM := values[p.RPM]
+		_, hasPedal := values[p.Pedal]
+		if hasRPM && hasPedal {
+			return ecu, p, true
+		}
+	}
+	return "", Profile{}, false
+}
+
+// Threshold counts the time a channel is above Value
+type Threshold struct {
+	Channel string
+	Value   float64
+}
+
+func (t Threshold) String() string {
+	return t.Channel + " > " + strconv.FormatFloat(t.Value, 'f', -1, 64)
+}
+
+// ParseThresholds parses "channel > value" lines, empty lines are ignored
+func ParseThresholds(lines []string) ([]Threshold, error) {
+	var thresholds []Threshold
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		channel, value, found := strings.Cut(line, ">")
+		if !found {
+			return nil, fmt.Errorf("invalid threshold %q, expected channel > value", line)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q: %w", line, err)
+		}
+		thresholds = append(thresholds, Threshold{Channel: strings.TrimSpace(channel), Value: v})
+	}
+	return thresholds, nil
+}
+
+type Config struct {
+	Profile
+
+	// Pedal at or above this is wide open throttle
+	WOTPedal float64
+	// Pedal at or below this is closed, used for idle and fuel cut
+	ClosedPedal float64
+	// Below this rpm with a closed pedal is idle, above FuelCutRPM is fuel cut
+	IdleRPM    float64
+	FuelCutRPM float64
+	// Cruise is a part throttle pedal below MaxCruisePedal with the rpm changing
+	// less than MaxCruiseRPMRate per second, above MinCruiseSpeed if the log has vehicle speed
+	MaxCruisePedal   float64
+	MaxCruiseRPMRate float64
+	MinCruiseSpeed   float64
+
+	// Records of another kind shorter than Gap does not end a segment
+	Gap time.Duration
+	// Segments shorter than this are dropped
+	MinDuration map[Kind]time.Duration
+
+	// Engine rpm per km/h in each gear starting with 1st, used to guess the gear from rpm and speed
+	GearRatios []float64
+
+	Thresholds []Threshold
+}
+
+func DefaultConfig(p Profile) *Config {
+	return &Config{
+		Profile:          p,
+		WOTPedal:         85,
+		ClosedPedal:      2,
+		IdleRPM:          1100,
+		FuelCutRPM:       1400,
+		MaxCruisePedal:   50,
+		MaxCruiseRPMRate: 300,
+		MinCruiseSpeed:   20,
+		Gap:              300 * time.Millisecond,
+		MinDuration: map[Kind]time.Duration{
+			KindWOT:     time.Second,
+			KindIdle:    3 * time.Second,
+			KindCruise:  5 * time.Second,
+			KindFuelCut: 500 * time.Millisecond,
+		},
+		// Saab 5-speed manual on 16" wheels
+		GearRatios: []float64{114, 59, 38, 30, 24},
+		Thresholds: []Threshold{
+			{Channel: p.Boost, Value: 1.0},
+			{Channel: "Lambda.External", Value: 1.0},
+		},
+	}
+}
+
+type ChannelStats struct {
+	Min float64
+	Max float64
+	Avg float64
+}
+
+type Segment struct {
+	Kind Kind
+	// Record positions, End is inclusive
+	Start     int
+	End       int
+	StartTime time.Time
+	EndTime   time.Time
+	// Most common gear in the segment, 0 if unknown
+	Gear     int
+	Channels map[string]ChannelStats
+	// Time above each threshold keyed by Threshold.String
+	TimeAbove map[string]time.Duration
+	// Highest boost and the rpm it was seen at
+	PeakBoost    float64
+	PeakBoostRPM float64
+	// Number of times the knock channel changed to a non zero value
+	KnockEvents int
+}
+
+func (s Segment) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// Analyze detects the segments of a log given as one value per record for every channel
+func Analyze(cfg *Config, times []time.Time, values map[string][]float64) []Segment {
+	n := len(times)
+	channels := make(map[string][]float64, len(values))
+	for k, v := range values {
+		// channels not in every record can't be lined up with the records
+		if len(v) == n {
+			channels[k] = v
+		}
+	}
+	rpm, pedal := channels[cfg.RPM], channels[cfg.Pedal]
+	if rpm == nil || pedal == nil {
+		return nil
+	}
+	speed := channels[cfg.Speed]
+
+	var segments []Segment
+	var (
+		kind     Kind
+		start    int
+		last     int
+		inRun    bool
+		lagIndex int
+	)
+	closeRun := func() {
+		if inRun && times[last].Sub(times[start]) >= cfg.MinDuration[kind] {
+			segments = append(segments, cfg.summarize(kind, start, last, times, channels))
+		}
+		inRun = false
+	}
+
+	for i := 0; i < n; i++ {
+		// rpm rate over the last half second to smooth out sensor noise
+		for lagIndex < i && times[i].Sub(times[lagIndex+1]) >= 500*time.Millisecond {
+			lagIndex++
+		}
+		var rpmRate float64
+		if dt := times[i].Sub(times[lagIndex]).Seconds(); dt > 0 {
+			rpmRate = (rpm[i] - rpm[lagIndex]) / dt
+		}
+		v := math.NaN()
+		if speed != nil {
+			v = speed[i]
+		}
+
+		k := cfg.classify(rpm[i], pedal[i], v, rpmRate)
+		switch {
+		case inRun && k == kind:
+			last = i
+		case inRun && times[i].Sub(times[last]) <= cfg.Gap:
+			// a short interruption, the run continues if the kind comes back
+		default:
+			closeRun()
+			if k != "" {
+				kind, start, last, inRun = k, i, i, true
+			}
+		}
+	}
+	closeRun()
+	return segments
+}
+
+// classify returns the kind of a single record, speed is NaN when the log has no vehicle speed
+func (cfg *Config) classify(rpm, pedal, speed, rpmRate float64) Kind {
+	switch {
+	case pedal >= cfg.WOTPedal:
+		return KindWOT
+	case pedal <= cfg.ClosedPedal && rpm < cfg.IdleRPM && (math.IsNaN(speed) || speed < 3):
+		return KindIdle
+	case pedal <= cfg.ClosedPedal && rpm >= cfg.FuelCutRPM:
+		return KindFuelCut
+	case pedal > cfg.ClosedPedal && pedal <= cfg.MaxCruisePedal &&
+		math.Abs(rpmRate) <= cfg.MaxCruiseRPMRate &&
+		(math.IsNaN(speed) || speed >= cfg.MinCruiseSpeed):
+		return KindCruise
+	}
+	return ""
+}
+
+func (cfg *Config) summarize(kind Kind, start, end int, times []time.Time, channels map[string][]float64) Segment {
+	s := Segment{
+		Kind:      kind,
+		Start:     start,
+		End:       end,
+		StartTime: times[start],
+		EndTime:   times[end],
+		Channels:  make(map[string]ChannelStats, len(channels)),
+		TimeAbove: make(map[string]time.Duration),
+	}
+
+	for name, v := range channels {
+		cs := ChannelStats{Min: v[start], Max: v[start]}
+		var sum float64
+		for _, x := range v[start : end+1] {
+			cs.Min = min(cs.Min, x)
+			cs.Max = max(cs.Max, x)
+			sum += x
+		}
+		cs.Avg = sum / float64(end-start+1)
+		s.Channels[name] = cs
+	}
+
+	for _, t := range cfg.Thresholds {
+		v, ok := channels[t.Channel]
+		if !ok {
+			continue
+		}
+		var d time.Duration
+		for i := start; i < end; i++ {
+			if v[i] > t.Value {
+				d += times[i+1].Sub(times[i])
+			}
+		}
+		s.TimeAbove[t.String()] = d
+	}
+
+	rpm := channels[cfg.RPM]
+	if boost, ok := channels[cfg.Boost]; ok {
+		s.PeakBoost = math.Inf(-1)
+		for i := start; i <= end; i++ {
+			if boost[i] > s.PeakBoost {
+				s.PeakBoost = boost[i]
+				s.PeakBoostRPM = rpm[i]
+			}
+		}
+	}
+
+	if knock, ok := channels[cfg.Knock]; ok {
+		prev := 0.0
+		if start > 0 {
+			prev = knock[start-1]
+		}
+		for _, k := range knock[start : end+1] {
+			if k != 0 && k != prev {
+				s.KnockEvents++
+			}
+			prev = k
+		}
+	}
+
+	if speed, ok := channels[cfg.Speed]; ok {
+		s.Gear = cfg.gear(rpm[start:end+1], speed[start:end+1])
+	}
+	return s
+}
+
+// gear returns the most common gear, samples more than 15% off every ratio are skipped
+func (cfg *Config) gear(rpm, speed []float64) int {
+	counts := make([]int, len(cfg.GearRatios)+1)
+	for i := range rpm {
+		if speed[i] < 10 {
+			continue
+		}
+		ratio := rpm[i] / speed[i]
+		best, bestDiff := 0, 0.15
+		for g, r := range cfg.GearRatios {
+			if diff := math.Abs(ratio-r) / r; diff < bestDiff {
+				best, bestDiff = g+1, diff
+			}
+		}
+		counts[best]++
+	}
+	gear, most := 0, 0
+	for g := 1; g < len(counts); g++ {
+		if counts[g] > most {
+			gear, most = g, counts[g]
+		}
+	}
+	return gear
+}
+
+// ChannelNames returns the channels of the segment sorted by name
+func (s Segment) ChannelNames() []string {
+	names := make([]string, 0, len(s.Channels))
+	for k := range s.Channels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
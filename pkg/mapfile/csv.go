@@ -0,0 +1,101 @@
+package mapfile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteCSV writes the map laid out as in the mapviewer, the first row holds the
+// name followed by the X axis and every following row starts with its Y axis value.
+// The highest Y row comes first
+func WriteCSV(w io.Writer, m *Map) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(m.XData)+1)
+	header = append(header, m.Name)
+	for _, x := range m.XData {
+		header = append(header, strconv.FormatFloat(x, 'f', m.XPrecision, 64))
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	cols := len(m.XData)
+	for y := len(m.YData) - 1; y >= 0; y-- {
+		row := make([]string, 0, cols+1)
+		row = append(row, strconv.FormatFloat(m.YData[y], 'f', m.YPrecision, 64))
+		for _, z := range m.ZData[y*cols : (y+1)*cols] {
+			row = append(row, strconv.FormatFloat(z, 'f', m.ZPrecision, 64))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads a map written by WriteCSV
+func ReadCSV(r io.Reader) (*Map, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, errors.New("csv map needs a header and at least one row")
+	}
+
+	m := &Map{Name: records[0][0]}
+	for i, s := range records[0][1:] {
+		v, prec, err := parseFloat(s)
+		if err != nil {
+			return nil, fmt.Errorf("x axis column %d: %w", i+1, err)
+		}
+		m.XData = append(m.XData, v)
+		m.XPrecision = max(m.XPrecision, prec)
+	}
+
+	cols := len(m.XData)
+	rows := len(records) - 1
+	m.YData = make([]float64, rows)
+	m.ZData = make([]float64, rows*cols)
+	for i, rec := range records[1:] {
+		if len(rec) != cols+1 {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", i+2, len(rec)-1, cols)
+		}
+		y := rows - 1 - i
+		v, prec, err := parseFloat(rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("y axis row %d: %w", i+2, err)
+		}
+		m.YData[y] = v
+		m.YPrecision = max(m.YPrecision, prec)
+		for x, s := range rec[1:] {
+			v, prec, err := parseFloat(s)
+			if err != nil {
+				return nil, fmt.Errorf("row %d column %d: %w", i+2, x+2, err)
+			}
+			m.ZData[y*cols+x] = v
+			m.ZPrecision = max(m.ZPrecision, prec)
+		}
+	}
+	return m, nil
+}
+
+// parseFloat also accepts decimal comma and returns the number of decimals used
+func parseFloat(s string) (float64, int, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	var prec int
+	if _, dec, found := strings.Cut(s, "."); found {
+		prec = len(dec)
+	}
+	return v, prec, nil
+}
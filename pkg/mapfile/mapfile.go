@@ -0,0 +1,104 @@
+// Package mapfile reads and writes single maps with their axes so they can be
+// moved between binaries or opened in a spreadsheet
+package mapfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Map is a table of ZData indexed by y*len(XData)+x where y=0 is the lowest row
+type Map struct {
+	Name string
+
+	XData []float64
+	YData []float64
+	ZData []float64
+
+	XPrecision int
+	YPrecision int
+	ZPrecision int
+
+	XLabel string
+	YLabel string
+	ZLabel string
+}
+
+var ErrUnsupportedFormat = errors.New("unsupported map file format")
+
+// Extensions lists the file extensions understood by Read
+var Extensions = []string{"csv", "xdf"}
+
+func (m *Map) Validate() error {
+	if len(m.XData) == 0 || len(m.YData) == 0 {
+		return errors.New("map has no axis data")
+	}
+	if len(m.XData)*len(m.YData) != len(m.ZData) {
+		return fmt.Errorf("map size mismatch, %d columns * %d rows != %d values", len(m.XData), len(m.YData), len(m.ZData))
+	}
+	return nil
+}
+
+// Read reads the map in filename by its extension. A XDF is read together with
+// the binary next to it and the table titled name is returned
+func Read(filename, name string) (*Map, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var m *Map
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		m, err = ReadCSV(f)
+	case ".xdf":
+		bin, berr := os.Open(BinName(filename))
+		if berr != nil {
+			return nil, fmt.Errorf("xdf binary: %w", berr)
+		}
+		defer bin.Close()
+		m, err = ReadXDF(f, bin, name)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, m.Validate()
+}
+
+// Write writes m to filename in the format given by its extension. A XDF is
+// written together with the binary holding its values, see BinName
+func Write(filename string, m *Map) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		if err := WriteCSV(&buf, m); err != nil {
+			return err
+		}
+	case ".xdf":
+		var bin bytes.Buffer
+		if err := WriteXDF(&buf, &bin, m); err != nil {
+			return err
+		}
+		if err := os.WriteFile(BinName(filename), bin.Bytes(), 0644); err != nil {
+			return err
+		}
+	default:
+		return ErrUnsupportedFormat
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// BinName returns the name of the binary that belongs to a XDF
+func BinName(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + ".bin"
+}
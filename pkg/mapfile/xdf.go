@@ -0,0 +1,446 @@
+package mapfile
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/eventbus"
+)
+
+// A TunerPro XDF only describes where tables are stored in a binary, the values
+// themselves live in the binary. WriteXDF therefore writes a XDF together with a
+// small binary holding the x axis, the y axis and the table as big endian signed
+// 32 bit integers scaled by the precision of each axis. Opening the binary in
+// TunerPro with the XDF shows the map.
+//
+// ReadXDF reads any table from a XDF and its binary as long as the table and its
+// axes only use the X variable in their conversions.
+
+const (
+	xdfFlagSigned   = 0x01
+	xdfFlagLSBFirst = 0x02
+	xdfFlagColMajor = 0x04
+	xdfFlagFloat    = 0x10000
+
+	// embedinfo types of a axis
+	xdfAxisLabels   = 1
+	xdfAxisEmbedded = 2
+
+	xdfElementBits = 32
+)
+
+type xdfFormat struct {
+	XMLName xml.Name   `xml:"XDFFORMAT"`
+	Version string     `xml:"version,attr"`
+	Header  xdfHeader  `xml:"XDFHEADER"`
+	Tables  []xdfTable `xml:"XDFTABLE"`
+}
+
+type xdfHeader struct {
+	Flags       string        `xml:"flags"`
+	Title       string        `xml:"deftitle"`
+	Description string        `xml:"description,omitempty"`
+	BaseOffset  xdfBaseOffset `xml:"BASEOFFSET"`
+	Defaults    xdfDefaults   `xml:"DEFAULTS"`
+	Region      xdfRegion     `xml:"REGION"`
+}
+
+type xdfBaseOffset struct {
+	Offset   string `xml:"offset,attr"`
+	Subtract int    `xml:"subtract,attr"`
+}
+
+type xdfDefaults struct {
+	DataSizeInBits int `xml:"datasizeinbits,attr"`
+	SigDigits      int `xml:"sigdigits,attr"`
+	OutputType     int `xml:"outputtype,attr"`
+	Signed         int `xml:"signed,attr"`
+	LSBFirst       int `xml:"lsbfirst,attr"`
+	Float          int `xml:"float,attr"`
+}
+
+type xdfRegion struct {
+	Type         string `xml:"type,attr"`
+	StartAddress string `xml:"startaddress,attr"`
+	Size         string `xml:"size,attr"`
+	RegionFlags  string `xml:"regionflags,attr"`
+	Name         string `xml:"name,attr"`
+	Desc         string `xml:"desc,attr"`
+}
+
+type xdfTable struct {
+	UniqueID    string    `xml:"uniqueid,attr"`
+	Flags       string    `xml:"flags,attr"`
+	Title       string    `xml:"title"`
+	Description string    `xml:"description,omitempty"`
+	Axes        []xdfAxis `xml:"XDFAXIS"`
+}
+
+type xdfAxis struct {
+	ID         string        `xml:"id,attr"`
+	UniqueID   string        `xml:"uniqueid,attr,omitempty"`
+	Data       *xdfEmbedded  `xml:"EMBEDDEDDATA"`
+	Units      string        `xml:"units"`
+	IndexCount int           `xml:"indexcount,omitempty"`
+	DecimalPl  int           `xml:"decimalpl"`
+	EmbedInfo  *xdfEmbedInfo `xml:"embedinfo,omitempty"`
+	Labels     []xdfLabel    `xml:"LABEL"`
+	OutputType int           `xml:"outputtype,omitempty"`
+	Math       xdfMath       `xml:"MATH"`
+}
+
+type xdfEmbedded struct {
+	TypeFlags       string `xml:"mmedtypeflags,attr"`
+	Address         string `xml:"mmedaddress,attr"`
+	ElementSizeBits int    `xml:"mmedelementsizebits,attr"`
+	RowCount        int    `xml:"mmedrowcount,attr,omitempty"`
+	ColCount        int    `xml:"mmedcolcount,attr,omitempty"`
+	MajorStrideBits int    `xml:"mmedmajorstridebits,attr"`
+	MinorStrideBits int    `xml:"mmedminorstridebits,attr"`
+}
+
+type xdfEmbedInfo struct {
+	Type int `xml:"type,attr"`
+}
+
+type xdfLabel struct {
+	Index int    `xml:"index,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type xdfMath struct {
+	Equation string   `xml:"equation,attr"`
+	Vars     []xdfVar `xml:"VAR"`
+}
+
+type xdfVar struct {
+	ID string `xml:"id,attr"`
+}
+
+// WriteXDF writes m as a XDF to w and the values it points at to bin
+func WriteXDF(w, bin io.Writer, m *Map) error {
+	cols, rows := len(m.XData), len(m.YData)
+	var data []byte
+	var err error
+	embed := func(id, units string, values []float64, precision, rowCount, colCount int) (xdfAxis, error) {
+		axis := xdfAxis{
+			ID:        id,
+			Units:     units,
+			DecimalPl: precision,
+			Data: &xdfEmbedded{
+				TypeFlags:       fmt.Sprintf("0x%02X", xdfFlagSigned),
+				Address:         fmt.Sprintf("0x%X", len(data)),
+				ElementSizeBits: xdfElementBits,
+			},
+			Math: xdfMath{Equation: "X", Vars: []xdfVar{{ID: "X"}}},
+		}
+		scale := math.Pow10(precision)
+		if precision > 0 {
+			axis.Math.Equation = "X/" + strconv.FormatFloat(scale, 'f', 0, 64)
+		}
+		for _, v := range values {
+			raw := math.Round(v * scale)
+			if raw < math.MinInt32 || raw > math.MaxInt32 {
+				return axis, fmt.Errorf("%s axis value %g does not fit the xdf data", id, v)
+			}
+			data = binary.BigEndian.AppendUint32(data, uint32(int32(raw)))
+		}
+		if id == "z" {
+			axis.Data.RowCount, axis.Data.ColCount = rowCount, colCount
+			axis.OutputType = 1
+		} else {
+			axis.UniqueID = "0x0"
+			axis.IndexCount = len(values)
+			axis.EmbedInfo = &xdfEmbedInfo{Type: xdfAxisEmbedded}
+		}
+		return axis, nil
+	}
+
+	axes := make([]xdfAxis, 3)
+	if axes[0], err = embed("x", m.XLabel, m.XData, m.XPrecision, 1, cols); err != nil {
+		return err
+	}
+	if axes[1], err = embed("y", m.YLabel, m.YData, m.YPrecision, 1, rows); err != nil {
+		return err
+	}
+	if axes[2], err = embed("z", m.ZLabel, m.ZData, m.ZPrecision, rows, cols); err != nil {
+		return err
+	}
+
+	doc := xdfFormat{
+		Version: "1.70",
+		Header: xdfHeader{
+			Flags:      "0x1",
+			Title:      m.Name,
+			BaseOffset: xdfBaseOffset{Offset: "0"},
+			Defaults: xdfDefaults{
+				DataSizeInBits: xdfElementBits,
+				SigDigits:      m.ZPrecision,
+				OutputType:     1,
+				Signed:         1,
+			},
+			Region: xdfRegion{
+				Type:         "0xFFFFFFFF",
+				StartAddress: "0x0",
+				Size:         fmt.Sprintf("0x%X", len(data)),
+				RegionFlags:  "0x0",
+				Name:         "Binary File",
+				Desc:         "This region describes the bin file edited by this XDF",
+			},
+		},
+		Tables: []xdfTable{
+			{
+				UniqueID:    "0x0",
+				Flags:       "0x0",
+				Title:       m.Name,
+				Description: m.ZLabel,
+				Axes:        axes,
+			},
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return err
+	}
+	_, err = bin.Write(data)
+	return err
+}
+
+// ReadXDF reads the table titled name from a XDF and its binary, if there is no
+// table with that name the XDF must hold a single table
+func ReadXDF(r io.Reader, bin io.ReaderAt, name string) (*Map, error) {
+	var doc xdfFormat
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	var table *xdfTable
+	for i, t := range doc.Tables {
+		if strings.EqualFold(strings.TrimSpace(t.Title), name) {
+			table = &doc.Tables[i]
+			break
+		}
+	}
+	if table == nil {
+		if len(doc.Tables) != 1 {
+			return nil, fmt.Errorf("xdf has no table named %q", name)
+		}
+		table = &doc.Tables[0]
+	}
+
+	base, err := parseXDFInt(doc.Header.BaseOffset.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("base offset: %w", err)
+	}
+	if doc.Header.BaseOffset.Subtract != 0 {
+		base = -base
+	}
+
+	var x, y, z *xdfAxis
+	for i, axis := range table.Axes {
+		switch axis.ID {
+		case "x":
+			x = &table.Axes[i]
+		case "y":
+			y = &table.Axes[i]
+		case "z":
+			z = &table.Axes[i]
+		}
+	}
+	if z == nil || z.Data == nil || z.Data.Address == "" {
+		return nil, fmt.Errorf("%s has no table data", table.Title)
+	}
+
+	rows, cols := max(z.Data.RowCount, 1), max(z.Data.ColCount, 1)
+	if z.Data.ColCount == 0 && x != nil && x.IndexCount > 0 {
+		cols = x.IndexCount
+	}
+	if z.Data.RowCount == 0 && y != nil && y.IndexCount > 0 {
+		rows = y.IndexCount
+	}
+
+	m := &Map{
+		Name:       table.Title,
+		ZLabel:     z.Units,
+		ZPrecision: z.DecimalPl,
+	}
+	if m.ZData, err = z.read(bin, base, rows, cols); err != nil {
+		return nil, fmt.Errorf("z axis: %w", err)
+	}
+	if m.XData, err = x.values(bin, base, cols); err != nil {
+		return nil, fmt.Errorf("x axis: %w", err)
+	}
+	if m.YData, err = y.values(bin, base, rows); err != nil {
+		return nil, fmt.Errorf("y axis: %w", err)
+	}
+	if x != nil {
+		m.XLabel, m.XPrecision = x.Units, x.DecimalPl
+	}
+	if y != nil {
+		m.YLabel, m.YPrecision = y.Units, y.DecimalPl
+	}
+	return m, nil
+}
+
+// values returns count axis values from the labels or the binary. Axes linked to
+// other tables are not supported and are numbered by index, values are imported
+// by index anyway
+func (a *xdfAxis) values(bin io.ReaderAt, base int64, count int) ([]float64, error) {
+	if a == nil {
+		return indexValues(count), nil
+	}
+	embedType := xdfAxisEmbedded
+	if a.EmbedInfo != nil {
+		embedType = a.EmbedInfo.Type
+	}
+	switch {
+	case embedType == xdfAxisLabels && len(a.Labels) > 0:
+		data := indexValues(count)
+		for _, l := range a.Labels {
+			if l.Index < 0 || l.Index >= count {
+				return nil, fmt.Errorf("label index %d out of range", l.Index)
+			}
+			v, _, err := parseFloat(l.Value)
+			if err != nil {
+				return nil, err
+			}
+			data[l.Index] = v
+		}
+		return data, nil
+	case embedType == xdfAxisEmbedded && a.Data != nil && a.Data.Address != "":
+		return a.read(bin, base, 1, count)
+	default:
+		return indexValues(count), nil
+	}
+}
+
+// read reads rows*cols values from the binary and converts them with the axis
+// equation, the values are returned indexed by y*cols+x
+func (a *xdfAxis) read(bin io.ReaderAt, base int64, rows, cols int) ([]float64, error) {
+	d := a.Data
+	flags, err := parseXDFInt(d.TypeFlags)
+	if err != nil {
+		return nil, fmt.Errorf("type flags: %w", err)
+	}
+	addr, err := parseXDFInt(d.Address)
+	if err != nil {
+		return nil, fmt.Errorf("address: %w", err)
+	}
+	bits := d.ElementSizeBits
+	if bits == 0 {
+		bits = 8
+	}
+	if bits != 8 && bits != 16 && bits != 32 {
+		return nil, fmt.Errorf("unsupported element size %d bits", bits)
+	}
+	if flags&xdfFlagFloat != 0 && bits != 32 {
+		return nil, fmt.Errorf("unsupported float size %d bits", bits)
+	}
+	conv, err := a.Math.converter()
+	if err != nil {
+		return nil, err
+	}
+
+	size := int64(bits / 8)
+	minor := size
+	if d.MinorStrideBits > 0 {
+		minor = int64(d.MinorStrideBits / 8)
+	}
+	colMajor := flags&xdfFlagColMajor != 0
+	major := int64(cols) * minor
+	if colMajor {
+		major = int64(rows) * minor
+	}
+	if d.MajorStrideBits > 0 {
+		major = int64(d.MajorStrideBits / 8)
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if flags&xdfFlagLSBFirst != 0 {
+		order = binary.LittleEndian
+	}
+	buf := make([]byte, size)
+	data := make([]float64, rows*cols)
+	for y := range rows {
+		for x := range cols {
+			off := addr + base + int64(y)*major + int64(x)*minor
+			if colMajor {
+				off = addr + base + int64(x)*major + int64(y)*minor
+			}
+			if _, err := bin.ReadAt(buf, off); err != nil {
+				return nil, fmt.Errorf("read 0x%X: %w", off, err)
+			}
+			var raw float64
+			switch {
+			case flags&xdfFlagFloat != 0:
+				raw = float64(math.Float32frombits(order.Uint32(buf)))
+			case bits == 8 && flags&xdfFlagSigned != 0:
+				raw = float64(int8(buf[0]))
+			case bits == 8:
+				raw = float64(buf[0])
+			case bits == 16 && flags&xdfFlagSigned != 0:
+				raw = float64(int16(order.Uint16(buf)))
+			case bits == 16:
+				raw = float64(order.Uint16(buf))
+			case flags&xdfFlagSigned != 0:
+				raw = float64(int32(order.Uint32(buf)))
+			default:
+				raw = float64(order.Uint32(buf))
+			}
+			if data[y*cols+x], err = conv(raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return data, nil
+}
+
+// converter parses the equation of the axis, only the X variable is supported
+func (m xdfMath) converter() (func(float64) (float64, error), error) {
+	if strings.TrimSpace(m.Equation) == "" {
+		return func(v float64) (float64, error) { return v, nil }, nil
+	}
+	expr, err := eventbus.ParseExpression(m.Equation)
+	if err != nil {
+		return nil, fmt.Errorf("equation %q: %w", m.Equation, err)
+	}
+	for _, t := range expr.Topics() {
+		if t != "X" {
+			return nil, fmt.Errorf("equation %q: unsupported variable %s", m.Equation, t)
+		}
+	}
+	values := make(map[string]float64, 1)
+	return func(v float64) (float64, error) {
+		values["X"] = v
+		return expr.Eval(values, time.Time{})
+	}, nil
+}
+
+func parseXDFInt(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 0, 64)
+}
+
+func indexValues(count int) []float64 {
+	data := make([]float64, count)
+	for i := range data {
+		data[i] = float64(i)
+	}
+	return data
+}
@@ -25,11 +25,24 @@ func Names() []string {
 	return names
 }
 
-func Set(name string, symbols []*symbol.Symbol) error {
+// Preset is the stored form of a preset with derived channels, presets without channels
+// are stored as a plain symbol list for backwards compatibility
+type Preset struct {
+	Symbols  []*symbol.Symbol `json:"symbols"`
+	Channels []string         `json:"channels,omitempty"`
+}
+
+func Set(name string, symbols []*symbol.Symbol, channels []string) error {
 	if strings.EqualFold(name, "T5 Dash") || strings.EqualFold(name, "T7 Dash") || strings.EqualFold(name, "T8 Dash") {
 		return fmt.Errorf("cannot replace system presets")
 	}
-	data, err := json.Marshal(symbols)
+	var data []byte
+	var err error
+	if len(channels) > 0 {
+		data, err = json.Marshal(Preset{Symbols: symbols, Channels: channels})
+	} else {
+		data, err = json.Marshal(symbols)
+	}
 	if err != nil {
 		return err
 	}
@@ -46,18 +59,35 @@ func Delete(name string) error {
 }
 
 func Get(name string) ([]*symbol.Symbol, error) {
+	preset, err := GetPreset(name)
+	if err != nil {
+		return nil, err
+	}
+	return preset.Symbols, nil
+}
+
+// GetPreset returns the symbols and derived channels of a preset
+func GetPreset(name string) (*Preset, error) {
 	data, ok := Map[name]
 	if !ok {
 		return nil, fmt.Errorf("preset not found")
 	}
+	return Decode([]byte(data))
+}
 
-	var symbols []*symbol.Symbol
-	err := json.Unmarshal([]byte(data), &symbols)
-	if err != nil {
+// Decode parses both the plain symbol list and the preset object format
+func Decode(data []byte) (*Preset, error) {
+	preset := &Preset{}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		if err := json.Unmarshal(data, &preset.Symbols); err != nil {
+			return nil, err
+		}
+		return preset, nil
+	}
+	if err := json.Unmarshal(data, preset); err != nil {
 		return nil, err
 	}
-
-	return symbols, nil
+	return preset, nil
 }
 
 func Load(app fyne.App) error {
@@ -0,0 +1,126 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+var ErrNotSupported = errors.New("not supported by the ECU simulator")
+
+// used when the logfile has no timing for the next record, i.e the last record
+const defaultDelay = 40 * time.Millisecond
+
+var _ datalogger.IClient = (*Client)(nil)
+
+type Config struct {
+	// Speed multiplier, 1 is real time, 2 twice as fast
+	Speed float64
+	// Start over from the beginning at the end of the log
+	Loop           bool
+	OnMessage      func(string)
+	CaptureCounter func(int)
+	FpsCounter     func(int)
+}
+
+// Client streams a recorded logfile into the event bus as if it was a live ECU
+type Client struct {
+	cfg *Config
+	lf  logfile.Logfile
+
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+func New(lf logfile.Logfile, cfg *Config) *Client {
+	if cfg.Speed <= 0 {
+		cfg.Speed = 1
+	}
+	return &Client{
+		cfg:  cfg,
+		lf:   lf,
+		quit: make(chan struct{}),
+	}
+}
+
+func (c *Client) Start() error {
+	defer c.lf.Close()
+	if c.lf.Len() == 0 {
+		return errors.New("logfile contains no records")
+	}
+	c.cfg.OnMessage(fmt.Sprintf("Simulating ECU from %d records at %gx speed", c.lf.Len(), c.cfg.Speed))
+	c.cfg.CaptureCounter(0)
+	c.cfg.FpsCounter(0)
+
+	c.lf.Seek(-1)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	secondTicker := time.NewTicker(time.Second)
+	defer secondTicker.Stop()
+
+	var captureCount, capturePerSecond int
+	for {
+		select {
+		case <-c.quit:
+			c.cfg.OnMessage("Stopped simulation")
+			return nil
+		case <-secondTicker.C:
+			c.cfg.FpsCounter(capturePerSecond)
+			capturePerSecond = 0
+		case <-timer.C:
+			rec := c.lf.Next()
+			if rec.EOF {
+				if !c.cfg.Loop {
+					c.cfg.OnMessage("End of log reached")
+					return nil
+				}
+				c.lf.Seek(-1)
+				timer.Reset(c.delay(0))
+				continue
+			}
+			for k, v := range rec.Values {
+				ebus.Publish(k, v)
+			}
+			captureCount++
+			capturePerSecond++
+			if captureCount%15 == 0 {
+				c.cfg.CaptureCounter(captureCount)
+			}
+			timer.Reset(c.delay(rec.DelayTillNext))
+		}
+	}
+}
+
+// delay scales the recorded delay in milliseconds with the playback speed
+func (c *Client) delay(ms int64) time.Duration {
+	d := time.Duration(ms) * time.Millisecond
+	if d <= 0 {
+		d = defaultDelay
+	}
+	return time.Duration(float64(d) / c.cfg.Speed)
+}
+
+func (c *Client) SetRAM(address uint32, data []byte) error {
+	return ErrNotSupported
+}
+
+func (c *Client) GetRAM(address uint32, length uint32) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+// AddMarker is not supported, the simulator does not write a log
+func (c *Client) AddMarker(string) error {
+	return ErrNotSupported
+}
+
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.quit)
+	})
+}
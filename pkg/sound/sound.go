@@ -1,16 +1,34 @@
 package sound
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"log"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/ebitengine/oto/v3"
 )
 
-var octx *oto.Context
+var (
+	octx     *oto.Context
+	initOnce sync.Once
+	initErr  error
+)
 
+// Init opens the audio device, only the first call does the work and it can
+// block for up to 10 seconds while the device gets ready
 func Init() error {
+	initOnce.Do(func() {
+		initErr = initContext()
+	})
+	return initErr
+}
+
+func initContext() error {
 	// Prepare an Oto context (this will use your default audio device) that will
 	// play all our sounds. Its configuration can't be changed later.
 
@@ -43,10 +61,43 @@ func Init() error {
 
 // Create a new 'player' that will handle our sound. Paused by default.
 func NewPlayer(r io.Reader) *oto.Player {
-	if octx == nil {
-		if err := Init(); err != nil {
-			panic("sound.NewPlayer: " + err.Error())
-		}
+	if err := Init(); err != nil {
+		panic("sound.NewPlayer: " + err.Error())
 	}
 	return octx.NewPlayer(r)
 }
+
+// Tone returns a sine tone in the format of the sound context, the tone is
+// switched on and off every pulse to make it sound like an alarm, zero pulse gives a steady tone
+func Tone(freq float64, duration, pulse time.Duration) io.Reader {
+	const sampleRate = 44100
+	samples := int(duration.Seconds() * sampleRate)
+	pulseSamples := int(pulse.Seconds() * sampleRate)
+	buf := make([]byte, 0, samples*4)
+	for i := range samples {
+		var v int16
+		if pulseSamples == 0 || (i/pulseSamples)%2 == 0 {
+			v = int16(math.Sin(2*math.Pi*freq*float64(i)/sampleRate) * 0.4 * math.MaxInt16)
+		}
+		// stereo
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(v))
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(v))
+	}
+	return bytes.NewReader(buf)
+}
+
+// Play plays r in the background, errors are logged since nobody waits for them
+func Play(r io.Reader) {
+	go func() {
+		if err := Init(); err != nil {
+			log.Println("sound.Play:", err)
+			return
+		}
+		player := octx.NewPlayer(r)
+		player.Play()
+		for player.IsPlaying() {
+			time.Sleep(50 * time.Millisecond)
+		}
+		player.Close()
+	}()
+}
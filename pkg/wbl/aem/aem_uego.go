@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/roffe/txlogger/pkg/wbl/sensor"
 	"go.bug.st/serial"
 )
 
@@ -21,6 +22,11 @@ type AEMuego struct {
 	lamba   float64
 	oxygen  float64
 	voltage float64
+	status  sensor.Status
+	// hasStatus is set once a CAN frame with the status bytes has been received, the serial output has no status
+	hasStatus bool
+	// hasCAN is set once a CAN frame with the oxygen and supply voltage has been received, the serial output only has the lambda
+	hasCAN bool
 
 	log func(string)
 
@@ -76,6 +82,23 @@ func (a *AEMuego) SetLambda(value float64) {
 	a.lamba = value
 }
 
+// Channels returns the lambda, the oxygen and system voltage are only sent on CAN
+// and are not returned until a CAN frame has been received
+func (a *AEMuego) Channels() []sensor.Channel {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	channels := []sensor.Channel{
+		{Name: "Lambda", Unit: "λ", Value: a.lamba, HasStatus: a.hasStatus, Status: a.status},
+	}
+	if a.hasCAN {
+		channels = append(channels,
+			sensor.Channel{Name: "Oxygen", Unit: "%", Value: a.oxygen},
+			sensor.Channel{Name: "SupplyVoltage", Unit: "V", Value: a.voltage},
+		)
+	}
+	return channels
+}
+
 func (a *AEMuego) run(ctx context.Context) {
 	buf := make([]byte, 8)
 	for {
@@ -175,7 +198,7 @@ func (a *AEMuego) SetData(data []byte) error {
 	binary.Read(r, binary.BigEndian, &wbl)
 	a.lamba = float64(wbl) * 0.0001
 
-	var oxygen uint16
+	var oxygen int16
 	binary.Read(r, binary.BigEndian, &oxygen)
 	a.oxygen = float64(oxygen) * 0.001
 
@@ -183,9 +206,27 @@ func (a *AEMuego) SetData(data []byte) error {
 	binary.Read(r, binary.BigEndian, &systemVolt)
 	a.voltage = float64(systemVolt) * 0.1
 
+	if len(data) >= 5 {
+		a.hasCAN = true
+	}
+
+	if len(data) >= 8 {
+		a.hasStatus = true
+		a.status = 0
+		if data[6]&0x80 == 0 {
+			// lambda is not valid until the sensor is up to temperature
+			a.status |= sensor.Warming
+		}
+		if data[7]&0x40 != 0 {
+			a.status |= sensor.Error
+		}
+	}
+
 	return nil
 }
 
 func (a *AEMuego) String() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	return fmt.Sprintf("Lambda: %.4f, Oxygen: %.3f, Voltage: %.1f", a.lamba, a.oxygen, a.voltage)
 }
@@ -10,6 +10,7 @@ import (
 	"sync"
 
 	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/wbl/sensor"
 )
 
 const (
@@ -103,6 +104,20 @@ func (l *LambdaToCAN) GetLambda() float64 {
 	return l.st.Lambda
 }
 
+func (l *LambdaToCAN) Channels() []sensor.Channel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return []sensor.Channel{
+		{Name: "Lambda", Unit: "λ", Value: l.st.Lambda, HasStatus: true, Status: l.st.sensorStatus()},
+		{Name: "SupplyVoltage", Unit: "V", Value: l.st.SupplyVoltage},
+		{Name: "HeaterPower", Unit: "%", Value: l.st.HeaterPower},
+		{Name: "SensorTemp", Unit: "°C", Value: l.st.SensorTemp},
+		{Name: "IpCurrent", Unit: "mA", Value: l.st.IpCurrent},
+		{Name: "Oxygen", Unit: "%", Value: l.st.OxygenConc},
+		{Name: "SensorResistance", Unit: "Ohm", Value: l.st.Resistance},
+	}
+}
+
 type LambdaToCANStatus struct {
 	SupplyVoltage    float64 // V
 	HeaterPower      float64 // %DC
@@ -127,6 +142,29 @@ type LambdaToCANStatus struct {
 	LambdaValid      bool
 }
 
+// Fault reports if any of the sensor or heater diagnostics are set
+func (s LambdaToCANStatus) Fault() bool {
+	return s.VmShortVcc || s.VmShortGnd || s.UnShortVcc || s.UnShortGnd || s.IaipShortVcc || s.IaipShortGnd ||
+		s.VubLowVoltage || s.HeaterShortVcc || s.HeaterShortGnd || s.HeaterOpenLoad
+}
+
+func (s LambdaToCANStatus) sensorStatus() sensor.Status {
+	var st sensor.Status
+	if s.HeaterState != HeaterRegulation {
+		st |= sensor.Warming
+	}
+	switch s.CalibrationState {
+	case CalibrationStart, CalibrationWaitSPIReset:
+		st |= sensor.Calibrating
+	case CalibrationError:
+		st |= sensor.Error
+	}
+	if s.Fault() {
+		st |= sensor.Error
+	}
+	return st
+}
+
 // PrettyPrint outputs the data in LambdaToCAN664 in a human-readable format.
 func (l *LambdaToCAN) String() string {
 	l.mu.Lock()
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/roffe/txlogger/pkg/wbl/sensor"
 	"go.bug.st/serial"
 )
 
@@ -138,6 +139,27 @@ func (c *ISP2Client) getLambda() float64 {
 	}
 }
 
+func (c *ISP2Client) Channels() []sensor.Channel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return []sensor.Channel{
+		{Name: "Lambda", Unit: "λ", Value: c.getLambda(), HasStatus: true, Status: sensorStatus(c.status)},
+		{Name: "AFR", Unit: "AFR", Value: c.afr},
+	}
+}
+
+func sensorStatus(status uint8) sensor.Status {
+	switch status {
+	case ISP2_WARMING:
+		return sensor.Warming
+	case ISP2_CALIBRATING, ISP2_HEATER_CALIBRATING:
+		return sensor.Calibrating
+	case ISP2_NEED_CALIBRATION, ISP2_LAMBDA_ERROR_CODE, ISP2_RESERVED:
+		return sensor.Error
+	}
+	return 0
+}
+
 func (c *ISP2Client) GetStatus() uint8 {
 	c.mu.Lock()
 	defer c.mu.Unlock()
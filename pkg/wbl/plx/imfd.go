@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/roffe/txlogger/pkg/wbl/sensor"
 	"go.bug.st/serial"
 )
 
@@ -141,6 +143,20 @@ func (s sensorType) String() string {
 	return "Unknown (" + strconv.Itoa(int(s)) + ")"
 }
 
+type sensorKey struct {
+	typ      sensorType
+	instance uint8
+}
+
+func (k sensorKey) String() string {
+	return fmt.Sprintf("%s #%d", k.typ.String(), k.instance)
+}
+
+type reading struct {
+	value float64
+	unit  string
+}
+
 const (
 	StartBit = 0x80 // 1000 0000
 	StopBit  = 0x40 // 0100 0000
@@ -156,7 +172,7 @@ type IMFDClient struct {
 
 	logFunc func(string)
 
-	values map[string]float64
+	values map[sensorKey]reading
 
 	closeOnce sync.Once
 	closed    chan struct{}
@@ -246,7 +262,7 @@ func NewIMFDClient(port string, cfg *IMFDClientConfig, logFunc func(string)) (*I
 		buffer:   make([]byte, 0, 32),
 		parsing:  false,
 		portName: port,
-		values:   make(map[string]float64),
+		values:   make(map[sensorKey]reading),
 		closed:   make(chan struct{}),
 		logFunc:  logFunc,
 	}
@@ -328,8 +344,8 @@ func (s *IMFDClient) Stop() {
 func (s *IMFDClient) GetSensor(sensorType sensorType, instance uint8) float64 {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	if v, ok := s.values[createMapkey(sensorType, instance)]; ok {
-		return v
+	if r, ok := s.values[sensorKey{sensorType, instance}]; ok {
+		return r.value
 	}
 	return 0.0
 }
@@ -337,19 +353,67 @@ func (s *IMFDClient) GetSensor(sensorType sensorType, instance uint8) float64 {
 func (s *IMFDClient) GetLambda() float64 {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	if v, ok := s.values[createMapkey(WidebandAirFuel, 0)]; ok {
-		return v
+	return s.getLambda()
+}
+
+func (s *IMFDClient) getLambda() float64 {
+	if r, ok := s.values[sensorKey{WidebandAirFuel, 0}]; ok {
+		return r.value
 	}
 	return 0.500
 }
 
+// Channels returns the first wideband followed by every other sensor seen in the chain.
+// The wideband status is reported as the status of the wideband with the same instance
+func (s *IMFDClient) Channels() []sensor.Channel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]sensorKey, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].typ != keys[j].typ {
+			return keys[i].typ < keys[j].typ
+		}
+		return keys[i].instance < keys[j].instance
+	})
+
+	lambda := sensor.Channel{Name: "Lambda", Unit: "λ", Value: s.getLambda(), HasStatus: true, Status: s.sensorStatus(0)}
+	if r, ok := s.values[sensorKey{WidebandAirFuel, 0}]; ok {
+		lambda.Unit = r.unit
+	}
+	channels := []sensor.Channel{lambda}
+	for _, k := range keys {
+		if k == (sensorKey{WidebandAirFuel, 0}) || k.typ == WidebandAFRStatus {
+			continue
+		}
+		r := s.values[k]
+		ch := sensor.Channel{Name: s.cfg.channelName(k), Unit: r.unit, Value: r.value}
+		if k.typ == WidebandAirFuel {
+			ch.HasStatus = true
+			ch.Status = s.sensorStatus(k.instance)
+		}
+		channels = append(channels, ch)
+	}
+	return channels
+}
+
+// sensorStatus returns the status of a wideband, the SM-AFR reports 1 while the sensor is warming up
+func (s *IMFDClient) sensorStatus(instance uint8) sensor.Status {
+	if r, ok := s.values[sensorKey{WidebandAFRStatus, instance}]; ok && r.value != 0 {
+		return sensor.Warming
+	}
+	return 0
+}
+
 func (s *IMFDClient) String() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	var out strings.Builder
 	out.WriteString("-- IMFDClient -----\n")
-	for k, v := range s.values {
-		out.WriteString(fmt.Sprintf("%s: %.3f\n", k, v))
+	for k, r := range s.values {
+		out.WriteString(fmt.Sprintf("%s: %.3f %s\n", k, r.value, r.unit))
 	}
 	out.WriteString(strings.Repeat("-", 20) + "\n")
 	return out.String()
@@ -378,11 +442,6 @@ func (s *IMFDClient) parsePackets(data []byte) error {
 	return nil
 }
 
-func createMapkey(typ sensorType, instance uint8) string {
-	return fmt.Sprintf("%s #%d", typ.String(), instance)
-
-}
-
 func (s *IMFDClient) parsePacket(packet []byte) error {
 	addrMSB := packet[0] & DataMask
 	addrLSB := packet[1] & DataMask
@@ -392,12 +451,121 @@ func (s *IMFDClient) parsePacket(packet []byte) error {
 	address := uint16(addrMSB)<<6 | uint16(addrLSB)
 	data := uint16(dataMSB)<<6 | uint16(dataLSB)
 	st := sensorType(address)
-	value, _ := convertData(st, 0, data)
-	key := createMapkey(st, instance)
-	s.values[key] = value
+	value, unit := convertData(st, s.cfg.unit(st), data)
+	s.values[sensorKey{st, instance}] = reading{value: value, unit: unit}
 	return nil
 }
 
+// unit returns the configured unit of a sensor type
+func (c *IMFDClientConfig) unit(typ sensorType) uint16 {
+	switch typ {
+	case WidebandAirFuel:
+		return uint16(c.WidebandAirFuel)
+	case ExhaustGasTemperature:
+		return uint16(c.ExhaustGasTemperature)
+	case FluidTemperature:
+		return uint16(c.FluidTemperature)
+	case Vacuum:
+		return uint16(c.Vacuum)
+	case Boost:
+		return uint16(c.Boost)
+	case AirIntakeTemperature:
+		return uint16(c.AirIntakeTemperature)
+	case VehicleSpeed:
+		return uint16(c.VehicleSpeed)
+	case FuelPressure:
+		return uint16(c.FuelPressure)
+	case MAP:
+		return uint16(c.MAP)
+	case MAF:
+		return uint16(c.MAF)
+	case NarrowbandOxygenSensor:
+		return uint16(c.NarrowbandOxygenSensor)
+	case DutyCycle:
+		return uint16(c.DutyCycle)
+	case FuelEfficiency:
+		return uint16(c.FuelEfficiency)
+	}
+	return 0
+}
+
+// channelName returns the name a sensor is logged as, instances after the first are numbered from 2.
+// The unit setting tells water from oil temperature and fuel from oil pressure
+func (c *IMFDClientConfig) channelName(k sensorKey) string {
+	var name string
+	switch k.typ {
+	case WidebandAirFuel:
+		name = "Lambda"
+		if c.WidebandAirFuel != 0 {
+			name = "AFR"
+		}
+	case ExhaustGasTemperature:
+		name = "EGT"
+	case FluidTemperature:
+		name = "WaterTemp"
+		if c.FluidTemperature >= 2 {
+			name = "OilTemp"
+		}
+	case Vacuum:
+		name = "Vacuum"
+	case Boost:
+		name = "Boost"
+	case AirIntakeTemperature:
+		name = "AirTemp"
+	case RPM:
+		name = "RPM"
+	case VehicleSpeed:
+		name = "VehicleSpeed"
+	case ThrottlePosition:
+		name = "Throttle"
+	case EngineLoad:
+		name = "EngineLoad"
+	case FuelPressure:
+		name = "FuelPressure"
+		if c.FuelPressure >= 3 {
+			name = "OilPressure"
+		}
+	case Timing:
+		name = "Timing"
+	case MAP:
+		name = "MAP"
+	case MAF:
+		name = "MAF"
+	case ShortTermFuelTrim:
+		name = "STFT"
+	case LongTermFuelTrim:
+		name = "LTFT"
+	case NarrowbandOxygenSensor:
+		name = "Narrowband"
+	case FuelLevel:
+		name = "FuelLevel"
+	case VoltMeter:
+		name = "Voltage"
+	case Knock:
+		name = "Knock"
+	case DutyCycle:
+		name = "DutyCycle"
+	case FuelEfficiency:
+		name = "FuelEfficiency"
+	case AnalogVoltage:
+		name = "AnalogVoltage"
+	case Speed:
+		name = "Frequency"
+	case WidebandAFRStatus:
+		name = "LambdaStatus"
+	case WidebandAFRHealth:
+		name = "LambdaHealth"
+	case WidebandAFRReaction:
+		name = "LambdaReaction"
+	default:
+		name = "Sensor" + strconv.Itoa(int(k.typ))
+	}
+	if k.instance > 0 {
+		name += strconv.Itoa(int(k.instance) + 1)
+	}
+	return name
+}
+
 func convertData(sensor sensorType, unit, raw uint16) (float64, string) {
 	var retUnit string
 	var value float64
@@ -0,0 +1,40 @@
+// Package sensor describes the readings published by wideband providers
+package sensor
+
+import "strings"
+
+// Status flags of a channel, a zero status is a valid reading
+type Status uint8
+
+const (
+	Warming Status = 1 << iota
+	Error
+	Calibrating
+)
+
+func (s Status) String() string {
+	if s == 0 {
+		return "OK"
+	}
+	var flags []string
+	if s&Warming != 0 {
+		flags = append(flags, "Warming")
+	}
+	if s&Error != 0 {
+		flags = append(flags, "Error")
+	}
+	if s&Calibrating != 0 {
+		flags = append(flags, "Calibrating")
+	}
+	return strings.Join(flags, ", ")
+}
+
+// Channel is one named reading of a provider
+type Channel struct {
+	Name  string
+	Unit  string
+	Value float64
+	// HasStatus is set for channels where the device reports the sensor health
+	HasStatus bool
+	Status    Status
+}
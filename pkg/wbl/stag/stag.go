@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/roffe/txlogger/pkg/wbl/sensor"
 	"go.bug.st/serial"
 )
 
@@ -17,6 +18,7 @@ type STAG struct {
 
 	lambda float64
 	oxygen float64
+	status sensor.Status
 
 	log func(string)
 
@@ -64,6 +66,15 @@ func (a *STAG) GetLambda() float64 {
 	return a.lambda
 }
 
+func (a *STAG) Channels() []sensor.Channel {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return []sensor.Channel{
+		{Name: "Lambda", Unit: "λ", Value: a.lambda, HasStatus: true, Status: a.status},
+		{Name: "Oxygen", Unit: "%", Value: a.oxygen},
+	}
+}
+
 func (a *STAG) run(ctx context.Context) {
 	packetContentBuffer := make([]byte, 0, 64)
 	buf := make([]byte, 8)
@@ -168,14 +179,18 @@ func (a *STAG) SetData(data []byte) error {
 	switch data[6] {
 	case 0x00:
 		a.log("status_sleep")
+		a.status = sensor.Warming
 	case 0x01:
 		a.log("status_warming")
+		a.status = sensor.Warming
 	case 0x02:
 		// status_work
+		a.status = 0
 		a.lambda = float64(uint32(data[12])<<24|uint32(data[13])<<16|uint32(data[14])<<8|uint32(data[15])) * 0.001
 		a.oxygen = float64((uint16(data[16])<<8)|uint16(data[17])) * 0.1
 	case 0x03:
 		a.log("status_breakdown")
+		a.status = sensor.Error
 	default:
 	}
 	return nil
@@ -11,12 +11,16 @@ import (
 	"github.com/roffe/txlogger/pkg/wbl/ecumaster"
 	"github.com/roffe/txlogger/pkg/wbl/innovate"
 	"github.com/roffe/txlogger/pkg/wbl/plx"
+	"github.com/roffe/txlogger/pkg/wbl/sensor"
 	"github.com/roffe/txlogger/pkg/wbl/stag"
 	"github.com/roffe/txlogger/pkg/wbl/zeitronix"
 )
 
 type LambdaProvider interface {
 	GetLambda() float64
+	// Channels returns every reading of the device, the lambda from GetLambda first.
+	// Channels may be added once the device has reported them
+	Channels() []sensor.Channel
 	Start(context.Context) error
 	Stop()
 	String() string
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/roffe/txlogger/pkg/wbl/sensor"
 	"go.bug.st/serial"
 )
 
@@ -41,6 +42,7 @@ type Zeitronix struct {
 
 	p         serial.Port
 	closeOnce sync.Once
+	mu        sync.Mutex
 	logFunc   func(string)
 }
 
@@ -118,6 +120,8 @@ func (z *Zeitronix) SetData(data []byte) error {
 	if data[0] != 0 || data[1] != 1 || data[2] != 2 {
 		return errors.New("invalid data format")
 	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
 	z.lambdaValue = float64(data[3]) * 0.01
 	z.egtValue = uint16(data[4]) | (uint16(data[5]) << 8)
 	z.rpmValue = uint16(data[6]) | (uint16(data[7]) << 8)
@@ -135,9 +139,24 @@ func (z *Zeitronix) Stop() {
 }
 
 func (z *Zeitronix) GetLambda() float64 {
+	z.mu.Lock()
+	defer z.mu.Unlock()
 	return z.lambdaValue
 }
 
+// Channels returns the lambda and the EGT and RPM inputs, the ZT-2 doesn't report sensor status
+func (z *Zeitronix) Channels() []sensor.Channel {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return []sensor.Channel{
+		{Name: "Lambda", Unit: "λ", Value: z.lambdaValue},
+		{Name: "EGT", Unit: "°C", Value: float64(z.egtValue)},
+		{Name: "RPM", Unit: "rpm", Value: float64(z.rpmValue)},
+	}
+}
+
 func (z *Zeitronix) String() string {
+	z.mu.Lock()
+	defer z.mu.Unlock()
 	return fmt.Sprintf("Lambda: %.3f, EGT: %d, RPM: %d, MAP: %d", z.lambdaValue, z.egtValue, z.rpmValue, z.mapValue)
 }
@@ -0,0 +1,238 @@
+package canflasher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/common"
+	"github.com/roffe/txlogger/pkg/ecu"
+	"github.com/roffe/txlogger/pkg/flasharchive"
+)
+
+func (t *CanFlasherWidget) archive() (*flasharchive.Archive, error) {
+	dir, err := common.GetBackupPath()
+	if err != nil {
+		return nil, err
+	}
+	return flasharchive.New(dir), nil
+}
+
+// backup makes sure the archive has a dump of the ECU before it is flashed, an existing
+// backup with the same VIN is only used if it holds what was last flashed to the ECU
+func (t *CanFlasherWidget) backup(ctx context.Context, archive *flasharchive.Archive, tr ecu.Client, name string) (*flasharchive.Backup, error) {
+	info, err := tr.Info(ctx)
+	if err != nil {
+		t.log("failed to read ECU info: " + err.Error())
+	}
+
+	b, err := archive.FindBackup(name, flasharchive.VIN(info))
+	if err != nil {
+		return nil, err
+	}
+	if b != nil {
+		t.log("Found backup of the current ECU contents from " + b.Time.Format("2006-01-02 15:04:05") + ": " + b.File)
+		return b, nil
+	}
+
+	t.log("Dumping ECU before flashing")
+	bin, err := tr.DumpECU(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("backup failed: %w", err)
+	}
+	b, err = archive.SaveBackup(name, info, bin, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("backup failed: %w", err)
+	}
+	t.log("Backup saved as " + b.File)
+	return b, nil
+}
+
+// ecuRestore flashes a backup of the selected ECU type from the archive
+func (t *CanFlasherWidget) ecuRestore() {
+	archive, err := t.archive()
+	if err != nil {
+		t.log(err.Error())
+		return
+	}
+	name := t.ecuSelect.Selected
+	backups, err := archive.Backups(name)
+	if err != nil {
+		t.log(err.Error())
+		return
+	}
+	if len(backups) == 0 {
+		t.log("No backups of " + name + " in " + archive.Dir())
+		return
+	}
+
+	options := make([]string, len(backups))
+	for i, b := range backups {
+		options[i] = b.String()
+	}
+	details := widget.NewLabel("")
+	details.Wrapping = fyne.TextWrapWord
+	selected := 0
+	sel := widget.NewSelect(options, func(s string) {
+		for i, o := range options {
+			if o == s {
+				selected = i
+			}
+		}
+		b := backups[selected]
+		lines := []string{b.File, fmt.Sprintf("%d bytes", b.Size)}
+		for _, info := range b.Info {
+			lines = append(lines, info.Desc+": "+info.Value)
+		}
+		details.SetText(strings.Join(lines, "\n"))
+	})
+	sel.SetSelectedIndex(0)
+
+	content := container.NewBorder(sel, nil, nil, nil, container.NewVScroll(details))
+	d := dialog.NewCustomConfirm("Restore "+name+" backup", "Flash", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		b := backups[selected]
+		bin, err := os.ReadFile(b.File)
+		if err != nil {
+			t.log(err.Error())
+			return
+		}
+		if flasharchive.MD5(bin) != b.MD5 {
+			t.log("Backup " + b.File + " does not match its recorded md5, not flashing")
+			return
+		}
+		t.log("Restoring " + b.File)
+		t.flash(flashJob{bin: bin, filename: b.File, opts: t.flashOptions(), restore: true})
+	}, fyne.CurrentApp().Driver().AllWindows()[0])
+	d.Resize(fyne.NewSize(600, 400))
+	d.Show()
+}
+
+// ecuRecover finishes the latest flash of the selected ECU type if it failed or was interrupted,
+// ECUs still in the bootloader only get the partitions that don't match the file written
+func (t *CanFlasherWidget) ecuRecover() {
+	archive, err := t.archive()
+	if err != nil {
+		t.log(err.Error())
+		return
+	}
+	name := t.ecuSelect.Selected
+	e, err := archive.Interrupted(name)
+	if err != nil {
+		t.log(err.Error())
+		return
+	}
+	if e == nil {
+		t.log("No failed flash of " + name + " to recover")
+		return
+	}
+
+	bin, err := os.ReadFile(e.File)
+	if err != nil {
+		t.log(err.Error())
+		return
+	}
+	if flasharchive.MD5(bin) != e.MD5 {
+		t.log(e.File + " has changed since it was flashed, flash it again instead")
+		return
+	}
+
+	opts := t.flashOptions()
+	opts.Boot, opts.NVDM = e.Boot, e.NVDM
+	msg := fmt.Sprintf("The flash of %s at %s failed:\n%s\n\nFlash it again? Partitions that already match are skipped if the ECU is still in the bootloader.",
+		e.File, e.Time.Format("2006-01-02 15:04:05"), e.Error)
+	dialog.ShowConfirm("Recover "+name, msg, func(ok bool) {
+		if !ok {
+			return
+		}
+		t.log("Recovering " + e.File)
+		t.flash(flashJob{bin: bin, filename: e.File, checksum: e.Checksum, opts: opts, restore: e.Restore, resume: true})
+	}, fyne.CurrentApp().Driver().AllWindows()[0])
+}
+
+func flashHistoryText(e flasharchive.Entry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s", e.Time.Format("2006-01-02 15:04:05"), e.ECU)
+	if e.VIN != "" {
+		b.WriteString("  " + e.VIN)
+	}
+	if e.Restore {
+		b.WriteString("  restore")
+	}
+	if e.Resume {
+		b.WriteString("  resume")
+	}
+	fmt.Fprintf(&b, "\n%s (%d bytes, md5 %s)", e.File, e.Size, e.MD5)
+	if e.Checksum != "" {
+		b.WriteString(", checksum " + e.Checksum)
+	}
+	var status []string
+	if len(e.Partitions) > 0 {
+		status = append(status, fmt.Sprintf("partitions %v", e.Partitions))
+	}
+	switch {
+	case e.Verify != ecu.VerifyMD5.String():
+		status = append(status, "not verified")
+	case e.Verified:
+		status = append(status, "md5 verified")
+	case len(e.Partitions) > 0:
+		status = append(status, "md5 verify failed")
+	}
+	status = append(status, "took "+e.Duration.Round(time.Second).String())
+	b.WriteString("\n" + strings.Join(status, ", "))
+	if e.Backup != "" {
+		b.WriteString("\nbackup " + e.Backup)
+	}
+	if e.Error != "" {
+		b.WriteString("\nfailed: " + e.Error)
+	}
+	return b.String()
+}
+
+// flashHistory shows the flash journal, newest session first
+func (t *CanFlasherWidget) flashHistory() {
+	archive, err := t.archive()
+	if err != nil {
+		t.log(err.Error())
+		return
+	}
+	entries, err := archive.Journal()
+	if err != nil {
+		t.log(err.Error())
+		return
+	}
+	if len(entries) == 0 {
+		t.log("No flash sessions recorded")
+		return
+	}
+	list := widget.NewList(
+		func() int {
+			return len(entries)
+		},
+		func() fyne.CanvasObject {
+			w := widget.NewLabel("")
+			w.TextStyle.Monospace = true
+			w.Selectable = true
+			return w
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(flashHistoryText(entries[id]))
+		},
+	)
+	// entries have a varying number of lines
+	for i, e := range entries {
+		size := widget.NewLabelWithStyle(flashHistoryText(e), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}).MinSize()
+		list.SetItemHeight(i, size.Height)
+	}
+	d := dialog.NewCustom("Flash history", "Close", list, fyne.CurrentApp().Driver().AllWindows()[0])
+	d.Resize(fyne.NewSize(800, 500))
+	d.Show()
+}
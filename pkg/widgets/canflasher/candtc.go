@@ -0,0 +1,80 @@
+package canflasher
+
+import (
+	"context"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/ecu"
+)
+
+func (t *CanFlasherWidget) ecuReadDTC() {
+	t.withECU(func(ctx context.Context, tr ecu.Client) {
+		dtcs, err := tr.ReadDTC(ctx)
+		if err != nil {
+			t.log(err.Error())
+			return
+		}
+		if len(dtcs) == 0 {
+			t.log("No DTCs found")
+			return
+		}
+		for _, d := range dtcs {
+			text := d.String()
+			if info := d.Info(); info.Name != "" {
+				text += " - " + info.Name
+			}
+			t.log(text)
+			if status := d.StatusString(); status != "" {
+				t.log("  " + status)
+			}
+		}
+	})
+}
+
+func (t *CanFlasherWidget) ecuClearDTC() {
+	t.withECU(func(ctx context.Context, tr ecu.Client) {
+		if err := tr.ClearDTC(ctx); err != nil {
+			t.log(err.Error())
+			return
+		}
+		t.log("DTCs cleared")
+	})
+}
+
+func (t *CanFlasherWidget) withECU(fn func(context.Context, ecu.Client)) {
+	dev, err := t.cfg.CSW.GetAdapter(t.ecuSelect.Selected)
+	if err != nil {
+		t.log(err.Error())
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		fyne.Do(t.Disable)
+		defer fyne.Do(t.Enable)
+
+		c, err := gocan.NewWithOpts(ctx, dev)
+		if err != nil {
+			t.logValues.Append(err.Error())
+			return
+		}
+		defer c.Close()
+
+		tr, err := ecu.New(c, &ecu.Config{
+			Name:       t.ecuSelect.Selected,
+			OnProgress: t.progress,
+			OnMessage:  func(s string) { t.logValues.Append(s) },
+			OnError:    func(err error) { t.logValues.Append(err.Error()) },
+		})
+		if err != nil {
+			t.log(err.Error())
+			return
+		}
+
+		fn(ctx, tr)
+	}()
+}
@@ -68,6 +68,7 @@ func (t *CanFlasherWidget) ecuDump() {
 
 		tr, err := ecu.New(c, &ecu.Config{
 			Name:       t.ecuSelect.Selected,
+			Flash:      t.flashOptions(),
 			OnProgress: t.progress,
 			OnMessage: func(s string) {
 				t.logValues.Append(fmt.Sprintf("%s - %s\n", time.Now().Format("15:04:05.000"), s))
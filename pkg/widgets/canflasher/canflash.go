@@ -2,14 +2,18 @@ package canflasher
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
 	"github.com/roffe/gocan"
 	"github.com/roffe/txlogger/pkg/ecu"
+	"github.com/roffe/txlogger/pkg/ecu/t7"
+	"github.com/roffe/txlogger/pkg/flasharchive"
 	"github.com/roffe/txlogger/pkg/native"
 )
 
@@ -23,13 +27,60 @@ func (t *CanFlasherWidget) ecuFlash() {
 		return
 	}
 
-	dev, err := t.cfg.CSW.GetAdapter(t.ecuSelect.Selected)
+	bin, err := os.ReadFile(filename)
 	if err != nil {
 		t.log(err.Error())
 		return
 	}
 
-	bin, err := os.ReadFile(filename)
+	if t.ecuSelect.Selected == "Trionic 7" {
+		if err := t7.VerifyChecksum(bin); err != nil {
+			t.log(err.Error())
+			if !errors.Is(err, t7.ErrChecksumMismatch) {
+				return
+			}
+			dialog.ShowConfirm("Checksum mismatch", "The bin has invalid checksums, correct them before flashing?", func(b bool) {
+				if !b {
+					t.log("Flash aborted")
+					return
+				}
+				if err := t7.FixChecksum(bin); err != nil {
+					t.log(err.Error())
+					return
+				}
+				t.log("Checksums corrected")
+				t.flash(flashJob{bin: bin, filename: filename, checksum: "corrected", opts: t.flashOptions()})
+			}, fyne.CurrentApp().Driver().AllWindows()[0])
+			return
+		}
+		t.log("Checksums OK")
+		t.flash(flashJob{bin: bin, filename: filename, checksum: "OK", opts: t.flashOptions()})
+		return
+	}
+
+	t.flash(flashJob{bin: bin, filename: filename, opts: t.flashOptions()})
+}
+
+type flashJob struct {
+	bin      []byte
+	filename string
+	// Checksum status of the file for the journal
+	checksum string
+	opts     ecu.FlashOptions
+	// bin is a backup from the archive
+	restore bool
+	// Finish an interrupted flash of the same file
+	resume bool
+}
+
+func (t *CanFlasherWidget) flash(job flashJob) {
+	archive, err := t.archive()
+	if err != nil {
+		t.log(err.Error())
+		return
+	}
+
+	dev, err := t.cfg.CSW.GetAdapter(t.ecuSelect.Selected)
 	if err != nil {
 		t.log(err.Error())
 		return
@@ -50,9 +101,32 @@ func (t *CanFlasherWidget) ecuFlash() {
 		}
 	}()
 
+	name := t.ecuSelect.Selected
+	bin, opts := job.bin, job.opts
+	// an ECU that failed to flash can't be dumped
+	backup := t.backupBOX.Checked && !job.restore && !job.resume
+
+	entry := flasharchive.Entry{
+		Time:     time.Now(),
+		ECU:      name,
+		File:     job.filename,
+		Size:     len(bin),
+		MD5:      flasharchive.MD5(bin),
+		Checksum: job.checksum,
+		Boot:     opts.Boot,
+		NVDM:     opts.NVDM,
+		Verify:   opts.Verify.String(),
+		Restore:  job.restore,
+		Resume:   job.resume,
+	}
+
 	go func() {
 		defer close(done)
-		ctx, cancel := context.WithTimeout(context.Background(), 1800*time.Second)
+		timeout := 1800 * time.Second
+		if backup {
+			timeout += 1200 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 
 		//defer dev.Close()
@@ -68,7 +142,8 @@ func (t *CanFlasherWidget) ecuFlash() {
 		defer c.Close()
 
 		tr, err := ecu.New(c, &ecu.Config{
-			Name:       t.ecuSelect.Selected,
+			Name:       name,
+			Flash:      opts,
 			OnProgress: t.progress,
 			OnMessage: func(s string) {
 				t.logValues.Append(fmt.Sprintf("%s - %s\n", time.Now().Format("15:04:05.000"), s))
@@ -76,13 +151,52 @@ func (t *CanFlasherWidget) ecuFlash() {
 			OnError: func(err error) {
 				t.logValues.Append(fmt.Sprintf("%s - %s\n", time.Now().Format("15:04:05.000"), err.Error()))
 			},
+			OnFlashResult: func(r ecu.FlashResult) {
+				entry.Partitions = r.Partitions
+				entry.Verified = r.Verified
+			},
 		})
 		if err != nil {
 			t.log(err.Error())
 			return
 		}
 
+		if job.resume {
+			if r, ok := tr.(ecu.Resumer); !ok {
+				t.log(name + " can't resume a flash, writing the whole file")
+			} else if r.InBootloader(ctx) {
+				t.log("ECU is in bootloader, writing the partitions that don't match")
+			} else {
+				t.log("ECU is not in bootloader, starting the bootloader")
+			}
+		}
+
+		if backup {
+			b, err := t.backup(ctx, archive, tr, name)
+			if err != nil {
+				t.log(err.Error())
+				t.log("Flash aborted, no backup of the ECU")
+				return
+			}
+			entry.Backup = b.File
+			entry.VIN = b.VIN
+		}
+
+		if err := archive.SetPending(&entry); err != nil {
+			t.log("failed to write flash journal: " + err.Error())
+		}
+		start := time.Now()
 		err = tr.FlashECU(ctx, bin)
+		entry.Duration = time.Since(start)
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		if err := archive.Log(entry); err != nil {
+			t.log("failed to write flash journal: " + err.Error())
+		}
+		if err := archive.SetPending(nil); err != nil {
+			t.log("failed to write flash journal: " + err.Error())
+		}
 		if err != nil {
 			t.log(err.Error())
 			return
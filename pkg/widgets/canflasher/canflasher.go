@@ -13,6 +13,7 @@ import (
 
 	// Import ecu packages
 
+	"github.com/roffe/txlogger/pkg/ecu"
 	_ "github.com/roffe/txlogger/pkg/ecu/t5"
 	_ "github.com/roffe/txlogger/pkg/ecu/t5legion"
 	_ "github.com/roffe/txlogger/pkg/ecu/t7"
@@ -37,10 +38,16 @@ type CanFlasherWidget struct {
 	logList     *widget.List
 	logValues   binding.StringList
 	infoBTN     *widget.Button
+	dtcBTN      *widget.Button
+	clearBTN    *widget.Button
 	dumpBTN     *widget.Button
 	flashBTN    *widget.Button
+	restoreBTN  *widget.Button
+	recoverBTN  *widget.Button
+	historyBTN  *widget.Button
 	bootBOX     *widget.Check
 	nvdmBOX     *widget.Check
+	backupBOX   *widget.Check
 	progressBar *widget.ProgressBar
 
 	l binding.DataListener
@@ -64,18 +71,36 @@ func New(cfg *Config) *CanFlasherWidget {
 
 func (t *CanFlasherWidget) Disable() {
 	t.infoBTN.Disable()
+	t.dtcBTN.Disable()
+	t.clearBTN.Disable()
 	t.dumpBTN.Disable()
 	t.flashBTN.Disable()
+	t.restoreBTN.Disable()
+	t.recoverBTN.Disable()
 	t.bootBOX.Disable()
 	t.nvdmBOX.Disable()
+	t.backupBOX.Disable()
 }
 
 func (t *CanFlasherWidget) Enable() {
 	t.infoBTN.Enable()
+	t.dtcBTN.Enable()
+	t.clearBTN.Enable()
 	t.dumpBTN.Enable()
 	t.flashBTN.Enable()
+	t.restoreBTN.Enable()
+	t.recoverBTN.Enable()
 	t.bootBOX.Enable()
 	t.nvdmBOX.Enable()
+	t.backupBOX.Enable()
+}
+
+// flashOptions returns the flash options selected in the widget
+func (t *CanFlasherWidget) flashOptions() ecu.FlashOptions {
+	return ecu.FlashOptions{
+		Boot: t.bootBOX.Checked,
+		NVDM: t.nvdmBOX.Checked,
+	}
 }
 
 func (t *CanFlasherWidget) log(s string) {
@@ -135,10 +160,14 @@ func (t *CanFlasherWidget) CreateRenderer() fyne.WidgetRenderer {
 
 	// t.wizzardBTN = widget.NewButton("Wizzard", nil) //t.wizzard)
 	t.infoBTN = widget.NewButton("Info", t.ecuInfo) //t.ecuInfo)
-	//t.dtcBTN = widget.NewButton("Read DTC", nil)   //t.readDTC)
+	t.dtcBTN = widget.NewButton("Read DTC", t.ecuReadDTC)
+	t.clearBTN = widget.NewButton("Clear DTC", t.ecuClearDTC)
 	t.dumpBTN = widget.NewButton("Dump", t.ecuDump)
 	//t.sramBTN = widget.NewButton("Dump SRAM", nil) //t.dumpSRAM)
 	t.flashBTN = widget.NewButton("Flash", t.ecuFlash)
+	t.restoreBTN = widget.NewButton("Restore previous", t.ecuRestore)
+	t.recoverBTN = widget.NewButton("Recover failed flash", t.ecuRecover)
+	t.historyBTN = widget.NewButton("Flash history", t.flashHistory)
 
 	t.bootBOX = widget.NewCheck("boot", func(b bool) {
 		fyne.CurrentApp().Preferences().SetBool(settings.PrefsBoot, b)
@@ -149,6 +178,11 @@ func (t *CanFlasherWidget) CreateRenderer() fyne.WidgetRenderer {
 
 	t.nvdmBOX.SetChecked(fyne.CurrentApp().Preferences().BoolWithFallback(settings.PrefsNvdm, false))
 	t.bootBOX.SetChecked(fyne.CurrentApp().Preferences().BoolWithFallback(settings.PrefsBoot, false))
+
+	t.backupBOX = widget.NewCheck("backup first", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool("canflasher_backup", b)
+	})
+	t.backupBOX.SetChecked(fyne.CurrentApp().Preferences().BoolWithFallback("canflasher_backup", true))
 	// t.ecuList.PlaceHolder = "Select ECU"
 	// t.adapterList.PlaceHolder = "Select Adapter"
 	// t.portList.PlaceHolder = "Select Port"
@@ -158,13 +192,18 @@ func (t *CanFlasherWidget) CreateRenderer() fyne.WidgetRenderer {
 	right := container.NewVBox(
 		t.ecuSelect,
 		t.infoBTN,
-		//t.dtcBTN,
+		t.dtcBTN,
+		t.clearBTN,
 		t.dumpBTN,
 		//t.sramBTN,
 		t.flashBTN,
+		t.restoreBTN,
+		t.recoverBTN,
+		t.historyBTN,
 		widget.NewLabel("Flash options:"),
 		t.bootBOX,
 		t.nvdmBOX,
+		t.backupBOX,
 	)
 
 	split := container.NewHSplit(left, right)
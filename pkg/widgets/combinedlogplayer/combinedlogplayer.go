@@ -26,6 +26,8 @@ type Widget struct {
 type CombinedLogplayerConfig struct {
 	Logfile logfile.Logfile
 	DBcfg   *dashboard.Config
+	// OnMarkersChanged is called when markers are edited in the player
+	OnMarkersChanged func([]logfile.Marker)
 }
 
 func New(cfg *CombinedLogplayerConfig) *Widget {
@@ -52,9 +54,10 @@ func New(cfg *CombinedLogplayerConfig) *Widget {
 
 	cp.db = db
 	cp.lp = logplayer.New(&logplayer.Config{
-		EBus:       bus,
-		Logfile:    cfg.Logfile,
-		TimeSetter: db.SetTime,
+		EBus:             bus,
+		Logfile:          cfg.Logfile,
+		TimeSetter:       db.SetTime,
+		OnMarkersChanged: cfg.OnMarkersChanged,
 	})
 
 	cp.ExtendBaseWidget(cp)
@@ -0,0 +1,185 @@
+// Package histogram shows log samples binned onto the axes of a map with
+// the number of samples and the average of a channel in every cell
+package histogram
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/colors"
+	"github.com/roffe/txlogger/pkg/interpolate"
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+const hitsOnly = "Hits only"
+
+var _ fyne.Widget = (*Histogram)(nil)
+
+type Config struct {
+	Logfile logfile.Logfile
+	// Map axes and the channels placing a sample on them
+	XAxis    []float64
+	YAxis    []float64
+	XChannel string
+	YChannel string
+	// Channel averaged in every cell, empty shows only the sample count
+	ZChannel string
+
+	XPrecision     int
+	YPrecision     int
+	ColorblindMode colors.ColorBlindMode
+}
+
+type Histogram struct {
+	widget.BaseWidget
+
+	cfg    *Config
+	values map[string][]float64
+
+	zSelect *widget.Select
+	grid    *fyne.Container
+
+	container *fyne.Container
+}
+
+// Cell holds the samples binned to one map cell
+type Cell struct {
+	Count int
+	// Average of the z channel, NaN without samples
+	Avg float64
+}
+
+func New(cfg *Config) *Histogram {
+	h := &Histogram{
+		cfg:    cfg,
+		values: logfile.Values(cfg.Logfile),
+		grid:   container.NewGridWithColumns(len(cfg.XAxis) + 1),
+	}
+	h.ExtendBaseWidget(h)
+
+	names := make([]string, 0, len(h.values))
+	for k := range h.values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	h.zSelect = widget.NewSelect(append([]string{hitsOnly}, names...), func(s string) {
+		if s == hitsOnly {
+			s = ""
+		}
+		h.cfg.ZChannel = s
+		h.render()
+	})
+	if cfg.ZChannel == "" {
+		h.zSelect.Selected = hitsOnly
+	} else {
+		h.zSelect.Selected = cfg.ZChannel
+	}
+
+	h.container = container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel(cfg.YChannel+" / "+cfg.XChannel), nil,
+			container.NewBorder(nil, nil, widget.NewLabel("Average"), nil, h.zSelect),
+		),
+		nil,
+		nil,
+		nil,
+		container.NewScroll(h.grid),
+	)
+	h.render()
+	return h
+}
+
+// Bin places every sample in the map cell closest to it, cells are in row order
+func Bin(values map[string][]float64, xAxis, yAxis []float64, xChannel, yChannel, zChannel string) []Cell {
+	cells := make([]Cell, len(xAxis)*len(yAxis))
+	sums := make([]float64, len(cells))
+	xs, ys, zs := values[xChannel], values[yChannel], values[zChannel]
+	if len(xAxis) == 0 || len(yAxis) == 0 {
+		return cells
+	}
+	for i := range min(len(xs), len(ys)) {
+		x, y := xs[i], ys[i]
+		if math.IsNaN(x) || math.IsNaN(y) {
+			continue
+		}
+		idx := interpolate.NearestIndex64(yAxis, y)*len(xAxis) + interpolate.NearestIndex64(xAxis, x)
+		if zs != nil {
+			if math.IsNaN(zs[i]) {
+				continue
+			}
+			sums[idx] += zs[i]
+		}
+		cells[idx].Count++
+	}
+	for i := range cells {
+		cells[i].Avg = math.NaN()
+		if cells[i].Count > 0 && zs != nil {
+			cells[i].Avg = sums[i] / float64(cells[i].Count)
+		}
+	}
+	return cells
+}
+
+func (h *Histogram) render() {
+	cfg := h.cfg
+	cells := Bin(h.values, cfg.XAxis, cfg.YAxis, cfg.XChannel, cfg.YChannel, cfg.ZChannel)
+	maxCount := 1
+	for _, c := range cells {
+		maxCount = max(maxCount, c.Count)
+	}
+
+	var objs []fyne.CanvasObject
+	// the highest y value is at the top like in the map viewer
+	for y := len(cfg.YAxis) - 1; y >= 0; y-- {
+		objs = append(objs, axisLabel(cfg.YAxis[y], cfg.YPrecision))
+		for x := range cfg.XAxis {
+			objs = append(objs, h.cell(cells[y*len(cfg.XAxis)+x], maxCount))
+		}
+	}
+	objs = append(objs, canvas.NewText("", color.Transparent))
+	for _, v := range cfg.XAxis {
+		objs = append(objs, axisLabel(v, cfg.XPrecision))
+	}
+	h.grid.Objects = objs
+	h.grid.Refresh()
+}
+
+func (h *Histogram) cell(c Cell, maxCount int) fyne.CanvasObject {
+	bg := canvas.NewRectangle(color.Transparent)
+	count := canvas.NewText("", theme.Color(theme.ColorNameForeground))
+	count.Alignment = fyne.TextAlignCenter
+	count.TextSize = theme.TextSize() * 0.8
+	avg := canvas.NewText("", theme.Color(theme.ColorNameForeground))
+	avg.Alignment = fyne.TextAlignCenter
+	avg.TextStyle.Bold = true
+
+	if c.Count > 0 {
+		bg.FillColor = colors.GetColorInterpolation(0, float64(maxCount), float64(c.Count), h.cfg.ColorblindMode)
+		count.Text = strconv.Itoa(c.Count)
+		count.Color = color.Black
+		avg.Color = color.Black
+		if !math.IsNaN(c.Avg) {
+			avg.Text = strconv.FormatFloat(c.Avg, 'f', 2, 64)
+		}
+	}
+	bg.StrokeColor = theme.Color(theme.ColorNameSeparator)
+	bg.StrokeWidth = 1
+	return container.NewStack(bg, container.NewVBox(avg, count))
+}
+
+func axisLabel(v float64, precision int) fyne.CanvasObject {
+	t := canvas.NewText(strconv.FormatFloat(v, 'f', precision, 64), theme.Color(theme.ColorNameForeground))
+	t.Alignment = fyne.TextAlignCenter
+	t.TextStyle.Bold = true
+	return container.NewCenter(t)
+}
+
+func (h *Histogram) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(h.container)
+}
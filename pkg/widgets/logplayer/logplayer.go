@@ -53,6 +53,14 @@ type Logplayer struct {
 	logFile  logfile.Logfile
 	playOnce sync.Once
 
+	// record times and channel values in record order
+	times  []time.Time
+	values map[string][]float64
+
+	markerMu  sync.Mutex
+	markers   []logfile.Marker
+	markerPos []int
+
 	OnMouseDown func()
 
 	focused bool
@@ -65,8 +73,14 @@ type logplayerObjects struct {
 	rewindBtn         *widget.Button
 	playbackToggleBtn *widget.Button
 	forwardBtn        *widget.Button
+	prevMarkerBtn     *widget.Button
+	nextMarkerBtn     *widget.Button
+	markerBtn         *widget.Button
+	statisticsBtn     *widget.Button
+	viewsBtn          *widget.Button
 	positionSlider    *slider
 	timeLabel         *widget.Label
+	markerLabel       *widget.Label
 	speedSelect       *widget.Select
 }
 
@@ -74,6 +88,12 @@ type Config struct {
 	EBus       *eventbus.Controller
 	Logfile    logfile.Logfile
 	TimeSetter func(time.Time)
+	// OnMarkersChanged is called when markers are added, edited or removed in the player
+	OnMarkersChanged func([]logfile.Marker)
+	// OnStatistics adds a button showing statistics for the log, values holds one value per record for every channel
+	OnStatistics func(times []time.Time, values map[string][]float64)
+	// Views adds a menu button opening other views of the log
+	Views []*fyne.MenuItem
 }
 
 func New(cfg *Config) *Logplayer {
@@ -163,13 +183,30 @@ func (l *Logplayer) TypedKey(ev *fyne.KeyEvent) {
 		l.control(&controlMsg{Op: OpNext})
 	case fyne.KeySpace:
 		l.objs.playbackToggleBtn.OnTapped()
+	case fyne.KeyLeftBracket:
+		l.prevMarker()
+	case fyne.KeyRightBracket:
+		l.nextMarker()
+	case fyne.KeyM:
+		l.editMarker()
 	}
 }
 
 func (l *Logplayer) TypedRune(_ rune) {
 }
 
+// Seek moves the playback position to record pos
+func (l *Logplayer) Seek(pos int) {
+	if pos < 0 {
+		pos = 0
+	}
+	l.control(&controlMsg{Op: OpSeek, Pos: pos})
+}
+
 func (l *Logplayer) control(op *controlMsg) {
+	if l.closed {
+		return
+	}
 	select {
 	case l.controlChan <- op:
 		//		log.Println("control", op.Op, op.Pos)
@@ -227,9 +264,16 @@ func (l *Logplayer) render() {
 		l.control(&controlMsg{Op: OpNext})
 	})
 
+	l.objs.prevMarkerBtn = widget.NewButtonWithIcon("", theme.MediaSkipPreviousIcon(), l.prevMarker)
+	l.objs.nextMarkerBtn = widget.NewButtonWithIcon("", theme.MediaSkipNextIcon(), l.nextMarker)
+	l.objs.markerBtn = widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), l.editMarker)
+	l.objs.markerLabel = widget.NewLabel("")
+	l.objs.markerLabel.Truncation = fyne.TextTruncateEllipsis
+
 	values := make(map[string][]float64)
 	for {
 		if rec := l.logFile.Next(); !rec.EOF {
+			l.times = append(l.times, rec.Time)
 			for k, v := range rec.Values {
 				if k == "Pgm_status" {
 					continue
@@ -241,6 +285,24 @@ func (l *Logplayer) render() {
 		}
 	}
 	l.logFile.Seek(-1)
+	l.values = values
+
+	if f := l.cfg.OnStatistics; f != nil {
+		l.objs.statisticsBtn = widget.NewButtonWithIcon("", theme.ListIcon(), func() {
+			f(l.times, l.values)
+		})
+	}
+	if len(l.cfg.Views) > 0 {
+		l.objs.viewsBtn = widget.NewButtonWithIcon("", theme.MoreVerticalIcon(), nil)
+		l.objs.viewsBtn.OnTapped = func() {
+			d := fyne.CurrentApp().Driver()
+			widget.ShowPopUpMenuAtPosition(
+				fyne.NewMenu("", l.cfg.Views...),
+				d.CanvasForObject(l.objs.viewsBtn),
+				d.AbsolutePositionForObject(l.objs.viewsBtn),
+			)
+		}
+	}
 
 	l.objs.plotter = plotter.NewPlotter(
 		values,
@@ -257,26 +319,39 @@ func (l *Logplayer) render() {
 			l.control(&controlMsg{Op: OpSeek, Pos: int(pos)})
 		}),
 	)
+	l.setMarkers(l.logFile.Markers())
 }
 
 func (l *Logplayer) CreateRenderer() fyne.WidgetRenderer {
+	buttons := []fyne.CanvasObject{
+		l.objs.rewindBtn,
+		l.objs.playbackToggleBtn,
+		l.objs.forwardBtn,
+		l.objs.restartBtn,
+		l.objs.prevMarkerBtn,
+		l.objs.nextMarkerBtn,
+		l.objs.markerBtn,
+	}
+	if l.objs.statisticsBtn != nil {
+		buttons = append(buttons, l.objs.statisticsBtn)
+	}
+	if l.objs.viewsBtn != nil {
+		buttons = append(buttons, l.objs.viewsBtn)
+	}
+
 	l.container = container.NewBorder(
 		nil,
 		container.NewBorder(
 			nil,
 			nil,
-			container.NewGridWithColumns(4,
-				l.objs.rewindBtn,
-				l.objs.playbackToggleBtn,
-				l.objs.forwardBtn,
-				l.objs.restartBtn,
-			),
+			container.NewGridWithColumns(len(buttons), buttons...),
 			nil,
 			container.NewBorder(
 				nil,
 				nil,
 				nil,
 				container.NewHBox(
+					layout.NewFixedWidth(150, l.objs.markerLabel),
 					layout.NewFixedWidth(85, l.objs.timeLabel),
 					layout.NewFixedWidth(75, l.objs.speedSelect),
 				),
@@ -386,6 +461,9 @@ func (l *Logplayer) playLog() {
 				l.logFile.Seek(op.Pos)
 				if rec := l.logFile.Get(); !rec.EOF {
 					l.objs.positionSlider.Value = float64(op.Pos)
+					fyne.Do(func() {
+						l.objs.positionSlider.Refresh()
+					})
 
 					if f := l.cfg.TimeSetter; f != nil {
 						f(rec.Time)
@@ -399,7 +477,7 @@ func (l *Logplayer) playLog() {
 						timeSetter(rec.Time)
 						timer.Stop()
 					}
-					l.objs.plotter.Seek(op.Pos)
+					l.seekPlotter(op.Pos)
 				}
 			case OpPrev:
 				if rec := l.logFile.Prev(); !rec.EOF {
@@ -418,7 +496,7 @@ func (l *Logplayer) playLog() {
 						}
 					}
 
-					l.objs.plotter.Seek(pos)
+					l.seekPlotter(pos)
 					if f := l.cfg.TimeSetter; f != nil {
 						f(rec.Time)
 					}
@@ -442,7 +520,7 @@ func (l *Logplayer) playLog() {
 					if f := l.cfg.TimeSetter; f != nil {
 						f(rec.Time)
 					}
-					l.objs.plotter.Seek(pos)
+					l.seekPlotter(pos)
 				}
 			case OpPlay:
 				l.state = statePlaying
@@ -473,7 +551,7 @@ func (l *Logplayer) playLog() {
 				if f := l.cfg.TimeSetter; f != nil {
 					f(rec.Time)
 				}
-				l.objs.plotter.Seek(currentPos)
+				l.seekPlotter(currentPos)
 			} else {
 				l.state = stateStopped
 				fyne.Do(func() {
@@ -0,0 +1,137 @@
+package logplayer
+
+import (
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+// setMarkers stores the markers in the log and places them on the timeline
+func (l *Logplayer) setMarkers(markers []logfile.Marker) {
+	l.logFile.SetMarkers(markers)
+	markers = l.logFile.Markers()
+	positions := make([]int, len(markers))
+	for i, m := range markers {
+		positions[i] = l.logFile.Index(m.Time)
+	}
+
+	l.markerMu.Lock()
+	l.markers = markers
+	l.markerPos = positions
+	l.markerMu.Unlock()
+
+	l.objs.plotter.SetMarkers(positions)
+}
+
+// seekPlotter moves the plotter cursor and shows the last marker passed
+func (l *Logplayer) seekPlotter(pos int) {
+	l.objs.plotter.Seek(pos)
+
+	var text string
+	l.markerMu.Lock()
+	for i, p := range l.markerPos {
+		if p > pos {
+			break
+		}
+		text = l.markers[i].Text
+	}
+	l.markerMu.Unlock()
+
+	fyne.Do(func() {
+		if l.objs.markerLabel.Text != text {
+			l.objs.markerLabel.SetText(text)
+		}
+	})
+}
+
+func (l *Logplayer) prevMarker() {
+	pos := int(l.objs.positionSlider.Value)
+	target := -1
+	l.markerMu.Lock()
+	for _, p := range l.markerPos {
+		if p >= pos {
+			break
+		}
+		target = p
+	}
+	l.markerMu.Unlock()
+	if target >= 0 {
+		l.Seek(target)
+	}
+}
+
+func (l *Logplayer) nextMarker() {
+	pos := int(l.objs.positionSlider.Value)
+	target := -1
+	l.markerMu.Lock()
+	for _, p := range l.markerPos {
+		if p > pos {
+			target = p
+			break
+		}
+	}
+	l.markerMu.Unlock()
+	if target >= 0 {
+		l.Seek(target)
+	}
+}
+
+// editMarker adds a marker at the current position or edits the one already there,
+// clearing the text removes the marker
+func (l *Logplayer) editMarker() {
+	pos := int(l.objs.positionSlider.Value)
+	if pos < 0 || pos >= len(l.times) {
+		return
+	}
+
+	l.markerMu.Lock()
+	markers := slices.Clone(l.markers)
+	idx := slices.Index(l.markerPos, pos)
+	l.markerMu.Unlock()
+
+	entry := widget.NewEntry()
+	title := "Add marker"
+	if idx >= 0 {
+		title = "Edit marker"
+		entry.SetText(markers[idx].Text)
+	}
+	entry.SetPlaceHolder("Leave empty to remove the marker")
+
+	dialog.ShowForm(title, "Save", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Text", entry),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		switch {
+		case idx >= 0 && entry.Text == "":
+			markers = slices.Delete(markers, idx, idx+1)
+		case idx >= 0:
+			markers[idx].Text = entry.Text
+		case entry.Text != "":
+			markers = append(markers, logfile.Marker{Time: l.times[pos], Text: entry.Text})
+		default:
+			return
+		}
+		l.setMarkers(markers)
+		l.seekPlotter(pos)
+		if f := l.cfg.OnMarkersChanged; f != nil {
+			f(l.logFile.Markers())
+		}
+	}, l.window())
+}
+
+// window returns the window the player is shown in
+func (l *Logplayer) window() fyne.Window {
+	windows := fyne.CurrentApp().Driver().AllWindows()
+	c := fyne.CurrentApp().Driver().CanvasForObject(l)
+	for _, w := range windows {
+		if w.Canvas() == c {
+			return w
+		}
+	}
+	return windows[0]
+}
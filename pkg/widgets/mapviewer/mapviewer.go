@@ -77,6 +77,15 @@ type MapViewer struct {
 
 	popup *widget.PopUpMenu
 
+	// Undo / redo history
+	undoStack, redoStack []*edit
+
+	// Proposed values shown as an overlay, NaN for no proposal
+	proposal      []float64
+	proposalView  *fyne.Container
+	proposalRects []*canvas.Rectangle
+	proposalTexts []*canvas.Text
+
 	widthFactor  float32
 	heightFactor float32
 
@@ -118,6 +127,7 @@ func (mv *MapViewer) CreateRenderer() fyne.WidgetRenderer {
 	mv.createXAxis()
 	mv.createZdata()
 	mv.createTextValues()
+	mv.createProposal()
 	mv.content = mv.render()
 	return widget.NewSimpleRenderer(mv.content)
 	//return &mapViewerRenderer{mv: mv}
@@ -179,6 +189,7 @@ func (mv *MapViewer) render() fyne.CanvasObject {
 			mv.selectionRect,
 		),
 		mv.valueTexts,
+		mv.proposalView,
 	)
 
 	buttons := mv.createButtons()
@@ -307,6 +318,7 @@ func (mv *MapViewer) Refresh() {
 	if mv.mesh != nil {
 		mv.mesh.LoadFloat64s(mv.zMin, mv.zMax, mv.cfg.ZData)
 	}
+	mv.refreshProposal()
 }
 
 func (mv *MapViewer) createYAxis() {
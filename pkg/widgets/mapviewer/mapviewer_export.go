@@ -0,0 +1,81 @@
+package mapviewer
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/roffe/txlogger/pkg/mapfile"
+	"github.com/roffe/txlogger/pkg/widgets"
+)
+
+// Map returns a copy of the map and its axes
+func (mv *MapViewer) Map() *mapfile.Map {
+	return &mapfile.Map{
+		Name:       mv.cfg.Name,
+		XData:      slices.Clone(mv.cfg.XData),
+		YData:      slices.Clone(mv.cfg.YData),
+		ZData:      slices.Clone(mv.cfg.ZData),
+		XPrecision: mv.cfg.XPrecision,
+		YPrecision: mv.cfg.YPrecision,
+		ZPrecision: mv.cfg.ZPrecision,
+		XLabel:     mv.cfg.XLabel,
+		YLabel:     mv.cfg.YLabel,
+		ZLabel:     mv.cfg.ZLabel,
+	}
+}
+
+// Import replaces the map values, the map must have the same dimensions.
+// Like paste the values are not written anywhere until saved
+func (mv *MapViewer) Import(m *mapfile.Map) error {
+	if len(m.XData) != mv.numColumns || len(m.YData) != mv.numRows {
+		return fmt.Errorf("map size mismatch, %s is %dx%d, expected %dx%d", m.Name, len(m.XData), len(m.YData), mv.numColumns, mv.numRows)
+	}
+	if !slices.Equal(m.XData, mv.cfg.XData) || !slices.Equal(m.YData, mv.cfg.YData) {
+		log.Printf("%s: imported axes differs from the binary, values are imported by index", mv.cfg.Name)
+	}
+	cells := mv.allCells()
+	old := mv.snapshot(cells)
+	copy(mv.cfg.ZData, m.ZData)
+	mv.recordEdit(cells, old, false)
+	mv.Refresh()
+	return nil
+}
+
+func (mv *MapViewer) exportMap(ext string) {
+	cb := func(filename string) {
+		if !strings.HasSuffix(strings.ToLower(filename), "."+ext) {
+			filename += "." + ext
+		}
+		if err := mapfile.Write(filename, mv.Map()); err != nil {
+			mv.showError(err)
+			return
+		}
+	}
+	widgets.SaveFile(cb, "Map file", ext)
+}
+
+func (mv *MapViewer) importMap() {
+	cb := func(r fyne.URIReadCloser) {
+		r.Close()
+		m, err := mapfile.Read(r.URI().Path(), mv.cfg.Name)
+		if err != nil {
+			mv.showError(err)
+			return
+		}
+		if err := mv.Import(m); err != nil {
+			mv.showError(err)
+		}
+	}
+	widgets.SelectFile(cb, "Map file", mapfile.Extensions...)
+}
+
+func (mv *MapViewer) showError(err error) {
+	if mv.cfg.OnError != nil {
+		mv.cfg.OnError(err)
+		return
+	}
+	log.Println("MapViewer:", err)
+}
@@ -74,6 +74,7 @@ func (mv *MapViewer) TypedKey(key *fyne.KeyEvent) {
 		if mv.inputBuffer.Len() == 0 {
 			return
 		}
+		old := mv.snapshot(mv.selectedCells)
 		for _, cell := range mv.selectedCells {
 			buff := mv.inputBuffer.String()
 			if strings.Contains(buff, ".") {
@@ -91,7 +92,7 @@ func (mv *MapViewer) TypedKey(key *fyne.KeyEvent) {
 				}
 			}
 		}
-		mv.updateCells()
+		mv.commitEdit(mv.selectedCells, old)
 		mv.Refresh()
 		mv.restoreValues = false
 		mv.inputBuffer.Reset()
@@ -104,10 +105,11 @@ func (mv *MapViewer) TypedKey(key *fyne.KeyEvent) {
 		}
 
 		increment := base * math.Pow(10, -float64(mv.cfg.ZPrecision))
+		old := mv.snapshot(mv.selectedCells)
 		for _, cell := range mv.selectedCells {
 			mv.cfg.ZData[cell] += increment
 		}
-		mv.updateCells()
+		mv.commitEdit(mv.selectedCells, old)
 		refresh = true
 	case fyne.KeyPageDown, "X":
 		base := 10.0
@@ -117,24 +119,27 @@ func (mv *MapViewer) TypedKey(key *fyne.KeyEvent) {
 			}
 		}
 		increment := base * math.Pow(10, -float64(mv.cfg.ZPrecision))
+		old := mv.snapshot(mv.selectedCells)
 		for _, cell := range mv.selectedCells {
 			mv.cfg.ZData[cell] -= increment
 		}
-		mv.updateCells()
+		mv.commitEdit(mv.selectedCells, old)
 		refresh = true
 	case "+", "A":
 		increment := math.Pow(10, -float64(mv.cfg.ZPrecision))
+		old := mv.snapshot(mv.selectedCells)
 		for _, cell := range mv.selectedCells {
 			mv.cfg.ZData[cell] += increment
 		}
-		mv.updateCells()
+		mv.commitEdit(mv.selectedCells, old)
 		refresh = true
 	case "-", "Z":
 		increment := math.Pow(10, -float64(mv.cfg.ZPrecision))
+		old := mv.snapshot(mv.selectedCells)
 		for _, cell := range mv.selectedCells {
 			mv.cfg.ZData[cell] -= increment
 		}
-		mv.updateCells()
+		mv.commitEdit(mv.selectedCells, old)
 		refresh = true
 	case "Up":
 		mv.SelectedY++
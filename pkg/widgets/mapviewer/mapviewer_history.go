@@ -0,0 +1,94 @@
+package mapviewer
+
+import (
+	"slices"
+)
+
+// max number of edits kept for undo
+const historySize = 100
+
+type edit struct {
+	cells    []int
+	old, new []float64
+	// synced is set when the edit was written by syncCells, paste and import
+	// only change the values in the viewer so undo and redo of them does not write either
+	synced bool
+}
+
+// snapshot returns the current values of cells, used as the old values of an edit
+func (mv *MapViewer) snapshot(cells []int) []float64 {
+	values := make([]float64, len(cells))
+	for i, idx := range cells {
+		values[i] = mv.cfg.ZData[idx]
+	}
+	return values
+}
+
+// recordEdit pushes the changed cells to the undo stack and reports them to OnEdit.
+// It must be called before the cells are synced so the change is known when the write completes
+func (mv *MapViewer) recordEdit(cells []int, old []float64, synced bool) {
+	e := &edit{synced: synced}
+	for i, idx := range cells {
+		if mv.cfg.ZData[idx] == old[i] {
+			continue
+		}
+		e.cells = append(e.cells, idx)
+		e.old = append(e.old, old[i])
+		e.new = append(e.new, mv.cfg.ZData[idx])
+	}
+	if len(e.cells) == 0 {
+		return
+	}
+	mv.undoStack = append(mv.undoStack, e)
+	if len(mv.undoStack) > historySize {
+		mv.undoStack = mv.undoStack[1:]
+	}
+	mv.redoStack = nil
+	if mv.cfg.OnEdit != nil {
+		mv.cfg.OnEdit("", e.cells, e.old, e.new)
+	}
+}
+
+// commitEdit records the edit and writes the cells like any other change
+func (mv *MapViewer) commitEdit(cells []int, old []float64) {
+	cells = slices.Clone(cells)
+	mv.recordEdit(cells, old, true)
+	mv.syncCells(cells)
+}
+
+func (mv *MapViewer) Undo() {
+	if !mv.cfg.Editable || len(mv.undoStack) == 0 {
+		return
+	}
+	e := mv.undoStack[len(mv.undoStack)-1]
+	mv.undoStack = mv.undoStack[:len(mv.undoStack)-1]
+	mv.redoStack = append(mv.redoStack, e)
+	mv.applyEdit("Undo", e, e.new, e.old)
+}
+
+func (mv *MapViewer) Redo() {
+	if !mv.cfg.Editable || len(mv.redoStack) == 0 {
+		return
+	}
+	e := mv.redoStack[len(mv.redoStack)-1]
+	mv.redoStack = mv.redoStack[:len(mv.redoStack)-1]
+	mv.undoStack = append(mv.undoStack, e)
+	mv.applyEdit("Redo", e, e.old, e.new)
+}
+
+// applyEdit sets the cells of e to values and writes them if e was written when made,
+// live edits in ECU RAM are reverted the same way
+func (mv *MapViewer) applyEdit(action string, e *edit, from, to []float64) {
+	mv.restoreValues = false
+	mv.inputBuffer.Reset()
+	for i, idx := range e.cells {
+		mv.cfg.ZData[idx] = to[i]
+	}
+	if mv.cfg.OnEdit != nil {
+		mv.cfg.OnEdit(action, e.cells, from, to)
+	}
+	if e.synced {
+		mv.syncCells(slices.Clone(e.cells))
+	}
+	mv.Refresh()
+}
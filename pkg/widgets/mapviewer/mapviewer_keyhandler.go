@@ -26,6 +26,10 @@ func (mv *MapViewer) TypedShortcut(shortcut fyne.Shortcut) {
 		mv.copy()
 	case "Paste":
 		mv.paste()
+	case "Undo":
+		mv.Undo()
+	case "Redo":
+		mv.Redo()
 	}
 }
 
@@ -58,6 +62,8 @@ func (mv *MapViewer) paste() {
 	}
 	cb := fyne.CurrentApp().Clipboard().Content()
 	split := strings.Split(cb, copyPasteSeparator)
+	var cells []int
+	var old []float64
 	for i, part := range split {
 		if len(part) < 3 {
 			continue
@@ -97,6 +103,8 @@ func (mv *MapViewer) paste() {
 			log.Printf("Index out of range: %d", index)
 			continue
 		}
+		cells = append(cells, index)
+		old = append(old, mv.cfg.ZData[index])
 		mv.cfg.ZData[index] = float64(value)
 		//if len(split) < 30 {
 		//	mv.cfg.UpdateECUFunc(index, []float64{mv.cfg.ZData[index]})
@@ -105,6 +113,7 @@ func (mv *MapViewer) paste() {
 	//if len(split) >= 30 {
 	//	mv.cfg.SaveECUFunc(mv.cfg.ZData)
 	//}
+	mv.recordEdit(cells, old, false)
 	mv.Refresh()
 }
 
@@ -113,11 +122,8 @@ func (mv *MapViewer) smooth() {
 		return
 	}
 
-	values := make([]float64, len(mv.selectedCells))
-
-	for i, idx := range mv.selectedCells {
-		values[i] = mv.cfg.ZData[idx]
-	}
+	old := mv.snapshot(mv.selectedCells)
+	values := slices.Clone(old)
 
 	start := values[0]
 	end := values[len(values)-1]
@@ -132,7 +138,7 @@ func (mv *MapViewer) smooth() {
 	for i, idx := range mv.selectedCells {
 		mv.cfg.ZData[idx] = values[i]
 	}
-	mv.updateCells()
+	mv.commitEdit(mv.selectedCells, old)
 	mv.Refresh()
 }
 
@@ -159,16 +165,17 @@ type updateBlock struct {
 	data []float64
 }
 
-func (mv *MapViewer) updateCells() {
-	if len(mv.selectedCells) == 0 {
+// syncCells writes the given cells using as few updates as possible
+func (mv *MapViewer) syncCells(cells []int) {
+	if len(cells) == 0 {
 		return
 	}
 
-	slices.Sort(mv.selectedCells)
+	slices.Sort(cells)
 	updates := []*updateBlock{
-		{mv.selectedCells[0], mv.selectedCells[0], []float64{mv.cfg.ZData[mv.selectedCells[0]]}},
+		{cells[0], cells[0], []float64{mv.cfg.ZData[cells[0]]}},
 	}
-	for _, cell := range mv.selectedCells[1:] {
+	for _, cell := range cells[1:] {
 		data := mv.cfg.ZData[cell]
 		last := updates[len(updates)-1]
 		if cell-1 == last.end {
@@ -179,7 +186,7 @@ func (mv *MapViewer) updateCells() {
 		}
 	}
 
-	if mv.shouldFullSync(updates) {
+	if mv.shouldFullSync(len(cells), updates) {
 		mv.fullSync()
 		return
 	}
@@ -187,12 +194,12 @@ func (mv *MapViewer) updateCells() {
 	mv.partialSync(updates)
 }
 
-func (mv *MapViewer) shouldFullSync(updates []*updateBlock) bool {
+func (mv *MapViewer) shouldFullSync(cells int, updates []*updateBlock) bool {
 	var lenUpdates int
 	for _, update := range updates {
 		lenUpdates += len(update.data)
 	}
-	return float32(len(mv.selectedCells)) > float32(mv.numColumns*mv.numRows)*0.60 || lenUpdates > 127
+	return float32(cells) > float32(mv.numColumns*mv.numRows)*0.60 || lenUpdates > 127
 }
 
 func (mv *MapViewer) fullSync() {
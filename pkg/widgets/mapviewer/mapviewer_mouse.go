@@ -252,6 +252,49 @@ func (mv *MapViewer) showPopupMenu(pos fyne.Position) {
 				fyne.NewMenuItem("Smooth", func() {
 					mv.smooth()
 				}),
+				fyne.NewMenuItem("Undo", func() {
+					mv.Undo()
+				}),
+				fyne.NewMenuItem("Redo", func() {
+					mv.Redo()
+				}),
+			)
+		}
+		menu.Items = append(menu.Items,
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Export CSV", func() {
+				mv.exportMap("csv")
+			}),
+			fyne.NewMenuItem("Export XDF", func() {
+				mv.exportMap("xdf")
+			}),
+		)
+		if mv.cfg.Editable {
+			menu.Items = append(menu.Items,
+				fyne.NewMenuItem("Import CSV / XDF", func() {
+					mv.importMap()
+				}),
+			)
+		}
+		if mv.cfg.Editable && mv.hasProposal() {
+			name := mv.cfg.ProposalName
+			if name == "" {
+				name = "proposal"
+			}
+			menu.Items = append(menu.Items,
+				fyne.NewMenuItemSeparator(),
+				fyne.NewMenuItem("Accept "+name, func() {
+					mv.acceptProposal(slices.Clone(mv.selectedCells))
+				}),
+				fyne.NewMenuItem("Reject "+name, func() {
+					mv.rejectProposal(mv.selectedCells)
+				}),
+				fyne.NewMenuItem("Accept all", func() {
+					mv.acceptProposal(mv.allCells())
+				}),
+				fyne.NewMenuItem("Reject all", func() {
+					mv.rejectProposal(mv.allCells())
+				}),
 			)
 		}
 		popupMenu := widget.NewPopUpMenu(menu,
@@ -26,10 +26,18 @@ type Config struct {
 	SaveECUFunc  func([]float64)
 	OnUpdateCell func(idx int, value []float64)
 	OnMouseDown  func()
+	OnError      func(error)
+	// Called with the old and new values of changed cells before they are written,
+	// action is "Undo", "Redo" or empty for a normal edit
+	OnEdit func(action string, cells []int, old, new []float64)
 
 	MeshView              bool
 	Editable              bool
 	CursorFollowCrosshair bool
+	// Show the absolute difference next to the percentage in the proposal overlay
+	ShowAbsoluteDelta bool
+	// What the proposal is called in the popup menu, defaults to "proposal"
+	ProposalName string
 
 	ColorblindMode colors.ColorBlindMode
 
@@ -0,0 +1,147 @@
+package mapviewer
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"github.com/roffe/txlogger/pkg/layout"
+)
+
+var (
+	proposalIncreaseColor = color.RGBA{0x00, 0xC8, 0x3C, 0xFF}
+	proposalDecreaseColor = color.RGBA{0xE0, 0x20, 0x20, 0xFF}
+)
+
+// SetProposal shows proposed values on top of the map, NaN marks cells without
+// a proposal. The proposed values are not applied until accepted from the popup menu.
+// Passing nil removes the overlay
+func (mv *MapViewer) SetProposal(values []float64) error {
+	if values != nil && len(values) != mv.numData {
+		return fmt.Errorf("MapViewer SetProposal len mismatch %d != %d", len(values), mv.numData)
+	}
+	mv.proposal = slices.Clone(values)
+	// the popup menu items depends on if there is a proposal
+	mv.popup = nil
+	mv.refreshProposal()
+	return nil
+}
+
+// Proposal returns the remaining proposed values
+func (mv *MapViewer) Proposal() []float64 {
+	return slices.Clone(mv.proposal)
+}
+
+func (mv *MapViewer) hasProposal() bool {
+	return slices.ContainsFunc(mv.proposal, func(v float64) bool {
+		return !math.IsNaN(v)
+	})
+}
+
+func (mv *MapViewer) createProposal() {
+	mv.proposalView = container.New(layout.NewGrid(mv.numColumns, mv.numRows, 1.32))
+	for range mv.cfg.ZData {
+		rect := &canvas.Rectangle{FillColor: color.Transparent, StrokeWidth: 2, CornerRadius: 2}
+		text := &canvas.Text{TextSize: minTextSize - 2, Alignment: fyne.TextAlignTrailing}
+		rect.Hide()
+		text.Hide()
+		mv.proposalRects = append(mv.proposalRects, rect)
+		mv.proposalTexts = append(mv.proposalTexts, text)
+		mv.proposalView.Add(container.NewStack(rect, container.NewBorder(nil, text, nil, nil)))
+	}
+	mv.refreshProposal()
+}
+
+func (mv *MapViewer) refreshProposal() {
+	if mv.proposalView == nil {
+		return
+	}
+	for idx := range mv.proposalRects {
+		rect, text := mv.proposalRects[idx], mv.proposalTexts[idx]
+		if mv.proposal == nil || math.IsNaN(mv.proposal[idx]) {
+			rect.Hide()
+			text.Hide()
+			continue
+		}
+		old, proposed := mv.cfg.ZData[idx], mv.proposal[idx]
+		col := proposalIncreaseColor
+		if proposed < old {
+			col = proposalDecreaseColor
+		}
+		delta := mv.formatDelta(old, proposed)
+		rect.StrokeColor = col
+		text.Color = col
+		text.Text = delta
+		rect.Show()
+		text.Show()
+		rect.Refresh()
+		text.Refresh()
+	}
+}
+
+func (mv *MapViewer) formatDelta(old, proposed float64) string {
+	var sign string
+	if proposed > old {
+		sign = "+"
+	}
+	abs := sign + strconv.FormatFloat(proposed-old, 'f', mv.cfg.ZPrecision, 64)
+	if old == 0 {
+		return abs
+	}
+	pct := sign + strconv.FormatFloat((proposed-old)/math.Abs(old)*100, 'f', 1, 64) + "%"
+	if mv.cfg.ShowAbsoluteDelta {
+		return abs + " " + pct
+	}
+	return pct
+}
+
+// acceptProposal writes the proposed value into the given cells and syncs them like any other edit
+func (mv *MapViewer) acceptProposal(cells []int) {
+	var accepted []int
+	var old []float64
+	for _, idx := range cells {
+		if mv.proposal == nil || math.IsNaN(mv.proposal[idx]) {
+			continue
+		}
+		old = append(old, mv.cfg.ZData[idx])
+		mv.cfg.ZData[idx] = mv.proposal[idx]
+		mv.proposal[idx] = math.NaN()
+		accepted = append(accepted, idx)
+	}
+	if len(accepted) == 0 {
+		return
+	}
+	mv.commitEdit(accepted, old)
+	mv.Refresh()
+	mv.proposalChanged()
+}
+
+func (mv *MapViewer) rejectProposal(cells []int) {
+	for _, idx := range cells {
+		if mv.proposal != nil {
+			mv.proposal[idx] = math.NaN()
+		}
+	}
+	mv.proposalChanged()
+}
+
+func (mv *MapViewer) proposalChanged() {
+	if !mv.hasProposal() {
+		mv.proposal = nil
+		mv.popup = nil
+	}
+	mv.refreshProposal()
+}
+
+func (mv *MapViewer) allCells() []int {
+	cells := make([]int, mv.numData)
+	for i := range cells {
+		cells[i] = i
+	}
+	return cells
+}
@@ -55,6 +55,9 @@ type Plotter struct {
 
 	hilightLine int
 
+	// record positions drawn as markers on the timeline
+	markers []int
+
 	OnDragged func(event *fyne.DragEvent)
 	OnTapped  func(event *fyne.PointEvent)
 }
@@ -257,8 +260,15 @@ func (p *Plotter) Seek(pos int) {
 	p.refreshImage(true)
 }
 
+// SetMarkers sets the record positions marked on the timeline
+func (p *Plotter) SetMarkers(positions []int) {
+	p.markers = positions
+	p.refreshImage(true)
+}
+
 func (p *Plotter) refreshImage(goroutine bool) {
 	img := image.NewRGBA(image.Rect(0, 0, int(p.plotResolution.Width), int(p.plotResolution.Height)))
+	p.plotMarkers(img)
 	for n := range len(p.ts) {
 		if !p.ts[n].Enabled {
 			continue
@@ -282,6 +292,29 @@ func (p *Plotter) refreshImage(goroutine bool) {
 
 }
 
+var markerColor = color.RGBA{255, 200, 0, 255}
+
+// plotMarkers draws a dashed line for every marker in view
+func (p *Plotter) plotMarkers(img *image.RGBA) {
+	startN, endN := min(max(p.plotStartPos, 0), p.dataLength), min(p.plotStartPos+p.dataPointsToShow, p.dataLength)
+	if endN <= startN {
+		return
+	}
+	s := img.Bounds().Size()
+	widthFactor := float64(s.X) / float64(endN-startN)
+	for _, pos := range p.markers {
+		if pos < startN || pos > endN {
+			continue
+		}
+		x := int(float64(pos-startN) * widthFactor)
+		for y := 0; y < s.Y; y++ {
+			if y%8 < 5 {
+				img.SetRGBA(x, y, markerColor)
+			}
+		}
+	}
+}
+
 type TimeSeries struct {
 	Name       string
 	Min        float64
@@ -0,0 +1,179 @@
+// Package scatterplot draws one log channel against another with the points
+// coloured by a third channel, such as lambda vs airmass coloured by rpm
+package scatterplot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/colors"
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+const noColor = "None"
+
+var (
+	_ fyne.Widget = (*Scatterplot)(nil)
+
+	backgroundColor = color.RGBA{0x18, 0x18, 0x18, 0xFF}
+	gridColor       = color.RGBA{0x40, 0x40, 0x40, 0xFF}
+	pointColor      = color.RGBA{0x40, 0xA0, 0xFF, 0xFF}
+)
+
+type Config struct {
+	Logfile logfile.Logfile
+	X       string
+	Y       string
+	// Color is the channel the points are coloured by, empty draws every point the same
+	Color          string
+	ColorblindMode colors.ColorBlindMode
+}
+
+type Scatterplot struct {
+	widget.BaseWidget
+
+	cfg    *Config
+	values map[string][]float64
+
+	xSelect, ySelect, colorSelect *widget.Select
+	raster                        *canvas.Raster
+	info                          *widget.Label
+
+	container *fyne.Container
+}
+
+func New(cfg *Config) *Scatterplot {
+	s := &Scatterplot{
+		cfg:    cfg,
+		values: logfile.Values(cfg.Logfile),
+		info:   widget.NewLabel(""),
+	}
+	s.ExtendBaseWidget(s)
+
+	names := make([]string, 0, len(s.values))
+	for k := range s.values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	s.xSelect = widget.NewSelect(names, func(v string) {
+		s.cfg.X = v
+		s.refresh()
+	})
+	s.xSelect.Selected = cfg.X
+	s.ySelect = widget.NewSelect(names, func(v string) {
+		s.cfg.Y = v
+		s.refresh()
+	})
+	s.ySelect.Selected = cfg.Y
+	s.colorSelect = widget.NewSelect(append([]string{noColor}, names...), func(v string) {
+		if v == noColor {
+			v = ""
+		}
+		s.cfg.Color = v
+		s.refresh()
+	})
+	s.colorSelect.Selected = noColor
+	if cfg.Color != "" {
+		s.colorSelect.Selected = cfg.Color
+	}
+
+	s.raster = canvas.NewRaster(s.draw)
+	s.raster.SetMinSize(fyne.NewSize(300, 200))
+
+	s.container = container.NewBorder(
+		container.NewGridWithColumns(3,
+			container.NewBorder(nil, nil, widget.NewLabel("X"), nil, s.xSelect),
+			container.NewBorder(nil, nil, widget.NewLabel("Y"), nil, s.ySelect),
+			container.NewBorder(nil, nil, widget.NewLabel("Color"), nil, s.colorSelect),
+		),
+		s.info,
+		nil,
+		nil,
+		s.raster,
+	)
+	s.refresh()
+	return s
+}
+
+func (s *Scatterplot) refresh() {
+	xMin, xMax := valueRange(s.values[s.cfg.X])
+	yMin, yMax := valueRange(s.values[s.cfg.Y])
+	text := fmt.Sprintf("X %s: %.4g - %.4g   Y %s: %.4g - %.4g", s.cfg.X, xMin, xMax, s.cfg.Y, yMin, yMax)
+	if s.cfg.Color != "" {
+		cMin, cMax := valueRange(s.values[s.cfg.Color])
+		text += fmt.Sprintf("   Color %s: %.4g - %.4g", s.cfg.Color, cMin, cMax)
+	}
+	s.info.SetText(text)
+	s.raster.Refresh()
+}
+
+func (s *Scatterplot) draw(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+	// grid lines at every tenth of the range
+	for i := 1; i < 10; i++ {
+		gx, gy := w*i/10, h*i/10
+		for y := range h {
+			img.SetRGBA(gx, y, gridColor)
+		}
+		for x := range w {
+			img.SetRGBA(x, gy, gridColor)
+		}
+	}
+
+	xs, ys, cs := s.values[s.cfg.X], s.values[s.cfg.Y], s.values[s.cfg.Color]
+	if xs == nil || ys == nil || w < 2 || h < 2 {
+		return img
+	}
+	xMin, xMax := valueRange(xs)
+	yMin, yMax := valueRange(ys)
+	cMin, cMax := valueRange(cs)
+	xSpan, ySpan := math.Max(xMax-xMin, 1e-9), math.Max(yMax-yMin, 1e-9)
+
+	for i := range min(len(xs), len(ys)) {
+		x, y := xs[i], ys[i]
+		if math.IsNaN(x) || math.IsNaN(y) {
+			continue
+		}
+		col := pointColor
+		if cs != nil && !math.IsNaN(cs[i]) {
+			col = colors.GetColorInterpolation(cMin, cMax, cs[i], s.cfg.ColorblindMode)
+		}
+		px := int((x - xMin) / xSpan * float64(w-2))
+		py := h - 2 - int((y-yMin)/ySpan*float64(h-2))
+		img.SetRGBA(px, py, col)
+		img.SetRGBA(px+1, py, col)
+		img.SetRGBA(px, py+1, col)
+		img.SetRGBA(px+1, py+1, col)
+	}
+	return img
+}
+
+// valueRange returns the min and max value ignoring NaN
+func valueRange(values []float64) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	if lo > hi {
+		return 0, 0
+	}
+	return lo, hi
+}
+
+func (s *Scatterplot) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(s.container)
+}
@@ -18,9 +18,11 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/roffe/gocan"
 	"github.com/roffe/gocan/proto"
+	"github.com/roffe/txlogger/pkg/alarm"
 	"github.com/roffe/txlogger/pkg/colors"
 	"github.com/roffe/txlogger/pkg/common"
 	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/ebus"
 	"github.com/roffe/txlogger/pkg/mdns"
 	"github.com/roffe/txlogger/pkg/ota"
 	"github.com/roffe/txlogger/pkg/wbl/aem"
@@ -30,6 +32,7 @@ import (
 	"github.com/roffe/txlogger/pkg/wbl/stag"
 	"github.com/roffe/txlogger/pkg/wbl/zeitronix"
 	"github.com/roffe/txlogger/pkg/widgets/txconfigurator"
+	"github.com/roffe/txlogger/relayserver"
 	"go.bug.st/serial/enumerator"
 )
 
@@ -54,6 +57,20 @@ const (
 	prefshighValue              = "highValue"
 	prefsUseADScanner           = "useADScanner"
 	prefsColorBlindMode         = "colorBlindMode"
+	prefsDerivedChannels        = "derivedChannels"
+	prefsRateClasses            = "rateClasses"
+	prefsAutoReconnect          = "autoReconnect"
+	prefsTriggerCondition       = "triggerCondition"
+	prefsTriggerPre             = "triggerPre"
+	prefsTriggerPost            = "triggerPost"
+	prefsTriggerSplit           = "triggerSplit"
+	prefsAlarms                 = "alarms"
+	prefsRelayHost              = "relayHost"
+	prefsRelaySession           = "relaySession"
+	prefsRelayToken             = "relayToken"
+	prefsRelayRole              = "relayRole"
+	prefsRelayTLS               = "relayTLS"
+	prefsRelayInsecure          = "relayInsecure"
 
 	// CAN
 	prefsAdapter = "adapter"
@@ -80,6 +97,8 @@ type SetText interface {
 type Config struct {
 	Logger          func(string)
 	SelectedEcuFunc func() string
+	// OnAlarmsChanged is called when new alarm rules are applied
+	OnAlarmsChanged func([]alarm.Rule)
 }
 
 type Widget struct {
@@ -98,6 +117,7 @@ type Widget struct {
 	realtimeBars          *widget.Check
 	logFormat             *widget.Select
 	logPath               *widget.Label
+	autoReconnect         *widget.Check
 	useMPH                *widget.Check
 	swapRPMandSpeed       *widget.Check
 	colorBlindMode        *widget.Select
@@ -127,6 +147,30 @@ type Widget struct {
 	highLabel                   *widget.Label
 	highEntry                   *widget.Entry
 
+	// Derived channels
+	derivedChannels      *widget.Entry
+	derivedChannelsError *widget.Label
+
+	rateClasses *widget.Entry
+
+	// Alarms
+	alarms      *widget.Entry
+	alarmsError *widget.Label
+
+	// Trigger logging
+	triggerCondition *widget.Entry
+	triggerPre       *widget.Entry
+	triggerPost      *widget.Entry
+	triggerSplit     *widget.Check
+
+	// Relay server
+	relayHost     *widget.Entry
+	relaySession  *widget.Entry
+	relayToken    *widget.Entry
+	relayRole     *widget.Select
+	relayTLS      *widget.Check
+	relayInsecure *widget.Check
+
 	images struct {
 		mtxl        *canvas.Image
 		lc2         *canvas.Image
@@ -168,6 +212,7 @@ func (sw *Widget) CreateRenderer() fyne.WidgetRenderer {
 	sw.logFormat = sw.newLogFormat()
 	sw.logPath = widget.NewLabel("")
 	sw.logPath.Truncation = fyne.TextTruncateEllipsis
+	sw.autoReconnect = sw.newAutoReconnect()
 	sw.useMPH = sw.newUserMPH()
 	sw.swapRPMandSpeed = sw.newSwapRPMandSpeed()
 	sw.colorBlindMode = sw.newColorBlindMode()
@@ -200,6 +245,9 @@ func (sw *Widget) CreateRenderer() fyne.WidgetRenderer {
 	tabs.Append(sw.loggingTab())
 	tabs.Append(sw.wblTab())
 	tabs.Append(sw.dashboardTab())
+	tabs.Append(sw.channelsTab())
+	tabs.Append(sw.alarmsTab())
+	tabs.Append(sw.relayTab())
 	tabs.Append(container.NewTabItem("txbridge", txconfigurator.NewConfigurator()))
 	//sw.container = tabs
 
@@ -329,6 +377,11 @@ func (cs *Widget) GetAdapter(ecuType string) (gocan.Adapter, error) {
 		}
 	}
 
+	return NewAdapter(ecuType, adapterName, port, baudrate, debug, cs.cfg.Logger)
+}
+
+// NewAdapter creates a CAN adapter with the filters and CAN rate used when logging ecuType
+func NewAdapter(ecuType, adapterName, port string, baudrate int, debug bool, logger func(string)) (gocan.Adapter, error) {
 	var canFilter []uint32
 	var canRate float64
 
@@ -370,7 +423,7 @@ func (cs *Widget) GetAdapter(ecuType string) (gocan.Adapter, error) {
 		defer cancel()
 		addr, err := mdns.Query(ctx, "txbridge.local")
 		if err != nil {
-			cs.cfg.Logger(fmt.Sprintf("Failed to resolve txbridge address via mDNS: %v", err))
+			logger(fmt.Sprintf("Failed to resolve txbridge address via mDNS: %v", err))
 		} else {
 			cfg.AdditionalConfig = map[string]string{
 				"address":    fmt.Sprintf("%s:%d", addr.String(), 1337),
@@ -381,6 +434,45 @@ func (cs *Widget) GetAdapter(ecuType string) (gocan.Adapter, error) {
 	return gocan.NewAdapter(adapterName, cfg)
 }
 
+// GetDerivedChannels returns the derived channel definitions, one "name = expression" per entry
+func (sw *Widget) GetDerivedChannels() []string {
+	return splitDerivedChannels(fyne.CurrentApp().Preferences().String(prefsDerivedChannels))
+}
+
+// SetDerivedChannels validates and applies the derived channel definitions to the event bus
+func (sw *Widget) SetDerivedChannels(defs []string) error {
+	if err := ebus.SetDerivedChannels(defs); err != nil {
+		return err
+	}
+	text := strings.Join(defs, "\n")
+	fyne.CurrentApp().Preferences().SetString(prefsDerivedChannels, text)
+	if sw.derivedChannels != nil {
+		sw.derivedChannels.SetText(text)
+		sw.derivedChannelsError.SetText("")
+	}
+	return nil
+}
+
+func splitDerivedChannels(text string) []string {
+	var defs []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			defs = append(defs, line)
+		}
+	}
+	return defs
+}
+
+// GetRateClasses returns the rate class overrides, invalid settings are ignored
+func (sw *Widget) GetRateClasses() map[string]datalogger.RateClass {
+	classes, err := datalogger.ParseRateClasses(strings.Split(fyne.CurrentApp().Preferences().String(prefsRateClasses), "\n"))
+	if err != nil {
+		sw.cfg.Logger("Invalid rate classes: " + err.Error())
+		return nil
+	}
+	return classes
+}
+
 func (sw *Widget) GetWidebandType() string {
 	return fyne.CurrentApp().Preferences().StringWithFallback(prefsWblSource, "None")
 
@@ -480,6 +572,71 @@ func (sw *Widget) GetLogPath() string {
 	return p
 }
 
+// GetAlarms returns the alarm rules, invalid settings are ignored
+func (sw *Widget) GetAlarms() []alarm.Rule {
+	rules, err := alarm.ParseRules(strings.Split(fyne.CurrentApp().Preferences().String(prefsAlarms), "\n"))
+	if err != nil {
+		sw.cfg.Logger("Invalid alarms: " + err.Error())
+		return nil
+	}
+	return rules
+}
+
+// SetAlarms validates and applies the alarm rules, one rule per line
+func (sw *Widget) SetAlarms(text string) error {
+	rules, err := alarm.ParseRules(strings.Split(text, "\n"))
+	if err != nil {
+		return err
+	}
+	fyne.CurrentApp().Preferences().SetString(prefsAlarms, text)
+	if sw.alarmsError != nil {
+		sw.alarmsError.SetText("")
+	}
+	if sw.cfg.OnAlarmsChanged != nil {
+		sw.cfg.OnAlarmsChanged(rules)
+	}
+	return nil
+}
+
+// GetTrigger returns the trigger logging settings, an empty condition logs everything
+func (sw *Widget) GetTrigger() datalogger.TriggerConfig {
+	prefs := fyne.CurrentApp().Preferences()
+	seconds := func(key, fallback string) time.Duration {
+		v, err := positiveFloatValidator(prefs.StringWithFallback(key, fallback))
+		if err != nil {
+			v, _ = strconv.ParseFloat(fallback, 64)
+		}
+		return time.Duration(v * float64(time.Second))
+	}
+	return datalogger.TriggerConfig{
+		Condition:   strings.TrimSpace(prefs.String(prefsTriggerCondition)),
+		PreTrigger:  seconds(prefsTriggerPre, "2"),
+		PostTrigger: seconds(prefsTriggerPost, "1"),
+		SplitFiles:  prefs.Bool(prefsTriggerSplit),
+	}
+}
+
+// GetRelay returns the relay server and session used by the Local+Relay and Remote modes
+func (sw *Widget) GetRelay() datalogger.RelayConfig {
+	prefs := fyne.CurrentApp().Preferences()
+	role := relayserver.RoleViewer
+	if prefs.StringWithFallback(prefsRelayRole, relayserver.RoleViewer.String()) == relayserver.RoleTuner.String() {
+		role = relayserver.RoleTuner
+	}
+	return datalogger.RelayConfig{
+		Host:               strings.TrimSpace(prefs.String(prefsRelayHost)),
+		Session:            strings.TrimSpace(prefs.String(prefsRelaySession)),
+		Token:              prefs.String(prefsRelayToken),
+		Role:               role,
+		TLS:                prefs.Bool(prefsRelayTLS),
+		InsecureSkipVerify: prefs.Bool(prefsRelayInsecure),
+	}
+}
+
+func (sw *Widget) GetAutoReconnect() bool {
+	return fyne.CurrentApp().Preferences().Bool(prefsAutoReconnect)
+}
+
 func (sw *Widget) GetUseMPH() bool {
 	return fyne.CurrentApp().Preferences().Bool(prefsUseMPH)
 }
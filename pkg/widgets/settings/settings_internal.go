@@ -21,6 +21,7 @@ import (
 	"github.com/roffe/txlogger/pkg/wbl/plx"
 	"github.com/roffe/txlogger/pkg/wbl/stag"
 	"github.com/roffe/txlogger/pkg/wbl/zeitronix"
+	"github.com/roffe/txlogger/relayserver"
 )
 
 func newImageFromResource(name string) *canvas.Image {
@@ -61,7 +62,7 @@ func newImageFromResource(name string) *canvas.Image {
 }
 
 func (sw *Widget) newLogFormat() *widget.Select {
-	return widget.NewSelect([]string{"CSV", "TXL"}, func(s string) {
+	return widget.NewSelect([]string{"CSV", "TXL", "TXB"}, func(s string) {
 		fyne.CurrentApp().Preferences().SetString(prefsLogFormat, s)
 	})
 }
@@ -303,6 +304,12 @@ func (sw *Widget) newRealtimeBars() *widget.Check {
 	})
 }
 
+func (sw *Widget) newAutoReconnect() *widget.Check {
+	return widget.NewCheck("Reconnect and keep logging to the same file when the connection is lost", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsAutoReconnect, b)
+	})
+}
+
 func (sw *Widget) newUserMPH() *widget.Check {
 	return widget.NewCheck("Use mph instead of km/h", func(b bool) {
 		fyne.CurrentApp().Preferences().SetBool(prefsUseMPH, b)
@@ -399,6 +406,11 @@ func (sw *Widget) loadPreferences() {
 	loadPrefsCheck(sw.meshView, prefsMeshView, true)
 	loadPrefsCheck(sw.realtimeBars, prefsRealtimeBars, true)
 	loadPrefsSelect(sw.logFormat, prefsLogFormat, "TXL")
+	loadPrefsCheck(sw.autoReconnect, prefsAutoReconnect, false)
+	loadPrefsText(sw.triggerCondition, prefsTriggerCondition, "")
+	loadPrefsText(sw.triggerPre, prefsTriggerPre, "2")
+	loadPrefsText(sw.triggerPost, prefsTriggerPost, "1")
+	loadPrefsCheck(sw.triggerSplit, prefsTriggerSplit, false)
 	logPath, err := common.GetLogPath()
 	if err != nil {
 		fyne.LogError("Could not get log path", err)
@@ -415,6 +427,15 @@ func (sw *Widget) loadPreferences() {
 	loadPrefsText(sw.lowEntry, prefslowValue, "0.5")
 	loadPrefsText(sw.highEntry, prefshighValue, "1.5")
 	loadPrefsSelect(sw.colorBlindMode, prefsColorBlindMode, "Normal")
+	loadPrefsText(sw.derivedChannels, prefsDerivedChannels, "")
+	loadPrefsText(sw.rateClasses, prefsRateClasses, "")
+	loadPrefsText(sw.alarms, prefsAlarms, "")
+	loadPrefsText(sw.relayHost, prefsRelayHost, "")
+	loadPrefsText(sw.relaySession, prefsRelaySession, "")
+	loadPrefsText(sw.relayToken, prefsRelayToken, "")
+	loadPrefsSelect(sw.relayRole, prefsRelayRole, relayserver.RoleViewer.String())
+	loadPrefsCheck(sw.relayTLS, prefsRelayTLS, false)
+	loadPrefsCheck(sw.relayInsecure, prefsRelayInsecure, false)
 
 	if sw.wblADscanner.Checked {
 		sw.minimumVoltageWidebandLabel.Show()
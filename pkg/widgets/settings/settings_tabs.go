@@ -1,14 +1,19 @@
 package settings
 
 import (
+	"strings"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/roffe/txlogger/pkg/common"
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/eventbus"
 	xlayout "github.com/roffe/txlogger/pkg/layout"
 	"github.com/roffe/txlogger/pkg/widgets"
+	"github.com/roffe/txlogger/relayserver"
 )
 
 func (sw *Widget) generalTab() *container.TabItem {
@@ -62,6 +67,51 @@ func (sw *Widget) generalTab() *container.TabItem {
 }
 
 func (sw *Widget) loggingTab() *container.TabItem {
+	sw.rateClasses = widget.NewMultiLineEntry()
+	sw.rateClasses.SetPlaceHolder("ActualIn.T_Engine = slow\nKnkDet.KnockCyl = fast")
+	sw.rateClasses.Wrapping = fyne.TextWrapOff
+	sw.rateClasses.SetMinRowsVisible(4)
+	sw.rateClasses.Validator = func(s string) error {
+		if _, err := datalogger.ParseRateClasses(strings.Split(s, "\n")); err != nil {
+			return err
+		}
+		fyne.CurrentApp().Preferences().SetString(prefsRateClasses, s)
+		return nil
+	}
+
+	rateHelp := widget.NewLabel("T8 symbol rate classes, one per line as name = fast, medium or slow. " +
+		"All symbols are read every tick, slower classes are written to the log less often. Symbols not listed are logged every tick")
+	rateHelp.Importance = widget.LowImportance
+	rateHelp.Wrapping = fyne.TextWrapWord
+
+	sw.triggerCondition = widget.NewEntry()
+	sw.triggerCondition.SetPlaceHolder("ActualIn.n_Engine > 3000 && Out.X_AccPedal > 80")
+	sw.triggerCondition.Validator = func(s string) error {
+		if s = strings.TrimSpace(s); s != "" {
+			if _, err := eventbus.ParseExpression(s); err != nil {
+				return err
+			}
+		}
+		fyne.CurrentApp().Preferences().SetString(prefsTriggerCondition, s)
+		return nil
+	}
+	secondsEntry := func(key string) *widget.Entry {
+		e := widget.NewEntry()
+		e.Validator = func(s string) error {
+			if _, err := positiveFloatValidator(s); err != nil {
+				return err
+			}
+			fyne.CurrentApp().Preferences().SetString(key, s)
+			return nil
+		}
+		return e
+	}
+	sw.triggerPre = secondsEntry(prefsTriggerPre)
+	sw.triggerPost = secondsEntry(prefsTriggerPost)
+	sw.triggerSplit = widget.NewCheck("New file per event", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsTriggerSplit, b)
+	})
+
 	return container.NewTabItem("Logging", container.NewVBox(
 		container.NewBorder(
 			nil,
@@ -101,6 +151,25 @@ func (sw *Widget) loggingTab() *container.TabItem {
 			nil,
 			sw.logPath,
 		),
+		sw.autoReconnect,
+		widget.NewSeparator(),
+		container.NewBorder(
+			nil,
+			nil,
+			widget.NewLabel("Trigger"),
+			nil,
+			sw.triggerCondition,
+		),
+		container.NewHBox(
+			widget.NewLabel("Pre-trigger (s)"),
+			xlayout.NewFixedWidth(70, sw.triggerPre),
+			widget.NewLabel("Stop after (s)"),
+			xlayout.NewFixedWidth(70, sw.triggerPost),
+			sw.triggerSplit,
+		),
+		widget.NewSeparator(),
+		rateHelp,
+		sw.rateClasses,
 	))
 }
 
@@ -250,6 +319,103 @@ func (sw *Widget) dashboardTab() *container.TabItem {
 	))
 }
 
+func (sw *Widget) channelsTab() *container.TabItem {
+	sw.derivedChannels = widget.NewMultiLineEntry()
+	sw.derivedChannels.SetPlaceHolder("AFR = Lambda.External * 14.7\nBoost = In.p_AirInlet - 1")
+	sw.derivedChannels.Wrapping = fyne.TextWrapOff
+	sw.derivedChannels.SetMinRowsVisible(10)
+	sw.derivedChannelsError = widget.NewLabel("")
+	sw.derivedChannelsError.Importance = widget.DangerImportance
+	sw.derivedChannelsError.Wrapping = fyne.TextWrapWord
+
+	apply := widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), func() {
+		if err := sw.SetDerivedChannels(splitDerivedChannels(sw.derivedChannels.Text)); err != nil {
+			sw.derivedChannelsError.SetText(err.Error())
+		}
+	})
+
+	help := widget.NewLabel("One channel per line as name = expression. Operators: + - * / ^ < > <= >= == !=\n" +
+		"Functions: abs(x) sqrt(x) min(a, b, ..) max(a, b, ..) if(cond, a, b) avg(x, n) minhold(x) maxhold(x) rate(x)\n" +
+		"Quote names containing spaces or operators, e.g. \"Wideband 2\" * 14.7")
+	help.Importance = widget.LowImportance
+	help.Wrapping = fyne.TextWrapWord
+
+	return container.NewTabItem("Channels", container.NewBorder(
+		help,
+		container.NewBorder(nil, nil, nil, apply, sw.derivedChannelsError),
+		nil,
+		nil,
+		sw.derivedChannels,
+	))
+}
+
+func (sw *Widget) alarmsTab() *container.TabItem {
+	sw.alarms = widget.NewMultiLineEntry()
+	sw.alarms.SetPlaceHolder("Lean under boost: Lambda.External > 1.05 hysteresis 0.02 for 500ms when In.p_AirInlet > 0.2\n" +
+		"Coolant: ActualIn.T_Engine > 105 hysteresis 3 for 5s")
+	sw.alarms.Wrapping = fyne.TextWrapOff
+	sw.alarms.SetMinRowsVisible(10)
+	sw.alarmsError = widget.NewLabel("")
+	sw.alarmsError.Importance = widget.DangerImportance
+	sw.alarmsError.Wrapping = fyne.TextWrapWord
+
+	apply := widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), func() {
+		if err := sw.SetAlarms(sw.alarms.Text); err != nil {
+			sw.alarmsError.SetText(err.Error())
+		}
+	})
+
+	help := widget.NewLabel("One alarm per line as name: value > threshold, use < to alarm on low values. " +
+		"Optional, in this order: hysteresis h, for duration, when condition and silent.\n" +
+		"Value and condition are expressions like the derived channels")
+	help.Importance = widget.LowImportance
+	help.Wrapping = fyne.TextWrapWord
+
+	return container.NewTabItem("Alarms", container.NewBorder(
+		help,
+		container.NewBorder(nil, nil, nil, apply, sw.alarmsError),
+		nil,
+		nil,
+		sw.alarms,
+	))
+}
+
+func (sw *Widget) relayTab() *container.TabItem {
+	textEntry := func(e *widget.Entry, key, placeholder string) *widget.Entry {
+		e.SetPlaceHolder(placeholder)
+		e.OnChanged = func(s string) {
+			fyne.CurrentApp().Preferences().SetString(key, s)
+		}
+		return e
+	}
+	sw.relayHost = textEntry(widget.NewEntry(), prefsRelayHost, relayserver.SERVER_HOST)
+	sw.relaySession = textEntry(widget.NewEntry(), prefsRelaySession, "1337")
+	sw.relayToken = textEntry(widget.NewPasswordEntry(), prefsRelayToken, "Session token")
+	sw.relayRole = widget.NewSelect([]string{relayserver.RoleViewer.String(), relayserver.RoleTuner.String()}, func(s string) {
+		fyne.CurrentApp().Preferences().SetString(prefsRelayRole, s)
+	})
+	sw.relayTLS = widget.NewCheck("TLS", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsRelayTLS, b)
+	})
+	sw.relayInsecure = widget.NewCheck("Accept self signed certificates", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsRelayInsecure, b)
+	})
+
+	help := widget.NewLabel("Used by the Local+Relay and Remote modes. Local+Relay shares the ECU as the tuner owning the session, " +
+		"Remote joins with the selected role. The token is only needed on relay servers with session tokens")
+	help.Importance = widget.LowImportance
+	help.Wrapping = fyne.TextWrapWord
+
+	return container.NewTabItem("Relay", container.NewVBox(
+		container.NewBorder(nil, nil, xlayout.NewFixedWidth(70, widget.NewLabel("Server")), nil, sw.relayHost),
+		container.NewBorder(nil, nil, xlayout.NewFixedWidth(70, widget.NewLabel("Session")), nil, sw.relaySession),
+		container.NewBorder(nil, nil, xlayout.NewFixedWidth(70, widget.NewLabel("Token")), nil, sw.relayToken),
+		container.NewBorder(nil, nil, xlayout.NewFixedWidth(70, widget.NewLabel("Role")), nil, sw.relayRole),
+		container.NewHBox(sw.relayTLS, sw.relayInsecure),
+		help,
+	))
+}
+
 func (sw *Widget) canTab() *container.TabItem {
 	return container.NewTabItem("CAN", container.NewVBox(
 		container.NewBorder(
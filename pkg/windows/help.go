@@ -111,6 +111,9 @@ func Help() *container.AppTabs {
 				widget.NewLabel("Plus: Increase playback speed"),
 				widget.NewLabel("Minus: Decrease playback speed"),
 				widget.NewLabel("Num Enter Reset playback speed"),
+				widget.NewLabel("[ / ]: Previous/next marker"),
+				widget.NewLabel("M: Add or edit marker"),
+				widget.NewLabel("Ctrl-M: Add marker while logging"),
 			)),
 		),
 	)
@@ -24,7 +24,9 @@ import (
 	"github.com/roffe/txlogger/pkg/datalogger"
 	"github.com/roffe/txlogger/pkg/debug"
 	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/journal"
 	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/presets"
 	"github.com/roffe/txlogger/pkg/update"
 	"github.com/roffe/txlogger/pkg/widgets/combinedlogplayer"
 	"github.com/roffe/txlogger/pkg/widgets/dashboard"
@@ -69,6 +71,7 @@ type MainWindow struct {
 	counters        *mainWindowCounters
 	loggingRunning  bool
 	filename        string
+	logFilename     string
 	symbolList      *symbollist.Widget
 	fw              symbol.SymbolCollection
 	dlc             datalogger.IClient
@@ -79,6 +82,9 @@ type MainWindow struct {
 	wm              *multiwindow.MultipleWindows
 	content         *fyne.Container
 	startup         bool
+	journal         *journal.Journal
+	alarmBanner     *alarmBanner
+	alarmCancels    []func()
 
 	gocanGatewayLED *ledicon.Widget
 	canLED          *ledicon.Widget
@@ -111,6 +117,8 @@ type mainWindowCounters struct {
 	capturedCounterLabel *widget.Label
 	errorCounterLabel    *widget.Label
 	fpsCounterLabel      *widget.Label
+
+	captured, errors, fps int
 }
 
 func NewMainWindow(app fyne.App) *MainWindow {
@@ -130,6 +138,8 @@ func NewMainWindow(app fyne.App) *MainWindow {
 
 		gocanGatewayLED: ledicon.New("Gateway"),
 		canLED:          ledicon.New("CAN"),
+		journal:         journal.New(),
+		alarmBanner:     newAlarmBanner(),
 		statusText:      secrettext.New("Harder, Better, Faster, Stronger"),
 		previewFeatures: app.Preferences().BoolWithFallback("enable_preview_features", false),
 	}
@@ -155,8 +165,14 @@ func NewMainWindow(app fyne.App) *MainWindow {
 		SelectedEcuFunc: func() string {
 			return mw.selects.ecuSelect.Selected
 		},
+		OnAlarmsChanged: mw.setAlarms,
 	})
 
+	if err := ebus.SetDerivedChannels(mw.settings.GetDerivedChannels()); err != nil {
+		mw.Error(fmt.Errorf("failed to load derived channels: %w", err))
+	}
+	mw.setAlarms(mw.settings.GetAlarms())
+
 	mw.loadPrefs()
 
 	symbolListConfig.ColorBlindMode = mw.settings.GetColorBlindMode()
@@ -238,6 +254,7 @@ func (mw *MainWindow) setupShortcuts() {
 	ctrl2 := &desktop.CustomShortcut{KeyName: fyne.Key2, Modifier: fyne.KeyModifierControl}
 	ctrl3 := &desktop.CustomShortcut{KeyName: fyne.Key3, Modifier: fyne.KeyModifierControl}
 	ctrl4 := &desktop.CustomShortcut{KeyName: fyne.Key4, Modifier: fyne.KeyModifierControl}
+	ctrlM := &desktop.CustomShortcut{KeyName: fyne.KeyM, Modifier: fyne.KeyModifierControl}
 
 	mw.Window.Canvas().AddShortcut(ctrlEnter, func(shortcut fyne.Shortcut) {
 		mw.wm.Arrange(&multiwindow.GridArranger{})
@@ -264,6 +281,10 @@ func (mw *MainWindow) setupShortcuts() {
 	mw.Window.Canvas().AddShortcut(ctrl4, func(shortcut fyne.Shortcut) {
 		log.Println("Ctrl-4")
 	})
+
+	mw.Window.Canvas().AddShortcut(ctrlM, func(shortcut fyne.Shortcut) {
+		mw.addLogMarker()
+	})
 }
 
 func (mw *MainWindow) render() {
@@ -332,7 +353,7 @@ func (mw *MainWindow) render() {
 		mw.statusText,
 	)
 
-	mw.content = container.NewBorder(toolbar, footer, nil, nil, mw.wm)
+	mw.content = container.NewBorder(container.NewVBox(toolbar, mw.alarmBanner), footer, nil, nil, mw.wm)
 }
 
 func (mw *MainWindow) LoadLogfileCombined(filename string, reader io.ReadCloser, p fyne.Position, fromRoutine bool) {
@@ -384,8 +405,9 @@ func (mw *MainWindow) LoadLogfileCombined(filename string, reader io.ReadCloser,
 	}
 
 	cpCfg := &combinedlogplayer.CombinedLogplayerConfig{
-		Logfile: logz,
-		DBcfg:   dbcfg,
+		Logfile:          logz,
+		DBcfg:            dbcfg,
+		OnMarkersChanged: mw.saveMarkers(filename),
 	}
 
 	cp := combinedlogplayer.New(cpCfg)
@@ -439,26 +461,35 @@ func (mw *MainWindow) LoadLogfileCombined(filename string, reader io.ReadCloser,
 	mw.Log("loaded log file " + filename + " in combined logplayer")
 }
 
-func (mw *MainWindow) LoadLogfile(filename string, r io.Reader, pos fyne.Position) {
+// LoadLogfile opens the log in a logplayer window, if the log is already open the existing window is raised.
+// Returns the logplayer or nil if the log could not be opened
+func (mw *MainWindow) LoadLogfile(filename string, r io.Reader, pos fyne.Position) *logplayer.Logplayer {
 	// Just filename, used for Window title
 	fp := filepath.Base(filename)
 
 	if w := mw.wm.HasWindow(fp); w != nil {
 		mw.wm.Raise(w)
-		return
+		lp, _ := w.Content().(*logplayer.Logplayer)
+		return lp
 	}
 
 	logz, err := logfile.Open(filename, r)
 	if err != nil {
 		mw.Error(fmt.Errorf("failed to open log file: %w", err))
-		return
+		return nil
 	}
 
 	mw.Log("loaded log file " + filename)
 
-	lp := logplayer.New(&logplayer.Config{
-		EBus:    ebus.CONTROLLER,
-		Logfile: logz,
+	var lp *logplayer.Logplayer
+	lp = logplayer.New(&logplayer.Config{
+		EBus:             ebus.CONTROLLER,
+		Logfile:          logz,
+		OnMarkersChanged: mw.saveMarkers(filename),
+		OnStatistics: func(times []time.Time, values map[string][]float64) {
+			mw.openLogStatistics(fp, lp, times, values)
+		},
+		Views: mw.logViews(fp, logz),
 	})
 	/*
 		content := container.NewBorder(
@@ -501,6 +532,7 @@ func (mw *MainWindow) LoadLogfile(filename string, r io.Reader, pos fyne.Positio
 	}
 	iw.Move(pos2)
 
+	return lp
 }
 
 func (mw *MainWindow) Log(s string) {
@@ -656,17 +688,30 @@ func (mw *MainWindow) LoadPreset(r io.Reader) error {
 	if err != nil {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
-	var cfg []*symbol.Symbol
-	if err := json.Unmarshal(b, &cfg); err != nil {
+	preset, err := presets.Decode(b)
+	if err != nil {
 		return fmt.Errorf("failed to unmarshal config file: %w", err)
 	}
-	mw.symbolList.LoadSymbols(cfg...)
-	mw.app.Preferences().SetString(prefsSymbolList, string(b))
+	if len(preset.Channels) > 0 {
+		if err := mw.settings.SetDerivedChannels(preset.Channels); err != nil {
+			return fmt.Errorf("failed to load derived channels: %w", err)
+		}
+	}
+	mw.symbolList.LoadSymbols(preset.Symbols...)
+	if sb, err := json.Marshal(preset.Symbols); err == nil {
+		mw.app.Preferences().SetString(prefsSymbolList, string(sb))
+	}
 	return nil
 }
 
 func (mw *MainWindow) SavePreset(filename string) error {
-	b, err := json.Marshal(mw.symbolList.Symbols())
+	var b []byte
+	var err error
+	if channels := mw.settings.GetDerivedChannels(); len(channels) > 0 {
+		b, err = json.Marshal(presets.Preset{Symbols: mw.symbolList.Symbols(), Channels: channels})
+	} else {
+		b, err = json.Marshal(mw.symbolList.Symbols())
+	}
 	if err != nil {
 		return fmt.Errorf("failed to marshal config file: %w", err)
 	}
@@ -0,0 +1,169 @@
+package windows
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/alarm"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/sound"
+)
+
+var (
+	alarmBannerColor      = color.RGBA{0xD0, 0x10, 0x10, 0xFF}
+	alarmBannerFlashColor = color.RGBA{0x60, 0x00, 0x00, 0xFF}
+)
+
+// alarmBanner is a flashing banner listing the active alarms, it is hidden when there are none
+type alarmBanner struct {
+	widget.BaseWidget
+
+	bg   *canvas.Rectangle
+	text *canvas.Text
+
+	// active alarm names in the order they were raised
+	active []string
+	values map[string]float64
+	stop   chan struct{}
+
+	container *fyne.Container
+}
+
+func newAlarmBanner() *alarmBanner {
+	b := &alarmBanner{
+		bg:     canvas.NewRectangle(alarmBannerColor),
+		text:   canvas.NewText("", color.White),
+		values: make(map[string]float64),
+	}
+	b.ExtendBaseWidget(b)
+	b.text.TextStyle.Bold = true
+	b.text.TextSize = theme.TextSize() * 1.3
+	b.text.Alignment = fyne.TextAlignCenter
+	b.container = container.NewStack(b.bg, container.NewPadded(b.text))
+	b.Hide()
+	return b
+}
+
+// update adds or removes the alarm of e, must be called on the fyne thread
+func (b *alarmBanner) update(e alarm.Event) {
+	name := e.Rule.Name
+	b.active = slices.DeleteFunc(b.active, func(s string) bool { return s == name })
+	if e.Active {
+		b.active = append(b.active, name)
+		b.values[name] = e.Value
+	}
+
+	if len(b.active) == 0 {
+		b.clear()
+		return
+	}
+
+	parts := make([]string, len(b.active))
+	for i, n := range b.active {
+		parts[i] = n + " (" + strconv.FormatFloat(b.values[n], 'f', 2, 64) + ")"
+	}
+	b.text.Text = "ALARM: " + strings.Join(parts, ", ")
+	b.text.Refresh()
+	b.Show()
+	b.flash()
+}
+
+func (b *alarmBanner) flash() {
+	if b.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	b.stop = stop
+	go func() {
+		t := time.NewTicker(400 * time.Millisecond)
+		defer t.Stop()
+		on := true
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				on = !on
+				fyne.Do(func() {
+					if on {
+						b.bg.FillColor = alarmBannerColor
+					} else {
+						b.bg.FillColor = alarmBannerFlashColor
+					}
+					b.bg.Refresh()
+				})
+			}
+		}
+	}()
+}
+
+func (b *alarmBanner) clear() {
+	b.active = nil
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+	b.bg.FillColor = alarmBannerColor
+	b.Hide()
+}
+
+func (b *alarmBanner) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(b.container)
+}
+
+// setAlarms replaces the alarm rules and subscribes to the topics they use
+func (mw *MainWindow) setAlarms(rules []alarm.Rule) {
+	for _, cancel := range mw.alarmCancels {
+		cancel()
+	}
+	mw.alarmCancels = nil
+	mw.alarmBanner.clear()
+	if len(rules) == 0 {
+		return
+	}
+
+	monitor, err := alarm.NewMonitor(rules, mw.onAlarm)
+	if err != nil {
+		mw.Error(fmt.Errorf("failed to set up alarms: %w", err))
+		return
+	}
+	// open the audio device now instead of when the first alarm is raised
+	if slices.ContainsFunc(rules, func(r alarm.Rule) bool { return !r.Silent }) {
+		go func() {
+			if err := sound.Init(); err != nil {
+				log.Println("alarm sound:", err)
+			}
+		}()
+	}
+	for _, topic := range monitor.Topics() {
+		mw.alarmCancels = append(mw.alarmCancels, ebus.CONTROLLER.SubscribeFunc(topic, func(v float64) {
+			monitor.Update(topic, v, time.Now())
+		}))
+	}
+}
+
+// onAlarm plays the alarm sound and marks the log when an alarm is raised.
+// It is called from the event bus, mw.dlc is only touched on the fyne thread
+func (mw *MainWindow) onAlarm(e alarm.Event) {
+	if e.Active && !e.Rule.Silent {
+		sound.Play(sound.Tone(880, 1500*time.Millisecond, 150*time.Millisecond))
+	}
+	fyne.Do(func() {
+		if dlc := mw.dlc; e.Active && dlc != nil {
+			if err := dlc.AddMarker(fmt.Sprintf("alarm: %s %.2f", e.Rule.Name, e.Value)); err != nil {
+				log.Println("alarm marker:", err)
+			}
+		}
+		mw.alarmBanner.update(e)
+	})
+}
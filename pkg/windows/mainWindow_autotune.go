@@ -0,0 +1,360 @@
+package windows
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/autotune"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/mapfile"
+	"github.com/roffe/txlogger/pkg/widgets"
+	"github.com/roffe/txlogger/pkg/widgets/mapviewer"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+	"github.com/roffe/txlogger/pkg/widgets/numericentry"
+)
+
+const prefsAutotuneTarget = "autotuneTarget"
+
+var _ fyne.Widget = (*Autotuner)(nil)
+
+// Autotuner collects wideband logs and proposes corrections to the fuel map,
+// the proposal is shown in the fuel map window where each cell can be accepted or rejected
+type Autotuner struct {
+	widget.BaseWidget
+
+	mw      *MainWindow
+	ecu     string
+	typ     symbol.ECUType
+	profile autotune.Profile
+
+	// fuel map axis symbols and their live channels
+	xAxis, yAxis string
+	xDesc, yDesc string
+	xFrom, yFrom string
+
+	logs     []logfile.Logfile
+	logNames []string
+	// the logs are in use by a run and are closed when it finishes
+	running, closed bool
+
+	logList *widget.Label
+	status  *widget.Label
+	target  *mapviewer.MapViewer
+	entries *autotuneEntries
+
+	container *fyne.Container
+}
+
+type autotuneEntries struct {
+	lambda                              *widget.Entry
+	minCoolant                          *numericentry.Widget
+	maxPedalRate, maxRPMRate, maxAMRate *numericentry.Widget
+	minHits, fullHits, maxCorrection    *numericentry.Widget
+}
+
+func NewAutotuner(mw *MainWindow) (*Autotuner, error) {
+	ecu := mw.selects.ecuSelect.Selected
+	profile, ok := autotune.Profiles[ecu]
+	if !ok {
+		return nil, fmt.Errorf("autotune is not supported for %s", ecu)
+	}
+	if mw.fw == nil {
+		return nil, errors.New("no binary loaded")
+	}
+	var typ symbol.ECUType
+	switch ecu {
+	case "T5":
+		typ = symbol.ECU_T5
+	case "T7":
+		typ = symbol.ECU_T7
+	case "T8":
+		typ = symbol.ECU_T8
+	}
+
+	axis := symbol.GetInfo(typ, profile.FuelMap)
+	at := &Autotuner{
+		mw:      mw,
+		ecu:     ecu,
+		typ:     typ,
+		profile: profile,
+		xAxis:   axis.X,
+		yAxis:   axis.Y,
+		xDesc:   axis.XDescription,
+		yDesc:   axis.YDescription,
+		xFrom:   axis.XFrom,
+		yFrom:   axis.YFrom,
+		logList: widget.NewLabel("No logs added"),
+		status:  widget.NewLabel(""),
+	}
+	at.ExtendBaseWidget(at)
+
+	target, err := at.newTargetMap()
+	if err != nil {
+		return nil, err
+	}
+	at.target = target
+
+	cfg, err := autotune.DefaultConfig(ecu)
+	if err != nil {
+		return nil, err
+	}
+	at.entries = &autotuneEntries{
+		lambda:        widget.NewEntry(),
+		minCoolant:    newFloatEntry(cfg.MinCoolant),
+		maxPedalRate:  newFloatEntry(cfg.MaxPedalRate),
+		maxRPMRate:    newFloatEntry(cfg.MaxRPMRate),
+		maxAMRate:     newFloatEntry(cfg.MaxAirmassRate),
+		minHits:       newFloatEntry(cfg.MinHits),
+		fullHits:      newFloatEntry(cfg.FullConfidenceHits),
+		maxCorrection: newFloatEntry(cfg.MaxCorrection * 100),
+	}
+	at.entries.lambda.SetText(cfg.Lambda)
+
+	at.logList.Wrapping = fyne.TextWrapWord
+	at.status.Wrapping = fyne.TextWrapWord
+
+	minHits := widget.NewFormItem("Min hits", at.entries.minHits)
+	minHits.HintText = "Weighted samples needed before a cell gets a proposal"
+	fullHits := widget.NewFormItem("Full confidence hits", at.entries.fullHits)
+	fullHits.HintText = "Cells with fewer hits only get part of the correction"
+
+	form := widget.NewForm(
+		widget.NewFormItem("Wideband", at.entries.lambda),
+		widget.NewFormItem("Min coolant °C", at.entries.minCoolant),
+		widget.NewFormItem("Max pedal change /s", at.entries.maxPedalRate),
+		widget.NewFormItem("Max rpm change /s", at.entries.maxRPMRate),
+		widget.NewFormItem("Max "+profile.Airmass+" change /s", at.entries.maxAMRate),
+		minHits,
+		fullHits,
+		widget.NewFormItem("Max correction %", at.entries.maxCorrection),
+	)
+
+	logButtons := container.NewGridWithColumns(2,
+		widget.NewButtonWithIcon("Add log", theme.ContentAddIcon(), at.addLog),
+		widget.NewButtonWithIcon("Clear logs", theme.ContentClearIcon(), at.clearLogs),
+	)
+
+	left := container.NewVBox(
+		widget.NewLabelWithStyle("Fuel map: "+profile.FuelMap, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		logButtons,
+		at.logList,
+		form,
+		widget.NewButtonWithIcon("Run", theme.MediaPlayIcon(), at.run),
+		at.status,
+	)
+
+	at.container = container.NewBorder(
+		nil,
+		nil,
+		container.NewVScroll(left),
+		nil,
+		container.NewBorder(
+			widget.NewLabel("Target lambda"),
+			nil,
+			nil,
+			nil,
+			at.target,
+		),
+	)
+
+	return at, nil
+}
+
+func newFloatEntry(value float64) *numericentry.Widget {
+	e := numericentry.New()
+	e.SetText(strconv.FormatFloat(value, 'f', -1, 64))
+	return e
+}
+
+func parseFloatEntry(name string, e *numericentry.Widget) (float64, error) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(e.Text, ",", "."), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return v, nil
+}
+
+// newTargetMap creates the target lambda map over the fuel map axes, the last
+// used target is restored from preferences if the axes still match
+func (at *Autotuner) newTargetMap() (*mapviewer.MapViewer, error) {
+	symX := at.mw.fw.GetByName(at.xAxis)
+	symY := at.mw.fw.GetByName(at.yAxis)
+	if symX == nil || symY == nil {
+		return nil, fmt.Errorf("failed to find axes for %s", at.profile.FuelMap)
+	}
+	xData, yData := symX.Float64s(), symY.Float64s()
+
+	zData := make([]float64, len(xData)*len(yData))
+	for i := range zData {
+		zData[i] = 1
+	}
+	if saved := at.mw.app.Preferences().String(prefsAutotuneTarget + at.ecu); saved != "" {
+		if m, err := mapfile.ReadCSV(strings.NewReader(saved)); err == nil && len(m.ZData) == len(zData) {
+			copy(zData, m.ZData)
+		}
+	}
+
+	return mapviewer.New(&mapviewer.Config{
+		Name:       "Target lambda",
+		XData:      xData,
+		YData:      yData,
+		ZData:      zData,
+		XPrecision: symbol.GetPrecision(symX.Correctionfactor),
+		YPrecision: symbol.GetPrecision(symY.Correctionfactor),
+		ZPrecision: 2,
+		XLabel:     at.xDesc,
+		YLabel:     at.yDesc,
+		ZLabel:     "Lambda",
+		// the target is only used by the autotuner, there is nothing to sync
+		SaveECUFunc:    func([]float64) {},
+		OnUpdateCell:   func(int, []float64) {},
+		OnError:        at.mw.Error,
+		Editable:       true,
+		ColorblindMode: at.mw.settings.GetColorBlindMode(),
+	})
+}
+
+func (at *Autotuner) addLog() {
+	cb := func(r fyne.URIReadCloser) {
+		defer r.Close()
+		filename := r.URI().Name()
+		lf, err := logfile.Open(filename, r)
+		if err != nil {
+			at.mw.Error(err)
+			return
+		}
+		at.logs = append(at.logs, lf)
+		at.logNames = append(at.logNames, filename)
+		at.logList.SetText(strings.Join(at.logNames, "\n"))
+	}
+	widgets.SelectFile(cb, "Log file", "csv", "t5l", "t7l", "t8l", "txb")
+}
+
+func (at *Autotuner) clearLogs() {
+	if at.running {
+		return
+	}
+	for _, lf := range at.logs {
+		lf.Close()
+	}
+	at.logs = nil
+	at.logNames = nil
+	at.logList.SetText("No logs added")
+}
+
+func (at *Autotuner) config() (*autotune.Config, error) {
+	cfg, err := autotune.DefaultConfig(at.ecu)
+	if err != nil {
+		return nil, err
+	}
+	cfg.XChannel = at.xFrom
+	cfg.YChannel = at.yFrom
+	cfg.Lambda = strings.TrimSpace(at.entries.lambda.Text)
+
+	for _, f := range []struct {
+		name  string
+		entry *numericentry.Widget
+		value *float64
+	}{
+		{"min coolant", at.entries.minCoolant, &cfg.MinCoolant},
+		{"max pedal change", at.entries.maxPedalRate, &cfg.MaxPedalRate},
+		{"max rpm change", at.entries.maxRPMRate, &cfg.MaxRPMRate},
+		{"max airmass change", at.entries.maxAMRate, &cfg.MaxAirmassRate},
+		{"min hits", at.entries.minHits, &cfg.MinHits},
+		{"full confidence hits", at.entries.fullHits, &cfg.FullConfidenceHits},
+		{"max correction", at.entries.maxCorrection, &cfg.MaxCorrection},
+	} {
+		v, err := parseFloatEntry(f.name, f.entry)
+		if err != nil {
+			return nil, err
+		}
+		*f.value = v
+	}
+	cfg.MaxCorrection /= 100
+	return cfg, nil
+}
+
+func (at *Autotuner) run() {
+	if at.running {
+		return
+	}
+	if len(at.logs) == 0 {
+		at.mw.Error(errors.New("add at least one log to autotune from"))
+		return
+	}
+	cfg, err := at.config()
+	if err != nil {
+		at.mw.Error(err)
+		return
+	}
+
+	mv := at.mw.openMapViewer(at.typ, "", at.profile.FuelMap)
+	if mv == nil {
+		return
+	}
+	fuel := mv.Map()
+	target := at.target.Map()
+
+	var buf bytes.Buffer
+	if err := mapfile.WriteCSV(&buf, target); err == nil {
+		at.mw.app.Preferences().SetString(prefsAutotuneTarget+at.ecu, buf.String())
+	}
+
+	at.status.SetText("Analyzing...")
+	at.running = true
+	logs := at.logs
+	go func() {
+		res, err := autotune.Run(cfg, fuel, target, logs...)
+		fyne.Do(func() {
+			at.running = false
+			if at.closed {
+				at.clearLogs()
+				return
+			}
+			if err != nil {
+				at.status.SetText(err.Error())
+				at.mw.Error(err)
+				return
+			}
+			at.status.SetText(res.String())
+			at.mw.Log("Autotune " + at.profile.FuelMap + ": " + res.String())
+			if err := mv.SetProposal(res.Proposal); err != nil {
+				at.mw.Error(err)
+			}
+		})
+	}()
+}
+
+func (at *Autotuner) close() {
+	at.closed = true
+	at.clearLogs()
+}
+
+func (at *Autotuner) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(at.container)
+}
+
+func (mw *MainWindow) openAutotune() {
+	if w := mw.wm.HasWindow("Autotune"); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+	at, err := NewAutotuner(mw)
+	if err != nil {
+		mw.Error(err)
+		return
+	}
+	inner := multiwindow.NewInnerWindow("Autotune", at)
+	inner.Icon = theme.ComputerIcon()
+	inner.OnClose = at.close
+	mw.wm.Add(inner)
+	inner.Resize(fyne.Size{Width: 900, Height: 600})
+}
@@ -3,7 +3,6 @@ package windows
 import (
 	"fmt"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
@@ -152,7 +151,7 @@ func (mw *MainWindow) newOpenLogBtn() *widget.Button {
 			filename := r.URI().Path()
 			mw.LoadLogfileCombined(filename, r, fyne.Position{}, true)
 		}
-		widgets.SelectFile(cb, "logfile", "t5l", "t7l", "t8l", "csv")
+		widgets.SelectFile(cb, "logfile", "t5l", "t7l", "t8l", "csv", "txb")
 	})
 }
 
@@ -249,22 +248,12 @@ func (mw *MainWindow) newLogplayerBtn() *widget.Button {
 func (mw *MainWindow) newLogBtn() *widget.Button {
 	return widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), func() {
 		if mw.loggingRunning {
-			if mw.dlc != nil {
-				mw.dlc.Close()
-			}
+			mw.stopLogging()
 			return
 		}
-		for _, v := range mw.symbolList.Symbols() {
-			if v.Name == "AirMassMast.m_Request" && mw.selects.ecuSelect.Selected == "T7" {
-				mw.Error(fmt.Errorf("AirMassMast.m_Request is not supported on T7, Did you forget to change preset?"))
-				return
-			}
-			if v.Name == "m_Request" && mw.selects.ecuSelect.Selected == "T8" {
-				mw.Error(fmt.Errorf("m_Request is not supported on T8, Did you forget to change preset?"))
-				return
-			}
+		if err := mw.startLogging(); err != nil {
+			mw.Error(err)
 		}
-		mw.startLogging()
 	})
 }
 
@@ -346,11 +335,28 @@ func (mw *MainWindow) newDashboardBtn() *widget.Button {
 		}
 	})
 }
-func (mw *MainWindow) startLogging() {
+func (mw *MainWindow) stopLogging() {
+	if mw.dlc != nil {
+		mw.dlc.Close()
+	}
+}
+
+func (mw *MainWindow) startLogging() error {
+	if mw.loggingRunning {
+		return fmt.Errorf("logging is already running")
+	}
 	if mw.symbolList.Count() == 0 {
-		mw.Error(fmt.Errorf("no symbols selected for logging"))
-		return
+		return fmt.Errorf("no symbols selected for logging")
+	}
+	for _, v := range mw.symbolList.Symbols() {
+		if v.Name == "AirMassMast.m_Request" && mw.selects.ecuSelect.Selected == "T7" {
+			return fmt.Errorf("AirMassMast.m_Request is not supported on T7, Did you forget to change preset?")
+		}
+		if v.Name == "m_Request" && mw.selects.ecuSelect.Selected == "T8" {
+			return fmt.Errorf("m_Request is not supported on T8, Did you forget to change preset?")
+		}
 	}
+
 	var device gocan.Adapter
 	var err error
 	deviceName := mw.selects.remoteSelect.Selected
@@ -358,26 +364,30 @@ func (mw *MainWindow) startLogging() {
 	if mw.selects.remoteSelect.SelectedIndex() < 2 {
 		device, err = mw.settings.GetAdapter(mw.selects.ecuSelect.Selected)
 		if err != nil {
-			mw.Error(err)
-			return
+			return err
 		}
 		deviceName = device.Name()
 	}
 
 	if mw.selects.ecuSelect.Selected == "T5" {
 		if strings.Contains(device.Name(), "J2534") || strings.Contains(device.Name(), "ELM327") {
-			mw.Error(fmt.Errorf("%s is not supported for T5", device.Name()))
-			return
+			return fmt.Errorf("%s is not supported for T5", device.Name())
 		}
 	}
 
-	mw.dlc, _, err = newDataLogger(mw, device)
+	mw.dlc, mw.logFilename, err = newDataLogger(mw, device)
 	if err != nil {
-		mw.Error(err)
-		return
+		return err
 	}
 
+	mw.runLogger(deviceName)
+	return nil
+}
+
+// runLogger starts mw.dlc and restores the UI when it stops
+func (mw *MainWindow) runLogger(deviceName string) {
 	mw.loggingRunning = true
+	mw.counters.captured, mw.counters.errors, mw.counters.fps = 0, 0, 0
 
 	mw.buttons.logBtn.Icon = theme.MediaStopIcon()
 	mw.buttons.logBtn.SetText("Stop")
@@ -396,6 +406,7 @@ func (mw *MainWindow) startLogging() {
 			mw.buttons.logBtn.Icon = theme.MediaPlayIcon()
 			mw.buttons.logBtn.SetText("Start")
 			mw.canLED.Off()
+			mw.counters.fps = 0
 			mw.counters.fpsCounterLabel.SetText("Fps: 0")
 		})
 	}()
@@ -409,23 +420,11 @@ func newDataLogger(mw *MainWindow, device gocan.Adapter) (datalogger.IClient, st
 		Symbols:        mw.symbolList.Symbols(),
 		Rate:           mw.settings.GetFreq(),
 		OnMessage:      mw.Log,
-		CaptureCounter: func(i int) {
-			fyne.Do(func() {
-				mw.counters.capturedCounterLabel.SetText("Cap: " + strconv.Itoa(i))
-			})
-		},
-		ErrorCounter: func(i int) {
-			fyne.Do(func() {
-				mw.counters.errorCounterLabel.SetText("Err: " + strconv.Itoa(i))
-			})
-		},
-		FpsCounter: func(i int) {
-			fyne.Do(func() {
-				mw.counters.fpsCounterLabel.SetText("Fps: " + strconv.Itoa(i))
-			})
-		},
-		LogFormat: mw.settings.GetLogFormat(),
-		LogPath:   mw.settings.GetLogPath(),
+		CaptureCounter: mw.setCapturedCounter,
+		ErrorCounter:   mw.setErrorCounter,
+		FpsCounter:     mw.setFpsCounter,
+		LogFormat:      mw.settings.GetLogFormat(),
+		LogPath:        mw.settings.GetLogPath(),
 		WidebandConfig: datalogger.WidebandConfig{
 			Type:                   mw.settings.GetWidebandType(),
 			Port:                   mw.settings.GetWidebandPort(),
@@ -435,6 +434,10 @@ func newDataLogger(mw *MainWindow, device gocan.Adapter) (datalogger.IClient, st
 			High:                   mw.settings.GetHigh(),
 		},
 		//Remote: mw.selects.remoteSelect.Selected == "Remote",
-		RemoteMode: mw.selects.remoteSelect.SelectedIndex(),
+		RemoteMode:    mw.selects.remoteSelect.SelectedIndex(),
+		Relay:         mw.settings.GetRelay(),
+		RateClasses:   mw.settings.GetRateClasses(),
+		AutoReconnect: mw.settings.GetAutoReconnect(),
+		Trigger:       mw.settings.GetTrigger(),
 	})
 }
@@ -0,0 +1,346 @@
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/bincompare"
+	"github.com/roffe/txlogger/pkg/widgets"
+	"github.com/roffe/txlogger/pkg/widgets/mapviewer"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+)
+
+var _ fyne.Widget = (*Comparer)(nil)
+
+type compareSide struct {
+	filename string
+	fw       symbol.SymbolCollection
+	typ      symbol.ECUType
+	// maps changed in this side that are not saved yet
+	changed map[string]bool
+}
+
+func (s *compareSide) String() string {
+	if s.fw == nil {
+		return "none"
+	}
+	name := filepath.Base(s.filename)
+	if len(s.changed) > 0 {
+		name += " *"
+	}
+	return name + " (" + s.typ.String() + ")"
+}
+
+// Comparer lists the symbols that differ between two binaries, opening a
+// symbol shows the A values with the B values overlaid so they can be transferred from B to A
+type Comparer struct {
+	widget.BaseWidget
+
+	mw   *MainWindow
+	a, b *compareSide
+
+	diffs []bincompare.Diff
+	// inner windows showing a diff, they are closed when the binaries changes
+	open map[string]*multiwindow.InnerWindow
+
+	aLabel, bLabel *widget.Label
+	status         *widget.Label
+	list           *widget.List
+
+	container *fyne.Container
+}
+
+func NewComparer(mw *MainWindow) *Comparer {
+	c := &Comparer{
+		mw:     mw,
+		a:      &compareSide{},
+		b:      &compareSide{},
+		open:   make(map[string]*multiwindow.InnerWindow),
+		aLabel: widget.NewLabel(""),
+		bLabel: widget.NewLabel(""),
+		status: widget.NewLabel("Open two binaries to compare"),
+	}
+	c.ExtendBaseWidget(c)
+
+	c.list = widget.NewList(
+		func() int {
+			return len(c.diffs)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			d := c.diffs[id]
+			text := d.String()
+			if d.Total > 0 {
+				text += fmt.Sprintf(" - %d/%d values differ", d.Cells, d.Total)
+			}
+			obj.(*widget.Label).SetText(text)
+		},
+	)
+	c.list.OnSelected = func(id widget.ListItemID) {
+		c.list.Unselect(id)
+		c.openDiff(c.diffs[id])
+	}
+
+	// the loaded binary can be used as A so transfers end up in the open file
+	useLoaded := widget.NewButtonWithIcon("Use loaded binary", theme.DocumentIcon(), func() {
+		if mw.fw == nil {
+			mw.Error(errors.New("no binary loaded"))
+			return
+		}
+		typ := symbol.ECUTypeFromString(mw.selects.ecuSelect.Selected)
+		c.setSide(c.a, &compareSide{filename: mw.filename, fw: mw.fw, typ: typ})
+	})
+
+	c.container = container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, widget.NewLabel("A:"), container.NewHBox(
+				useLoaded,
+				widget.NewButtonWithIcon("Open", theme.FolderOpenIcon(), func() { c.load(c.a) }),
+				widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), c.saveA),
+			), c.aLabel),
+			container.NewBorder(nil, nil, widget.NewLabel("B:"), container.NewHBox(
+				widget.NewButtonWithIcon("Open", theme.FolderOpenIcon(), func() { c.load(c.b) }),
+			), c.bLabel),
+			widget.NewButtonWithIcon("Swap A and B", theme.ViewRefreshIcon(), c.swap),
+		),
+		c.status,
+		nil,
+		nil,
+		c.list,
+	)
+	c.update()
+	return c
+}
+
+func (c *Comparer) load(side *compareSide) {
+	cb := func(r fyne.URIReadCloser) {
+		defer r.Close()
+		filename := r.URI().Path()
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			c.mw.Error(fmt.Errorf("error reading file: %w", err))
+			return
+		}
+		ecuType, symbols, err := symbol.Load(filename, data, c.mw.Log)
+		if err != nil {
+			c.mw.Error(fmt.Errorf("error loading symbols: %w", err))
+			return
+		}
+		c.setSide(side, &compareSide{filename: filename, fw: symbols, typ: ecuType})
+	}
+	widgets.SelectFile(cb, "Binary file", "bin")
+}
+
+func (c *Comparer) setSide(side, value *compareSide) {
+	*side = *value
+	c.update()
+}
+
+func (c *Comparer) swap() {
+	c.a, c.b = c.b, c.a
+	c.update()
+}
+
+func (c *Comparer) saveA() {
+	if c.a.fw == nil {
+		return
+	}
+	if err := c.a.fw.Save(c.a.filename); err != nil {
+		c.mw.Error(err)
+		return
+	}
+	for name := range c.a.changed {
+		c.mw.journal.MarkFile(name, nil)
+	}
+	c.a.changed = nil
+	c.mw.Log("Saved " + c.a.filename)
+	c.aLabel.SetText(c.a.String())
+}
+
+// update recompares the binaries, diff windows are closed since they show the old state
+func (c *Comparer) update() {
+	for _, w := range c.open {
+		w.Close()
+	}
+	clear(c.open)
+	c.diffs = nil
+	c.aLabel.SetText(c.a.String())
+	c.bLabel.SetText(c.b.String())
+
+	switch {
+	case c.a.fw == nil || c.b.fw == nil:
+		c.status.SetText("Open two binaries to compare")
+	case c.a.typ != c.b.typ:
+		c.status.SetText(fmt.Sprintf("Can't compare a %s binary with a %s binary", c.a.typ, c.b.typ))
+	default:
+		c.diffs = bincompare.Compare(c.a.fw, c.b.fw)
+		c.status.SetText(strconv.Itoa(len(c.diffs)) + " symbols differ")
+	}
+	c.list.Refresh()
+}
+
+// updateDiff recompares a single symbol after values has been transferred
+func (c *Comparer) updateDiff(name string) {
+	d, changed := bincompare.CompareSymbol(c.a.fw, c.b.fw, name)
+	for i := range c.diffs {
+		if c.diffs[i].Name != name {
+			continue
+		}
+		if changed {
+			c.diffs[i] = d
+		} else {
+			c.diffs = append(c.diffs[:i], c.diffs[i+1:]...)
+		}
+		break
+	}
+	if c.a.changed == nil {
+		c.a.changed = make(map[string]bool)
+	}
+	c.a.changed[name] = true
+	c.aLabel.SetText(c.a.String())
+	c.status.SetText(strconv.Itoa(len(c.diffs)) + " symbols differ")
+	c.list.Refresh()
+}
+
+func (c *Comparer) openDiff(d bincompare.Diff) {
+	if d.OnlyInA || d.OnlyInB {
+		c.mw.Log(d.String() + ", nothing to compare")
+		return
+	}
+	title := "Compare " + d.Name
+	if w := c.mw.wm.HasWindow(title); w != nil {
+		c.mw.wm.Raise(w)
+		return
+	}
+
+	symA, symB := c.a.fw.GetByName(d.Name), c.b.fw.GetByName(d.Name)
+	valuesA, valuesB := symA.Float64s(), symB.Float64s()
+	if len(valuesA) != len(valuesB) {
+		c.mw.Error(fmt.Errorf("%s has %d values in A and %d in B", d.Name, len(valuesA), len(valuesB)))
+		return
+	}
+
+	proposal := make([]float64, len(valuesA))
+	for i := range valuesA {
+		proposal[i] = math.NaN()
+		if valuesA[i] != valuesB[i] {
+			proposal[i] = valuesB[i]
+		}
+	}
+
+	axis := symbol.GetInfo(c.a.typ, d.Name)
+	xData, yData, xPrecision, yPrecision := c.axes(axis.X, axis.Y, len(valuesA))
+
+	// transferred values are written to A, it is saved with the Save button
+	store := func() {
+		if err := symA.SetData(symA.EncodeFloat64s(valuesA)); err != nil {
+			c.mw.Error(err)
+			return
+		}
+		c.updateDiff(d.Name)
+	}
+
+	mv, err := mapviewer.New(&mapviewer.Config{
+		Name:         d.Name,
+		XData:        xData,
+		YData:        yData,
+		ZData:        valuesA,
+		XPrecision:   xPrecision,
+		YPrecision:   yPrecision,
+		ZPrecision:   symbol.GetPrecision(symA.Correctionfactor),
+		XLabel:       axis.XDescription,
+		YLabel:       axis.YDescription,
+		ZLabel:       axis.ZDescription,
+		SaveECUFunc:  func([]float64) { store() },
+		OnUpdateCell: func(int, []float64) { store() },
+		OnError:      c.mw.Error,
+		OnEdit: func(action string, cells []int, old, new []float64) {
+			c.mw.journal.Add(d.Name, len(xData), action, cells, old, new)
+		},
+		Editable:          true,
+		ShowAbsoluteDelta: true,
+		ProposalName:      "value from B",
+		ColorblindMode:    c.mw.settings.GetColorBlindMode(),
+	})
+	if err != nil {
+		c.mw.Error(err)
+		return
+	}
+	if err := mv.SetProposal(proposal); err != nil {
+		c.mw.Error(err)
+		return
+	}
+
+	inner := multiwindow.NewInnerWindow(title, mv)
+	inner.Icon = theme.GridIcon()
+	inner.OnClose = func() {
+		delete(c.open, d.Name)
+	}
+	c.open[d.Name] = inner
+	c.mw.wm.Add(inner)
+}
+
+// axes returns the axis values from A, index based axes are used when the
+// axis symbols are missing or does not match the number of values
+func (c *Comparer) axes(xName, yName string, values int) (xData, yData []float64, xPrecision, yPrecision int) {
+	index := func(n int) []float64 {
+		data := make([]float64, n)
+		for i := range data {
+			data[i] = float64(i)
+		}
+		return data
+	}
+
+	if symX := c.a.fw.GetByName(xName); symX != nil {
+		xData = symX.Float64s()
+		xPrecision = symbol.GetPrecision(symX.Correctionfactor)
+	}
+	if len(xData) == 0 || values%len(xData) != 0 {
+		// 1D symbols are shown as a single column
+		return []float64{0}, index(values), 0, 0
+	}
+	rows := values / len(xData)
+	if symY := c.a.fw.GetByName(yName); symY != nil {
+		if yData := symY.Float64s(); len(yData) == rows {
+			return xData, yData, xPrecision, symbol.GetPrecision(symY.Correctionfactor)
+		}
+	}
+	if rows == 1 {
+		return xData, []float64{0}, xPrecision, 0
+	}
+	return xData, index(rows), xPrecision, 0
+}
+
+func (c *Comparer) close() {
+	for _, w := range c.open {
+		w.Close()
+	}
+}
+
+func (c *Comparer) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(c.container)
+}
+
+func (mw *MainWindow) openCompare() {
+	if w := mw.wm.HasWindow("Compare binaries"); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+	c := NewComparer(mw)
+	inner := multiwindow.NewInnerWindow("Compare binaries", c)
+	inner.Icon = theme.ContentCopyIcon()
+	inner.OnClose = c.close
+	mw.wm.Add(inner)
+	inner.Resize(fyne.Size{Width: 600, Height: 500})
+}
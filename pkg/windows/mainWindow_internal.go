@@ -76,7 +76,7 @@ func (mw *MainWindow) onDropped(p fyne.Position, uris []fyne.URI) {
 			if err := mw.LoadSymbolsFromFile(filename); err != nil {
 				mw.Error(err)
 			}
-		case ".t5l", ".t7l", ".t8l", ".csv":
+		case ".t5l", ".t7l", ".t8l", ".csv", ".txb":
 			// Check if we dropped it on the open log button
 			// log.Println(mw.buttons.openLogBtn.Position(), mw.buttons.openLogBtn.Size())
 			if p.X >= mw.buttons.openLogBtn.Position().X && p.X <= mw.buttons.openLogBtn.Position().X+mw.buttons.openLogBtn.Size().Width &&
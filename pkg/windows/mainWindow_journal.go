@@ -0,0 +1,117 @@
+package windows
+
+import (
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/journal"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+)
+
+var journalColumns = []struct {
+	title string
+	width float32
+}{
+	{"Time", 90},
+	{"Map", 220},
+	{"Cell", 60},
+	{"Old", 80},
+	{"New", 80},
+	{"Action", 60},
+	{"ECU", 50},
+	{"File", 50},
+}
+
+func journalCell(e journal.Entry, col int) string {
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "-"
+	}
+	switch col {
+	case 0:
+		return e.Time.Format("15:04:05")
+	case 1:
+		return e.Map
+	case 2:
+		return strconv.Itoa(e.X) + "," + strconv.Itoa(e.Y)
+	case 3:
+		return strconv.FormatFloat(e.Old, 'f', -1, 64)
+	case 4:
+		return strconv.FormatFloat(e.New, 'f', -1, 64)
+	case 5:
+		return e.Action
+	case 6:
+		return yesNo(e.ECU)
+	case 7:
+		return yesNo(e.File)
+	}
+	return ""
+}
+
+func (mw *MainWindow) openJournal() {
+	if w := mw.wm.HasWindow("Change journal"); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+
+	// newest first
+	var entries []journal.Entry
+	load := func() {
+		all := mw.journal.Entries()
+		entries = make([]journal.Entry, len(all))
+		for i, e := range all {
+			entries[len(all)-1-i] = e
+		}
+	}
+	load()
+
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(entries), len(journalColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(journalCell(entries[id.Row], id.Col))
+		},
+	)
+	table.ShowHeaderColumn = false
+	table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	table.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		if id.Col >= 0 {
+			obj.(*widget.Label).SetText(journalColumns[id.Col].title)
+		}
+	}
+	for i, c := range journalColumns {
+		table.SetColumnWidth(i, c.width)
+	}
+
+	cancel := mw.journal.OnChange(func() {
+		fyne.Do(func() {
+			load()
+			table.Refresh()
+		})
+	})
+
+	content := container.NewBorder(
+		nil,
+		widget.NewButtonWithIcon("Clear", theme.DeleteIcon(), mw.journal.Clear),
+		nil,
+		nil,
+		table,
+	)
+
+	inner := multiwindow.NewInnerWindow("Change journal", content)
+	inner.Icon = theme.HistoryIcon()
+	inner.OnClose = cancel
+	mw.wm.Add(inner)
+	inner.Resize(fyne.Size{Width: 720, Height: 400})
+}
@@ -0,0 +1,172 @@
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/autotune"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/widgets/histogram"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+	"github.com/roffe/txlogger/pkg/widgets/scatterplot"
+)
+
+// logViews returns the extra views offered by the logplayer
+func (mw *MainWindow) logViews(filename string, lf logfile.Logfile) []*fyne.MenuItem {
+	return []*fyne.MenuItem{
+		fyne.NewMenuItemWithIcon("Scatter plot", theme.GridIcon(), func() {
+			mw.openScatterPlot(filename, lf)
+		}),
+		fyne.NewMenuItemWithIcon("Histogram", theme.GridIcon(), func() {
+			mw.openHistogram(filename, lf)
+		}),
+	}
+}
+
+// openScatterPlot defaults to lambda vs airmass coloured by rpm
+func (mw *MainWindow) openScatterPlot(filename string, lf logfile.Logfile) {
+	title := "Scatter plot " + filename
+	if w := mw.wm.HasWindow(title); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+	cfg := &scatterplot.Config{
+		Logfile:        lf,
+		Y:              "Lambda.External",
+		ColorblindMode: mw.settings.GetColorBlindMode(),
+	}
+	if p, ok := autotune.Profiles[mw.selects.ecuSelect.Selected]; ok {
+		cfg.X = p.Airmass
+		cfg.Color = p.RPM
+	}
+	inner := multiwindow.NewInnerWindow(title, scatterplot.New(cfg))
+	inner.Icon = theme.GridIcon()
+	mw.wm.Add(inner)
+	inner.Resize(fyne.Size{Width: 700, Height: 500})
+}
+
+// openHistogram bins the log onto the axes of a map from the loaded binary, defaulting to the fuel map
+func (mw *MainWindow) openHistogram(filename string, lf logfile.Logfile) {
+	title := "Histogram " + filename
+	if w := mw.wm.HasWindow(title); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+	if mw.fw == nil {
+		mw.Error(errors.New("load a binary to bin the log onto its map axes"))
+		return
+	}
+
+	ecu := mw.selects.ecuSelect.Selected
+	typ, maps := mw.histogramMaps(ecu)
+	if len(maps) == 0 {
+		mw.Error(fmt.Errorf("no maps with two axes found for %s", ecu))
+		return
+	}
+
+	body := container.NewStack()
+	status := widget.NewLabel("")
+	mapSelect := widget.NewSelect(maps, func(mapName string) {
+		h, err := mw.newHistogram(typ, mapName, lf)
+		if err != nil {
+			status.SetText(err.Error())
+			body.Objects = nil
+			body.Refresh()
+			return
+		}
+		status.SetText("")
+		body.Objects = []fyne.CanvasObject{h}
+		body.Refresh()
+	})
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel("Map"), nil, mapSelect),
+		status,
+		nil,
+		nil,
+		body,
+	)
+
+	if p, ok := autotune.Profiles[ecu]; ok && slices.Contains(maps, p.FuelMap) {
+		mapSelect.SetSelected(p.FuelMap)
+	} else {
+		mapSelect.SetSelectedIndex(0)
+	}
+
+	inner := multiwindow.NewInnerWindow(title, content)
+	inner.Icon = theme.GridIcon()
+	mw.wm.Add(inner)
+	inner.Resize(fyne.Size{Width: 900, Height: 600})
+}
+
+func (mw *MainWindow) newHistogram(typ symbol.ECUType, mapName string, lf logfile.Logfile) (*histogram.Histogram, error) {
+	axis := symbol.GetInfo(typ, mapName)
+	symX, symY := mw.fw.GetByName(axis.X), mw.fw.GetByName(axis.Y)
+	if symX == nil || symY == nil {
+		return nil, fmt.Errorf("failed to find axes for %s", mapName)
+	}
+	if lf.Len() == 0 {
+		return nil, errors.New("log is empty")
+	}
+	rec := lf.At(0)
+	for _, ch := range []string{axis.XFrom, axis.YFrom} {
+		if _, ok := rec.Values[ch]; !ok {
+			return nil, fmt.Errorf("%s is not in the log", ch)
+		}
+	}
+	cfg := &histogram.Config{
+		Logfile:        lf,
+		XAxis:          symX.Float64s(),
+		YAxis:          symY.Float64s(),
+		XChannel:       axis.XFrom,
+		YChannel:       axis.YFrom,
+		XPrecision:     symbol.GetPrecision(symX.Correctionfactor),
+		YPrecision:     symbol.GetPrecision(symY.Correctionfactor),
+		ColorblindMode: mw.settings.GetColorBlindMode(),
+	}
+	if _, ok := rec.Values["Lambda.External"]; ok {
+		cfg.ZChannel = "Lambda.External"
+	}
+	return histogram.New(cfg), nil
+}
+
+// histogramMaps lists the maps of the tuning menu that has live sources for both axes
+func (mw *MainWindow) histogramMaps(ecu string) (symbol.ECUType, []string) {
+	var typ symbol.ECUType
+	var menu map[string][]string
+	switch ecu {
+	case "T5":
+		typ, menu = symbol.ECU_T5, T5SymbolsTuning
+	case "T7":
+		typ, menu = symbol.ECU_T7, T7SymbolsTuning
+	case "T8":
+		typ, menu = symbol.ECU_T8, T8SymbolsTuning
+	}
+	seen := make(map[string]bool)
+	var maps []string
+	for _, names := range menu {
+		for _, name := range names {
+			if parts := strings.Split(name, "|"); len(parts) == 2 {
+				name = parts[1]
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			axis := symbol.GetInfo(typ, name)
+			if axis.XFrom == "" || axis.YFrom == "" || mw.fw.GetByName(name) == nil {
+				continue
+			}
+			maps = append(maps, name)
+		}
+	}
+	slices.Sort(maps)
+	return typ, maps
+}
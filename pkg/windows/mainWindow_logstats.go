@@ -0,0 +1,225 @@
+package windows
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/logstats"
+	"github.com/roffe/txlogger/pkg/widgets/logplayer"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+)
+
+var logStatsColumns = []struct {
+	title string
+	width float32
+	cmp   func(a, b logstats.Segment) int
+}{
+	{"Kind", 80, func(a, b logstats.Segment) int { return cmp.Compare(a.Kind, b.Kind) }},
+	{"Start", 90, func(a, b logstats.Segment) int { return a.StartTime.Compare(b.StartTime) }},
+	{"Duration", 80, func(a, b logstats.Segment) int { return cmp.Compare(a.Duration(), b.Duration()) }},
+	{"Gear", 50, func(a, b logstats.Segment) int { return cmp.Compare(a.Gear, b.Gear) }},
+	{"Peak boost", 90, func(a, b logstats.Segment) int { return cmp.Compare(a.PeakBoost, b.PeakBoost) }},
+	{"@ RPM", 70, func(a, b logstats.Segment) int { return cmp.Compare(a.PeakBoostRPM, b.PeakBoostRPM) }},
+	{"Knock", 60, func(a, b logstats.Segment) int { return cmp.Compare(a.KnockEvents, b.KnockEvents) }},
+	{"Time above", 260, func(a, b logstats.Segment) int { return cmp.Compare(timeAbove(a), timeAbove(b)) }},
+}
+
+func logStatsCell(s logstats.Segment, col int) string {
+	switch col {
+	case 0:
+		return string(s.Kind)
+	case 1:
+		return s.StartTime.Format("15:04:05.0")
+	case 2:
+		return s.Duration().Round(100 * time.Millisecond).String()
+	case 3:
+		if s.Gear == 0 {
+			return "-"
+		}
+		return strconv.Itoa(s.Gear)
+	case 4:
+		return strconv.FormatFloat(s.PeakBoost, 'f', 2, 64)
+	case 5:
+		return strconv.FormatFloat(s.PeakBoostRPM, 'f', 0, 64)
+	case 6:
+		return strconv.Itoa(s.KnockEvents)
+	case 7:
+		keys := make([]string, 0, len(s.TimeAbove))
+		for k := range s.TimeAbove {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + ": " + s.TimeAbove[k].Round(100*time.Millisecond).String()
+		}
+		return strings.Join(parts, ", ")
+	}
+	return ""
+}
+
+// timeAbove is the summed time above all thresholds, used for sorting
+func timeAbove(s logstats.Segment) time.Duration {
+	var d time.Duration
+	for _, v := range s.TimeAbove {
+		d += v
+	}
+	return d
+}
+
+func logStatsDetails(s logstats.Segment) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s - %s (%s)\n", s.Kind, s.StartTime.Format("15:04:05.0"), s.EndTime.Format("15:04:05.0"), s.Duration().Round(100*time.Millisecond))
+	for _, name := range s.ChannelNames() {
+		c := s.Channels[name]
+		fmt.Fprintf(&b, "%s: min %.4g max %.4g avg %.4g\n", name, c.Min, c.Max, c.Avg)
+	}
+	return b.String()
+}
+
+// openLogStatistics shows the segments detected in a log, selecting a segment seeks the logplayer to it
+func (mw *MainWindow) openLogStatistics(filename string, lp *logplayer.Logplayer, times []time.Time, values map[string][]float64) {
+	title := "Statistics " + filename
+	if w := mw.wm.HasWindow(title); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+
+	ecu, profile, ok := logstats.DetectProfile(values)
+	if !ok {
+		mw.Error(errors.New("statistics needs a log with rpm and pedal position"))
+		return
+	}
+	cfg := logstats.DefaultConfig(profile)
+
+	var segments []logstats.Segment
+	summary := widget.NewLabel("")
+	details := widget.NewLabel("Select a segment to show channel statistics")
+	details.Wrapping = fyne.TextWrapWord
+
+	sortCol, sortDesc := 1, false
+	sortSegments := func() {
+		slices.SortStableFunc(segments, func(a, b logstats.Segment) int {
+			c := logStatsColumns[sortCol].cmp(a, b)
+			if sortDesc {
+				return -c
+			}
+			return c
+		})
+	}
+
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(segments), len(logStatsColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(logStatsCell(segments[id.Row], id.Col))
+		},
+	)
+	table.ShowHeaderColumn = false
+	table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewButton("", nil)
+	}
+	table.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		if id.Col < 0 {
+			return
+		}
+		btn := obj.(*widget.Button)
+		btn.SetText(logStatsColumns[id.Col].title)
+		btn.SetIcon(nil)
+		if id.Col == sortCol {
+			if sortDesc {
+				btn.SetIcon(theme.MenuDropDownIcon())
+			} else {
+				btn.SetIcon(theme.MenuDropUpIcon())
+			}
+		}
+		btn.OnTapped = func() {
+			if sortCol == id.Col {
+				sortDesc = !sortDesc
+			} else {
+				sortCol, sortDesc = id.Col, false
+			}
+			sortSegments()
+			table.UnselectAll()
+			table.Refresh()
+		}
+	}
+	for i, c := range logStatsColumns {
+		table.SetColumnWidth(i, c.width)
+	}
+	table.OnSelected = func(id widget.TableCellID) {
+		if id.Row < 0 || id.Row >= len(segments) {
+			return
+		}
+		s := segments[id.Row]
+		details.SetText(logStatsDetails(s))
+		lp.Seek(s.Start)
+	}
+
+	analyze := func() {
+		segments = logstats.Analyze(cfg, times, values)
+		sortSegments()
+		counts := make(map[logstats.Kind]int)
+		for _, s := range segments {
+			counts[s.Kind]++
+		}
+		summary.SetText(fmt.Sprintf("%s log, %d segments: %d WOT, %d cruise, %d idle, %d fuel cut", ecu, len(segments),
+			counts[logstats.KindWOT], counts[logstats.KindCruise], counts[logstats.KindIdle], counts[logstats.KindFuelCut]))
+		details.SetText("Select a segment to show channel statistics")
+		table.UnselectAll()
+		table.Refresh()
+	}
+
+	thresholds := widget.NewMultiLineEntry()
+	thresholds.SetMinRowsVisible(2)
+	lines := make([]string, len(cfg.Thresholds))
+	for i, t := range cfg.Thresholds {
+		lines[i] = t.String()
+	}
+	thresholds.SetText(strings.Join(lines, "\n"))
+	thresholds.SetPlaceHolder("In.p_AirInlet > 1.2")
+
+	applyBtn := widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), func() {
+		t, err := logstats.ParseThresholds(strings.Split(thresholds.Text, "\n"))
+		if err != nil {
+			mw.Error(err)
+			return
+		}
+		cfg.Thresholds = t
+		analyze()
+	})
+
+	analyze()
+
+	content := container.NewBorder(
+		container.NewVBox(
+			summary,
+			container.NewBorder(nil, nil, widget.NewLabel("Time above"), applyBtn, thresholds),
+		),
+		nil,
+		nil,
+		nil,
+		container.NewVSplit(
+			table,
+			container.NewVScroll(details),
+		),
+	)
+
+	inner := multiwindow.NewInnerWindow(title, content)
+	inner.Icon = theme.ListIcon()
+	mw.wm.Add(inner)
+	inner.Resize(fyne.Size{Width: 900, Height: 520})
+}
@@ -0,0 +1,41 @@
+package windows
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+// addLogMarker writes a marker to the log being recorded, bound to Ctrl-M
+func (mw *MainWindow) addLogMarker() {
+	dlc := mw.dlc
+	if dlc == nil {
+		mw.Log("not logging, marker not added")
+		return
+	}
+	text := "Marker " + time.Now().Format("15:04:05")
+	if err := dlc.AddMarker(text); err != nil {
+		mw.Error(fmt.Errorf("failed to add marker: %w", err))
+		return
+	}
+	mw.Log("added " + text)
+}
+
+// saveMarkers returns a func storing markers edited in a logplayer back in the log file.
+// Logs not opened from a file on disk can't be updated
+func (mw *MainWindow) saveMarkers(filename string) func([]logfile.Marker) {
+	return func(markers []logfile.Marker) {
+		if _, err := os.Stat(filename); err != nil || !filepath.IsAbs(filename) {
+			mw.Log("markers not saved, " + filepath.Base(filename) + " was not opened from a file")
+			return
+		}
+		if err := logfile.SaveMarkers(filename, markers); err != nil {
+			mw.Error(fmt.Errorf("failed to save markers: %w", err))
+			return
+		}
+		mw.Log(fmt.Sprintf("saved %d markers to %s", len(markers), filename))
+	}
+}
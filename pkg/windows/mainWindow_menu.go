@@ -148,6 +148,9 @@ func (mw *MainWindow) setupMenu() {
 			mapWindow.Icon = theme.GridIcon()
 			mw.wm.Add(mapWindow)
 		},
+		"Autotune": func(str string) {
+			mw.openAutotune()
+		},
 		"Pgm_status": func(str string) {
 			if w := mw.wm.HasWindow("Pgm_status"); w != nil {
 				return
@@ -177,17 +180,20 @@ func (mw *MainWindow) setupMenu() {
 				mw.wm.Add(inner)
 			}),
 			fyne.NewMenuItemWithIcon("Open binary", theme.DocumentIcon(), mw.loadBinary),
+			fyne.NewMenuItemWithIcon("Compare binaries", theme.ContentCopyIcon(), mw.openCompare),
+			fyne.NewMenuItemWithIcon("Change journal", theme.HistoryIcon(), mw.openJournal),
 			fyne.NewMenuItemWithIcon("Open log", theme.DocumentIcon(), func() {
 				cb := func(r fyne.URIReadCloser) {
 					defer r.Close()
-					filename := r.URI().Name()
+					filename := r.URI().Path()
 					mw.Log("opening logfile " + filename)
 					sz := mw.Window.Content().Size()
 					p := fyne.NewPos(sz.Width/2, sz.Height/2)
 					mw.LoadLogfile(filename, r, p)
 				}
-				widgets.SelectFile(cb, "Log file", "csv", "t5l", "t7l", "t8l")
+				widgets.SelectFile(cb, "Log file", "csv", "t5l", "t7l", "t8l", "txb")
 			}),
+			fyne.NewMenuItemWithIcon("Simulate ECU from log", theme.MediaPlayIcon(), mw.openSimulator),
 			fyne.NewMenuItemWithIcon("Open log folder", theme.FolderIcon(), func() {
 				var cmd *exec.Cmd
 				switch runtime.GOOS {
@@ -253,21 +259,24 @@ func (mw *MainWindow) loadBinary() {
 var openMapLock sync.Mutex
 
 func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string) {
+	mw.openMapViewer(typ, title, mapName)
+}
+
+// openMapViewer opens or raises the map window and returns its viewer, nil on failure
+func (mw *MainWindow) openMapViewer(typ symbol.ECUType, title string, mapName string) *mapviewer.MapViewer {
 	if mw.fw == nil {
 		mw.Error(fmt.Errorf("no binary loaded"))
-		return
+		return nil
 	}
 
 	axis := symbol.GetInfo(typ, mapName)
 
-	windowName := axis.Z
-	if title != "" {
-		windowName += " - " + title
-	}
+	windowName := axis.Z + " - " + axis.ZDescription
 
 	if w := mw.wm.HasWindow(windowName); w != nil {
 		mw.wm.Raise(w)
-		return
+		mv, _ := w.Content().(*mapviewer.MapViewer)
+		return mv
 	}
 
 	symX := mw.fw.GetByName(axis.X)
@@ -288,7 +297,7 @@ func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string)
 
 	if symZ == nil {
 		mw.Error(fmt.Errorf("failed to find symbol %s", axis.Z))
-		return
+		return nil
 	}
 
 	var xData, yData, zData []float64
@@ -309,7 +318,7 @@ func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string)
 				kyltempTab := mw.fw.GetByName("Kyltemp_tab!")
 				if kyltempSteg == nil || kyltempTab == nil {
 					mw.Error(fmt.Errorf("missing coolant temperature symbols"))
-					return
+					return nil
 				}
 				realTemp := LookupCoolantTemperature(val, kyltempSteg.Ints(), kyltempTab.Ints())
 				yData[idx] = float64(realTemp)
@@ -363,6 +372,11 @@ func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string)
 				mw.Error(err)
 				return
 			}
+			cells := make([]int, len(value))
+			for i := range cells {
+				cells[i] = idx + i
+			}
+			mw.journal.MarkECU(axis.Z, cells)
 			//mw.Log(fmt.Sprintf("set $%d %s %s", addr, axis.Z, time.Since(start).Truncate(10*time.Millisecond)))
 			mw.Log(fmt.Sprintf("set %s $%X %dms", axis.Z, addr+uint32(idx*dataLen), time.Since(start).Truncate(10*time.Millisecond).Milliseconds()))
 		}
@@ -417,6 +431,7 @@ func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string)
 			return
 		}
 		buff.Reset()
+		mw.journal.MarkECU(axis.Z, nil)
 
 		//mw.Log(fmt.Sprintf("save %s %s", axis.Z, time.Since(start).Truncate(10*time.Millisecond)))
 		mw.Log(fmt.Sprintf("save %s %s", axis.Z, time.Since(start).Truncate(10*time.Millisecond)))
@@ -447,6 +462,7 @@ func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string)
 			mw.Error(err)
 			return
 		}
+		mw.journal.MarkFile(axis.Z, nil)
 		mw.Log(fmt.Sprintf("Saved %s", axis.Z))
 	}
 
@@ -481,6 +497,10 @@ func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string)
 		LoadECUFunc:  loadRamFunc,
 		SaveECUFunc:  saveRamFunc,
 		OnUpdateCell: updateFunc,
+		OnError:      mw.Error,
+		OnEdit: func(action string, cells []int, old, new []float64) {
+			mw.journal.Add(axis.Z, len(xData), action, cells, old, new)
+		},
 
 		MeshView:              mw.settings.GetMeshView(),
 		Editable:              true,
@@ -536,7 +556,7 @@ func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string)
 	mv, err := mapviewer.New(cfg)
 	if err != nil {
 		mw.Error(err)
-		return
+		return nil
 	}
 
 	if mw.settings.GetAutoLoad() && mw.dlc != nil {
@@ -550,7 +570,7 @@ func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string)
 		}()
 	}
 
-	mapWindow := multiwindow.NewInnerWindow(axis.Z+" - "+axis.ZDescription, mv)
+	mapWindow := multiwindow.NewInnerWindow(windowName, mv)
 	mapWindow.Icon = theme.GridIcon()
 
 	cfg.OnMouseDown = func() {
@@ -582,6 +602,7 @@ func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string)
 	}
 
 	mw.wm.Add(mapWindow)
+	return mv
 }
 
 func LookupCoolantTemperature(axisvalue int, kyltempSteg, kyltempTab []int) int {
@@ -0,0 +1,39 @@
+package windows
+
+// Methods used to control the main window from outside, must be called from the fyne main thread
+
+// Status is a snapshot of the main window state
+type Status struct {
+	Logging  bool   `json:"logging"`
+	ECU      string `json:"ecu"`
+	Binfile  string `json:"binfile"`
+	Logfile  string `json:"logfile"`
+	Symbols  int    `json:"symbols"`
+	Captured int    `json:"captured"`
+	Errors   int    `json:"errors"`
+	Fps      int    `json:"fps"`
+}
+
+// StartLogging starts logging with the current ECU, adapter and symbol list
+func (mw *MainWindow) StartLogging() error {
+	return mw.startLogging()
+}
+
+// StopLogging stops a running logging session
+func (mw *MainWindow) StopLogging() {
+	mw.stopLogging()
+}
+
+// Status returns the current state, logfile and counters are kept from the last logging session
+func (mw *MainWindow) Status() *Status {
+	return &Status{
+		Logging:  mw.loggingRunning,
+		ECU:      mw.selects.ecuSelect.Selected,
+		Binfile:  mw.filename,
+		Logfile:  mw.logFilename,
+		Symbols:  mw.symbolList.Count(),
+		Captured: mw.counters.captured,
+		Errors:   mw.counters.errors,
+		Fps:      mw.counters.fps,
+	}
+}
@@ -0,0 +1,74 @@
+package windows
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/simulator"
+	"github.com/roffe/txlogger/pkg/widgets"
+)
+
+var simulatorSpeeds = []string{"0.25x", "0.5x", "1x", "2x", "4x", "8x"}
+
+func (mw *MainWindow) openSimulator() {
+	if mw.loggingRunning {
+		mw.Error(errors.New("stop logging before starting the ECU simulator"))
+		return
+	}
+	cb := func(r fyne.URIReadCloser) {
+		defer r.Close()
+		filename := r.URI().Name()
+		lf, err := logfile.Open(filename, r)
+		if err != nil {
+			mw.Error(err)
+			return
+		}
+
+		speed := widget.NewSelect(simulatorSpeeds, nil)
+		speed.SetSelected("1x")
+		loop := widget.NewCheck("Restart at end of log", nil)
+		loop.SetChecked(true)
+
+		dialog.ShowForm("Simulate ECU from "+filename, "Start", "Cancel", []*widget.FormItem{
+			widget.NewFormItem("Speed", speed),
+			widget.NewFormItem("", loop),
+		}, func(start bool) {
+			if !start {
+				lf.Close()
+				return
+			}
+			multiplier, err := strconv.ParseFloat(strings.TrimSuffix(speed.Selected, "x"), 64)
+			if err != nil {
+				multiplier = 1
+			}
+			if err := mw.StartSimulator(filename, lf, multiplier, loop.Checked); err != nil {
+				lf.Close()
+				mw.Error(err)
+			}
+		}, mw.Window)
+	}
+	widgets.SelectFile(cb, "Log file", "csv", "t5l", "t7l", "t8l", "txb")
+}
+
+// StartSimulator replays lf into the event bus in place of a live ECU, it is stopped like any other logger.
+// Must be called on the fyne thread
+func (mw *MainWindow) StartSimulator(filename string, lf logfile.Logfile, speed float64, loop bool) error {
+	if mw.loggingRunning {
+		return errors.New("logging is already running")
+	}
+	mw.dlc = simulator.New(lf, &simulator.Config{
+		Speed:          speed,
+		Loop:           loop,
+		OnMessage:      mw.Log,
+		CaptureCounter: mw.setCapturedCounter,
+		FpsCounter:     mw.setFpsCounter,
+	})
+	mw.logFilename = ""
+	mw.runLogger("ECU simulator (" + filename + ")")
+	return nil
+}
@@ -26,6 +26,7 @@ var T5SymbolsTuning = map[string][]string{
 		"Injector scaling|Inj_konst!",
 		"Battery correction map|Batt_korr_tab!",
 		"Fuel cut in overboost|Tryck_vakt_tab!",
+		"Autotune",
 	},
 	"Ignition": {
 		"Ignition normal|Ign_map_0!",
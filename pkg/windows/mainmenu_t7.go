@@ -52,6 +52,7 @@ var T7SymbolsTuning = map[string][]string{
 		"Gas VE map|BFuelCal.GasMap",
 		"Enrichment factor during starting|StartCal.EnrFacTab",
 		"Enrichment factor during starting E85|StartCal.EnrFacE85Tab",
+		"Autotune",
 	},
 	"Ignition": {
 		"Ignition map|IgnNormCal.Map",
@@ -52,6 +52,7 @@ var T8SymbolsTuning = map[string][]string{
 		"Injection end angle map|InjAnglCal.Map",
 		"Jerk enrichment petrol|BFuelCal.m_AirJerkTab",
 		"Jerk enrichment Fuelmaster|BFuelCal.JerkEnrichFacTab",
+		"Autotune",
 		"PurgeCal.ST_PurgeEnable",
 		"LambdaCal.ST_Enable",
 		"FCutCal.ST_Enable",
@@ -1,6 +1,8 @@
 package windows
 
 import (
+	"strconv"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/widget"
 )
@@ -45,3 +47,26 @@ func (mw *MainWindow) createCounters() {
 		}))
 	*/
 }
+
+// counter callbacks for the loggers, safe to call from any goroutine
+
+func (mw *MainWindow) setCapturedCounter(i int) {
+	fyne.Do(func() {
+		mw.counters.captured = i
+		mw.counters.capturedCounterLabel.SetText("Cap: " + strconv.Itoa(i))
+	})
+}
+
+func (mw *MainWindow) setErrorCounter(i int) {
+	fyne.Do(func() {
+		mw.counters.errors = i
+		mw.counters.errorCounterLabel.SetText("Err: " + strconv.Itoa(i))
+	})
+}
+
+func (mw *MainWindow) setFpsCounter(i int) {
+	fyne.Do(func() {
+		mw.counters.fps = i
+		mw.counters.fpsCounterLabel.SetText("Fps: " + strconv.Itoa(i))
+	})
+}
@@ -41,12 +41,17 @@ func (mw *MainWindow) createSelects() {
 		if presetName == "Select preset" {
 			return
 		}
-		preset, err := presets.Get(presetName)
+		preset, err := presets.GetPreset(presetName)
 		if err != nil {
 			mw.Error(err)
 			return
 		}
-		mw.symbolList.LoadSymbols(preset...)
+		if len(preset.Channels) > 0 {
+			if err := mw.settings.SetDerivedChannels(preset.Channels); err != nil {
+				mw.Error(err)
+			}
+		}
+		mw.symbolList.LoadSymbols(preset.Symbols...)
 		mw.SyncSymbols()
 		ecu := mw.app.Preferences().String(prefsSelectedECU)
 		mw.app.Preferences().SetString(ecu+prefsSelectedPreset, presetName)
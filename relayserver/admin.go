@@ -0,0 +1,141 @@
+package relayserver
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type clientStats struct {
+	rxBytes    atomic.Uint64
+	txBytes    atomic.Uint64
+	rxMessages atomic.Uint64
+	txMessages atomic.Uint64
+	dropped    atomic.Uint64
+}
+
+// countingConn counts the bytes read and written on the connection
+type countingConn struct {
+	net.Conn
+	stats *clientStats
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.stats.rxBytes.Add(uint64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.stats.txBytes.Add(uint64(n))
+	return n, err
+}
+
+type ClientInfo struct {
+	Addr       string    `json:"addr"`
+	Role       string    `json:"role"`
+	Owner      bool      `json:"owner"`
+	Connected  time.Time `json:"connected"`
+	RxBytes    uint64    `json:"rx_bytes"`
+	TxBytes    uint64    `json:"tx_bytes"`
+	RxMessages uint64    `json:"rx_messages"`
+	TxMessages uint64    `json:"tx_messages"`
+	Dropped    uint64    `json:"dropped"`
+	// average throughput since connect in bytes per second
+	RxRate float64 `json:"rx_rate"`
+	TxRate float64 `json:"tx_rate"`
+}
+
+type SessionInfo struct {
+	ID           string       `json:"id"`
+	Created      time.Time    `json:"created"`
+	LastActivity time.Time    `json:"last_activity"`
+	Clients      []ClientInfo `json:"clients"`
+}
+
+// SessionInfos returns a snapshot of all sessions and their clients
+func (s *Server) SessionInfos() []SessionInfo {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	infos := make([]SessionInfo, 0, len(s.Sessions))
+	for _, sess := range s.Sessions {
+		si := SessionInfo{
+			ID:           sess.ID,
+			Created:      sess.Created,
+			LastActivity: sess.LastActivity(),
+		}
+		for _, c := range sess.clients {
+			si.Clients = append(si.Clients, c.info(c == sess.owner))
+		}
+		infos = append(infos, si)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
+
+func (c *Client) info(owner bool) ClientInfo {
+	ci := ClientInfo{
+		Addr:      c.RemoteAddr(),
+		Role:      c.role.String(),
+		Owner:     owner,
+		Connected: c.connected,
+	}
+	if c.stats != nil {
+		ci.RxBytes = c.stats.rxBytes.Load()
+		ci.TxBytes = c.stats.txBytes.Load()
+		ci.RxMessages = c.stats.rxMessages.Load()
+		ci.TxMessages = c.stats.txMessages.Load()
+		ci.Dropped = c.stats.dropped.Load()
+	}
+	if secs := time.Since(c.connected).Seconds(); secs > 0 {
+		ci.RxRate = float64(ci.RxBytes) / secs
+		ci.TxRate = float64(ci.TxBytes) / secs
+	}
+	return ci
+}
+
+func (s *Server) runAdmin() {
+	if s.cfg.AdminToken == "" && !isLoopback(s.cfg.AdminAddr) {
+		log.Printf("admin endpoint not started, %s is not a loopback address and no admin token is set", s.cfg.AdminAddr)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.AdminToken != "" && !tokenEqual(s.cfg.AdminToken, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s.SessionInfos()); err != nil {
+			log.Printf("admin: %v", err)
+		}
+	})
+	log.Println("Admin endpoint listening on", s.cfg.AdminAddr)
+	if err := http.ListenAndServe(s.cfg.AdminAddr, mux); err != nil {
+		log.Printf("admin endpoint error: %v", err)
+	}
+}
+
+// isLoopback reports if the listen address only accepts local connections
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
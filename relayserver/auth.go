@@ -0,0 +1,81 @@
+package relayserver
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	ErrUnknownSession = errors.New("unknown session")
+	ErrInvalidToken   = errors.New("invalid token")
+)
+
+// SessionAuth holds the secrets for one session, the tuner token also grants the viewer role
+type SessionAuth struct {
+	Tuner  string `json:"tuner"`
+	Viewer string `json:"viewer"`
+}
+
+// LoadSessionAuth reads a JSON file mapping session IDs to their secrets
+//
+//	{"1337": {"tuner": "secret", "viewer": "other secret"}}
+func LoadSessionAuth(filename string) (map[string]*SessionAuth, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make(map[string]*SessionAuth)
+	if err := json.Unmarshal(b, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	for id, sa := range sessions {
+		if sa == nil || (sa.Tuner == "" && sa.Viewer == "") {
+			return nil, fmt.Errorf("session %s has no tokens", id)
+		}
+	}
+	return sessions, nil
+}
+
+// LoadTLSConfig loads a certificate and key for the server listener
+func LoadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// authorize returns the role granted for the join request
+func (s *Server) authorize(req JoinRequest) (Role, error) {
+	if s.cfg.Sessions == nil {
+		return req.Role, nil
+	}
+	sa, found := s.cfg.Sessions[req.SessionID]
+	if !found {
+		return RoleViewer, ErrUnknownSession
+	}
+	if tokenEqual(sa.Tuner, req.Token) {
+		return req.Role, nil
+	}
+	if tokenEqual(sa.Viewer, req.Token) {
+		if req.Role != RoleViewer {
+			return RoleViewer, fmt.Errorf("%w for role %s", ErrInvalidToken, req.Role)
+		}
+		return RoleViewer, nil
+	}
+	return RoleViewer, ErrInvalidToken
+}
+
+func tokenEqual(secret, token string) bool {
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(token)) == 1
+}
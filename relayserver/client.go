@@ -1,11 +1,13 @@
 package relayserver
 
 import (
+	"crypto/tls"
 	"encoding/gob"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"slices"
 	"sync"
 	"time"
 
@@ -25,6 +27,13 @@ type Client struct {
 
 	closeOnce sync.Once
 	done      chan struct{}
+	// serializes sendDropOldest while it rearranges the send queue
+	dropMu sync.Mutex
+
+	// server side only
+	role      Role
+	connected time.Time
+	stats     *clientStats
 }
 
 func NewClient(host string) (*Client, error) {
@@ -32,6 +41,36 @@ func NewClient(host string) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newClient(conn), nil
+}
+
+// NewTLSClient connects to a relay server running with TLS enabled
+func NewTLSClient(host string, tlsConfig *tls.Config) (*Client, error) {
+	conn, err := tls.Dial("tcp", host, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn), nil
+}
+
+// newServerClient wraps an accepted connection, traffic is counted for the admin endpoint
+func newServerClient(conn net.Conn) *Client {
+	stats := &clientStats{}
+	conn = &countingConn{Conn: conn, stats: stats}
+	return &Client{
+		conn:        conn,
+		dec:         gob.NewDecoder(conn),
+		enc:         gob.NewEncoder(conn),
+		sendChan:    make(chan Message, 10),
+		recvChan:    make(chan Message, 10),
+		recevierMap: make(map[RelayMessageType]chan Message),
+		done:        make(chan struct{}),
+		connected:   time.Now(),
+		stats:       stats,
+	}
+}
+
+func newClient(conn net.Conn) *Client {
 	client := &Client{
 		conn:        conn,
 		dec:         gob.NewDecoder(conn),
@@ -45,7 +84,7 @@ func NewClient(host string) (*Client, error) {
 	go client.sendHandler()
 	go client.receiveHandler()
 
-	return client, nil
+	return client
 }
 
 func (c *Client) sendHandler() {
@@ -60,6 +99,9 @@ func (c *Client) sendHandler() {
 				log.Println("Error sending message:", err.Error())
 				return
 			}
+			if c.stats != nil {
+				c.stats.txMessages.Add(1)
+			}
 		}
 	}
 
@@ -77,6 +119,9 @@ func (c *Client) receiveHandler() {
 			close(c.recvChan)
 			return
 		}
+		if c.stats != nil {
+			c.stats.rxMessages.Add(1)
+		}
 		c.deliverMessage(msg)
 	}
 }
@@ -108,6 +153,37 @@ func (c *Client) JoinSession(sessionID string) error {
 	return c.Send(joinMsg)
 }
 
+// Join joins a session on a server with authentication enabled and returns the role granted by the server
+func (c *Client) Join(sessionID, token string, role Role, owner bool) (Role, error) {
+	recvChan := c.receiveKindCH(MsgTypeJoinResponse)
+	defer c.cleanup(MsgTypeJoinResponse)
+	err := c.Send(Message{
+		Kind: MsgTypeJoinSession,
+		Body: JoinRequest{
+			SessionID: sessionID,
+			Token:     token,
+			Role:      role,
+			Owner:     owner,
+		},
+	})
+	if err != nil {
+		return RoleViewer, err
+	}
+	select {
+	case msg := <-recvChan:
+		resp, ok := msg.Body.(JoinResponse)
+		if !ok {
+			return RoleViewer, fmt.Errorf("invalid join response data")
+		}
+		if !resp.OK {
+			return RoleViewer, fmt.Errorf("join rejected: %s", resp.Error)
+		}
+		return resp.Role, nil
+	case <-time.After(4 * time.Second):
+		return RoleViewer, fmt.Errorf("timeout waiting for join response")
+	}
+}
+
 func (c *Client) Send(msg Message) error {
 	select {
 	case c.sendChan <- msg:
@@ -117,6 +193,44 @@ func (c *Client) Send(msg Message) error {
 	}
 }
 
+// sendDropOldest queues log data and discards the oldest queued log data if the client is not keeping up.
+// Other messages are never discarded, if only those are queued the new log data is dropped instead
+func (c *Client) sendDropOldest(msg Message) {
+	c.dropMu.Lock()
+	defer c.dropMu.Unlock()
+	select {
+	case c.sendChan <- msg:
+		return
+	default:
+	}
+
+	queued := make([]Message, 0, cap(c.sendChan)+1)
+	for n := len(c.sendChan); n > 0; n-- {
+		select {
+		case m := <-c.sendChan:
+			queued = append(queued, m)
+		default:
+		}
+	}
+	idx := slices.IndexFunc(queued, func(m Message) bool {
+		return m.Kind == MsgTypeData
+	})
+	if idx >= 0 {
+		queued = slices.Delete(queued, idx, idx+1)
+		queued = append(queued, msg)
+	}
+	if c.stats != nil {
+		c.stats.dropped.Add(1)
+	}
+	for _, m := range queued {
+		select {
+		case c.sendChan <- m:
+		case <-c.done:
+			return
+		}
+	}
+}
+
 func (c *Client) SendReadResponse(data []byte) error {
 	msg := Message{
 		Kind: MsgTypeReadResponse,
@@ -253,6 +367,10 @@ func (c *Client) receiveKindCH(kind RelayMessageType) chan Message {
 	return recvChan
 }
 
+func (c *Client) RemoteAddr() string {
+	return c.conn.RemoteAddr().String()
+}
+
 func (c *Client) Close() error {
 	c.closeOnce.Do(func() {
 		close(c.done)
@@ -1,18 +1,69 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"time"
 
 	"github.com/roffe/txlogger/relayserver"
 )
 
+var (
+	listenAddr   string
+	sessionsFile string
+	tlsCert      string
+	tlsKey       string
+	maxClients   int
+	idleTimeout  time.Duration
+	adminAddr    string
+	adminToken   string
+)
+
 func init() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	flag.StringVar(&listenAddr, "listen", ":9000", "listen address")
+	flag.StringVar(&sessionsFile, "sessions", "", "JSON file with per session tuner & viewer tokens, authentication is disabled if not set")
+	flag.StringVar(&tlsCert, "tls-cert", "", "TLS certificate file")
+	flag.StringVar(&tlsKey, "tls-key", "", "TLS key file")
+	flag.IntVar(&maxClients, "max-clients", 0, "max clients per session, 0 for no limit")
+	flag.DurationVar(&idleTimeout, "idle-timeout", 30*time.Minute, "close sessions without activity for at least 1s, 0 to disable")
+	flag.StringVar(&adminAddr, "admin", "", "admin HTTP listen address, e.g. 127.0.0.1:9001")
+	flag.StringVar(&adminToken, "admin-token", "", "bearer token required by the admin endpoint, without it the endpoint only listens on loopback addresses")
+	flag.Parse()
 }
 
 func main() {
-	server := relayserver.New()
-	if err := server.Run(":9000"); err != nil {
+	if idleTimeout != 0 && idleTimeout < time.Second {
+		log.Fatalf("invalid -idle-timeout %s, use 0 to disable or at least 1s", idleTimeout)
+	}
+
+	cfg := &relayserver.Config{
+		MaxClients:  maxClients,
+		IdleTimeout: idleTimeout,
+		AdminAddr:   adminAddr,
+		AdminToken:  adminToken,
+	}
+
+	if sessionsFile != "" {
+		sessions, err := relayserver.LoadSessionAuth(sessionsFile)
+		if err != nil {
+			log.Fatalf("failed to load sessions: %v", err)
+		}
+		cfg.Sessions = sessions
+	} else {
+		log.Println("warning: no sessions file, anyone can join any session as tuner")
+	}
+
+	if tlsCert != "" || tlsKey != "" {
+		tlsConfig, err := relayserver.LoadTLSConfig(tlsCert, tlsKey)
+		if err != nil {
+			log.Fatalf("failed to load TLS certificate: %v", err)
+		}
+		cfg.TLSConfig = tlsConfig
+	}
+
+	server := relayserver.New(cfg)
+	if err := server.Run(listenAddr); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
@@ -14,6 +14,7 @@ const (
 	MsgTypeWriteResponse
 	MsgTypeSymbolListRequest
 	MsgTypeSymbolListResponse
+	MsgTypeJoinResponse
 )
 
 func (rmt RelayMessageType) String() string {
@@ -36,6 +37,8 @@ func (rmt RelayMessageType) String() string {
 		return "SymbolListRequest"
 	case MsgTypeSymbolListResponse:
 		return "SymbolListResponse"
+	case MsgTypeJoinResponse:
+		return "JoinResponse"
 	default:
 		return fmt.Sprintf("Unknown (%d)", rmt)
 	}
@@ -50,6 +53,45 @@ func (m *Message) String() string {
 	return fmt.Sprintf("#%d: %q", m.Kind, m.Body)
 }
 
+// Role decides what a client is allowed to do in a session
+type Role int
+
+const (
+	// RoleViewer only receives MsgTypeData
+	RoleViewer Role = iota
+	// RoleTuner has full access, including RAM read and write requests
+	RoleTuner
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleTuner:
+		return "tuner"
+	default:
+		return fmt.Sprintf("Unknown (%d)", r)
+	}
+}
+
+// JoinRequest is the body of MsgTypeJoinSession, a plain session ID string is
+// still accepted by servers running without authentication
+type JoinRequest struct {
+	SessionID string
+	Token     string
+	Role      Role
+	// Owner is set by the car side logger, everything the owner sends is relayed to
+	// all peers while peers only talk to the owner
+	Owner bool
+}
+
+// JoinResponse is sent by the server as reply to a JoinRequest
+type JoinResponse struct {
+	OK    bool
+	Role  Role
+	Error string
+}
+
 type LogValue struct {
 	Name  string
 	Value float64
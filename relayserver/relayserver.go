@@ -1,10 +1,14 @@
 package relayserver
 
 import (
+	"crypto/tls"
 	"encoding/gob"
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"sync"
+	"time"
 
 	symbol "github.com/roffe/ecusymbol"
 )
@@ -17,17 +21,39 @@ func init() {
 	gob.Register(LogValues{})
 	gob.Register(&DataRequest{})
 	gob.Register([]*symbol.Symbol{})
+	gob.Register(JoinRequest{})
+	gob.Register(JoinResponse{})
+}
 
+type Config struct {
+	// Sessions maps session IDs to their secrets, nil disables authentication
+	Sessions map[string]*SessionAuth
+	// TLSConfig enables TLS on the listener when set
+	TLSConfig *tls.Config
+	// MaxClients per session, 0 means no limit
+	MaxClients int
+	// IdleTimeout closes sessions without any relayed messages, 0 disables expiry
+	IdleTimeout time.Duration
+	// AdminAddr is the listen address of the admin HTTP endpoint, empty disables it
+	AdminAddr string
+	// AdminToken is required as a bearer token by the admin endpoint. Without
+	// a token the endpoint is only started on a loopback address
+	AdminToken string
 }
 
 type Server struct {
-	Sessions  map[string][]*Client
+	Sessions  map[string]*Session
 	sessionMu sync.Mutex
+	cfg       *Config
 }
 
-func New() *Server {
+func New(cfg *Config) *Server {
+	if cfg == nil {
+		cfg = &Config{}
+	}
 	return &Server{
-		Sessions: make(map[string][]*Client),
+		Sessions: make(map[string]*Session),
+		cfg:      cfg,
 	}
 }
 
@@ -35,87 +61,124 @@ func (s *Server) Run(listenAddr string) error {
 	if listenAddr == "" {
 		listenAddr = ":9000"
 	}
-	listener, err := net.Listen("tcp", listenAddr)
+	var listener net.Listener
+	var err error
+	if s.cfg.TLSConfig != nil {
+		listener, err = tls.Listen("tcp", listenAddr, s.cfg.TLSConfig)
+	} else {
+		listener, err = net.Listen("tcp", listenAddr)
+	}
 	if err != nil {
 		log.Fatalf("listen error: %v", err)
 	}
-	log.Println("Server listening on", listenAddr)
+	log.Printf("Server listening on %s (tls: %t, auth: %t)", listenAddr, s.cfg.TLSConfig != nil, s.cfg.Sessions != nil)
+	return s.Serve(listener)
+}
+
+// Serve accepts clients on listener until it is closed
+func (s *Server) Serve(listener net.Listener) error {
+	if s.cfg.IdleTimeout > 0 {
+		go s.expireIdle()
+	}
+
+	if s.cfg.AdminAddr != "" {
+		go s.runAdmin()
+	}
 
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
 			log.Printf("accept error: %v", err)
 			continue
 		}
 		log.Printf("connection from %s", conn.RemoteAddr().String())
-		client := &Client{
-			conn:        conn,
-			dec:         gob.NewDecoder(conn),
-			enc:         gob.NewEncoder(conn),
-			sendChan:    make(chan Message, 10),
-			recvChan:    make(chan Message, 10),
-			recevierMap: make(map[RelayMessageType]chan Message),
-		}
+		client := newServerClient(conn)
 		go client.sendHandler()
 		go client.receiveHandler()
 		go s.handle(client)
 	}
 }
 
-func (s *Server) SendToSession(c *Client, sessionID string, msg Message) {
-	s.sessionMu.Lock()
-	defer s.sessionMu.Unlock()
-	clients, exists := s.Sessions[sessionID]
-	if !exists {
-		log.Printf("No clients in session %s to send message", sessionID)
-		return
-	}
-	for _, client := range clients {
-		if client != c {
-			if err := client.Send(msg); err != nil {
-				log.Printf("Error sending message to client %s: %v", client.conn.RemoteAddr().String(), err)
-			}
-		}
-	}
-}
-
-func (s *Server) AddClient(client *Client, sessionID string) {
-	s.sessionMu.Lock()
-	defer s.sessionMu.Unlock()
-	log.Printf("Adding client to session %s", sessionID)
-	s.Sessions[sessionID] = append(s.Sessions[sessionID], client)
-}
-
-func (s *Server) RemoveClient(client *Client, sessionID string) {
-	s.sessionMu.Lock()
-	defer s.sessionMu.Unlock()
-	clients := s.Sessions[sessionID]
-	for i, c := range clients {
-		if c == client {
-			log.Printf("Removing client from session: %s", sessionID)
-			s.Sessions[sessionID] = append(clients[:i], clients[i+1:]...)
-			break
-		}
-	}
-	if len(s.Sessions[sessionID]) == 0 {
-		delete(s.Sessions, sessionID)
-	}
-}
-
 func (s *Server) handle(c *Client) {
 	defer log.Println("exit handle()!!")
-	defer c.conn.Close()
+	defer c.Close()
 	var sessionID string
 	for msg := range c.recvChan {
 		switch msg.Kind {
 		case MsgTypeJoinSession:
-			sessId := msg.Body.(string)
-			s.AddClient(c, sessId)
-			defer s.RemoveClient(c, sessId)
-			sessionID = sessId
+			if sessionID != "" {
+				log.Printf("%s already joined session %s", c.RemoteAddr(), sessionID)
+				continue
+			}
+			req, err := s.joinRequest(msg)
+			if err != nil {
+				log.Printf("join from %s rejected: %v", c.RemoteAddr(), err)
+				c.Send(Message{Kind: MsgTypeJoinResponse, Body: JoinResponse{Error: err.Error()}})
+				// give the send handler a chance to deliver the response before closing
+				time.Sleep(100 * time.Millisecond)
+				return
+			}
+			c.role = req.Role
+			_, legacy := msg.Body.(string)
+			err = s.AddClient(c, req.SessionID, req.Owner)
+			if legacy && errors.Is(err, ErrSessionOwned) {
+				// clients without join requests takes ownership if it's free, otherwise they join as peer
+				err = s.AddClient(c, req.SessionID, false)
+			}
+			if err != nil {
+				log.Printf("join from %s rejected: %v", c.RemoteAddr(), err)
+				c.Send(Message{Kind: MsgTypeJoinResponse, Body: JoinResponse{Error: err.Error()}})
+				time.Sleep(100 * time.Millisecond)
+				return
+			}
+			defer s.RemoveClient(c, req.SessionID)
+			sessionID = req.SessionID
+			if !legacy {
+				c.Send(Message{Kind: MsgTypeJoinResponse, Body: JoinResponse{OK: true, Role: req.Role}})
+			}
 		default:
-			//log.Printf("Received %s from %s", msg.Kind.String(), c.conn.RemoteAddr().String())
+			if sessionID == "" {
+				log.Printf("dropping %s from %s, not in a session", msg.Kind.String(), c.RemoteAddr())
+				continue
+			}
+			if c.role == RoleViewer {
+				log.Printf("dropping %s from viewer %s", msg.Kind.String(), c.RemoteAddr())
+				continue
+			}
+			//log.Printf("Received %s from %s", msg.Kind.String(), c.RemoteAddr())
 			s.SendToSession(c, sessionID, msg)
 		}
 	}
 }
+
+// joinRequest validates a join message and returns the request with the granted role
+func (s *Server) joinRequest(msg Message) (JoinRequest, error) {
+	var req JoinRequest
+	switch body := msg.Body.(type) {
+	case string:
+		// clients that predates authentication only sends the session ID
+		if s.cfg.Sessions != nil {
+			return req, ErrInvalidToken
+		}
+		req = JoinRequest{SessionID: body, Role: RoleTuner, Owner: true}
+	case JoinRequest:
+		req = body
+	default:
+		return req, fmt.Errorf("invalid join request %T", msg.Body)
+	}
+	if req.SessionID == "" {
+		return req, ErrUnknownSession
+	}
+	if req.Owner && req.Role != RoleTuner {
+		return req, fmt.Errorf("only tuners can own a session")
+	}
+	role, err := s.authorize(req)
+	if err != nil {
+		return req, err
+	}
+	req.Role = role
+	return req, nil
+}
@@ -0,0 +1,84 @@
+package relayserver
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func startServer(t *testing.T, cfg *Config) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go New(cfg).Serve(l)
+	return l.Addr().String()
+}
+
+func dial(t *testing.T, addr string) *Client {
+	t.Helper()
+	c, err := NewClient(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestViewerReceivesData(t *testing.T) {
+	addr := startServer(t, &Config{
+		Sessions: map[string]*SessionAuth{"1337": {Tuner: "tuner", Viewer: "viewer"}},
+	})
+
+	owner := dial(t, addr)
+	if _, err := owner.Join("1337", "tuner", RoleTuner, true); err != nil {
+		t.Fatalf("owner join: %v", err)
+	}
+	viewer := dial(t, addr)
+	role, err := viewer.Join("1337", "viewer", RoleViewer, false)
+	if err != nil {
+		t.Fatalf("viewer join: %v", err)
+	}
+	if role != RoleViewer {
+		t.Fatalf("granted %s, want %s", role, RoleViewer)
+	}
+
+	// requests from viewers are dropped and never reaches the owner
+	if err := viewer.Send(Message{Kind: MsgTypeSymbolListRequest}); err != nil {
+		t.Fatal(err)
+	}
+	// replies from the owner are not relayed to viewers
+	if err := owner.Send(Message{Kind: MsgTypeSymbolListResponse}); err != nil {
+		t.Fatal(err)
+	}
+	want := LogValues{{Name: "rpm", Value: 3000}, {Name: "Lambda.External", Value: 0.85}}
+	if err := owner.Send(Message{Kind: MsgTypeData, Body: want}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-viewer.Ch():
+		if msg.Kind != MsgTypeData {
+			t.Fatalf("viewer got %s, want %s", msg.Kind, MsgTypeData)
+		}
+		got, ok := msg.Body.(LogValues)
+		if !ok || len(got) != len(want) {
+			t.Fatalf("viewer got %v, want %v", msg.Body, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("value %d: got %v, want %v", i, got[i], want[i])
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for data")
+	}
+
+	select {
+	case msg := <-owner.Ch():
+		t.Errorf("owner got %s from viewer", msg.Kind)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
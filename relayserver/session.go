@@ -0,0 +1,153 @@
+package relayserver
+
+import (
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrSessionFull  = errors.New("session is full")
+	ErrSessionOwned = errors.New("session already has an owner")
+)
+
+// Session is a group of clients relaying to each other. The owner is the car side logger,
+// everything it sends goes to all peers while messages from peers only goes to the owner
+type Session struct {
+	ID      string
+	Created time.Time
+
+	owner   *Client
+	clients []*Client
+
+	// unix nano of the last message relayed in the session
+	lastActivity atomic.Int64
+}
+
+func newSession(id string) *Session {
+	sess := &Session{
+		ID:      id,
+		Created: time.Now(),
+	}
+	sess.touch()
+	return sess
+}
+
+func (sess *Session) touch() {
+	sess.lastActivity.Store(time.Now().UnixNano())
+}
+
+func (sess *Session) LastActivity() time.Time {
+	return time.Unix(0, sess.lastActivity.Load())
+}
+
+// AddClient adds a client to the session, the session is created if it does not exist
+func (s *Server) AddClient(client *Client, sessionID string, owner bool) error {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	sess, found := s.Sessions[sessionID]
+	if !found {
+		sess = newSession(sessionID)
+	}
+	if s.cfg.MaxClients > 0 && len(sess.clients) >= s.cfg.MaxClients {
+		return ErrSessionFull
+	}
+	if owner {
+		if sess.owner != nil {
+			return ErrSessionOwned
+		}
+		sess.owner = client
+	}
+	sess.clients = append(sess.clients, client)
+	sess.touch()
+	s.Sessions[sessionID] = sess
+	log.Printf("Adding %s %s (owner: %t) to session %s", client.role, client.RemoteAddr(), owner, sessionID)
+	return nil
+}
+
+func (s *Server) RemoveClient(client *Client, sessionID string) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	sess, found := s.Sessions[sessionID]
+	if !found {
+		return
+	}
+	for i, c := range sess.clients {
+		if c == client {
+			log.Printf("Removing %s from session: %s", client.RemoteAddr(), sessionID)
+			sess.clients = append(sess.clients[:i], sess.clients[i+1:]...)
+			break
+		}
+	}
+	if sess.owner == client {
+		log.Printf("Owner left session %s", sessionID)
+		sess.owner = nil
+	}
+	if len(sess.clients) == 0 {
+		delete(s.Sessions, sessionID)
+	}
+}
+
+func (s *Server) SendToSession(c *Client, sessionID string, msg Message) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	sess, exists := s.Sessions[sessionID]
+	if !exists {
+		log.Printf("No clients in session %s to send message", sessionID)
+		return
+	}
+	sess.touch()
+
+	// peers only talk to the owner
+	if sess.owner != c {
+		if sess.owner == nil {
+			return
+		}
+		s.sendTo(sess.owner, msg)
+		return
+	}
+
+	for _, client := range sess.clients {
+		if client == c {
+			continue
+		}
+		// viewers only receive log data
+		if client.role == RoleViewer && msg.Kind != MsgTypeData {
+			continue
+		}
+		s.sendTo(client, msg)
+	}
+}
+
+func (s *Server) sendTo(client *Client, msg Message) {
+	// log data is only useful when it's fresh, make room for it instead of stalling the session on slow clients
+	if msg.Kind == MsgTypeData {
+		client.sendDropOldest(msg)
+		return
+	}
+	if err := client.Send(msg); err != nil {
+		log.Printf("Error sending message to client %s: %v", client.RemoteAddr(), err)
+	}
+}
+
+// expireIdle closes all clients in sessions without activity for the configured idle timeout
+func (s *Server) expireIdle() {
+	ticker := time.NewTicker(min(max(s.cfg.IdleTimeout/2, time.Second), time.Minute))
+	defer ticker.Stop()
+	for range ticker.C {
+		var expired []*Client
+		s.sessionMu.Lock()
+		for id, sess := range s.Sessions {
+			if time.Since(sess.LastActivity()) > s.cfg.IdleTimeout {
+				log.Printf("Session %s idle since %s, closing", id, sess.LastActivity().Format(time.RFC3339))
+				expired = append(expired, sess.clients...)
+			}
+		}
+		s.sessionMu.Unlock()
+		// closing the connection makes the handler remove the client from the session
+		for _, c := range expired {
+			c.Close()
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/ecu"
+	"github.com/roffe/txlogger/pkg/ecu/t7"
+)
+
+type command struct {
+	name    []string
+	usage   string
+	help    string
+	args    int
+	timeout time.Duration
+	run     func(ctx context.Context, tr ecu.Client, args []string, out *output) error
+}
+
+var commands = []command{
+	{[]string{"info"}, "info", "print ECU information", 0, 30 * time.Second, runInfo},
+	{[]string{"dump"}, "dump <file>", "read the flash to file", 1, 20 * time.Minute, runDump},
+	{[]string{"flash"}, "flash <file>", "write file to the flash", 1, 30 * time.Minute, runFlash},
+	{[]string{"erase"}, "erase", "erase the flash", 0, 5 * time.Minute, runErase},
+	{[]string{"dtc", "read"}, "dtc read", "read diagnostic trouble codes", 0, 30 * time.Second, runReadDTC},
+	{[]string{"dtc", "clear"}, "dtc clear", "clear diagnostic trouble codes", 0, 30 * time.Second, runClearDTC},
+	{[]string{"reset"}, "reset", "reset the ECU", 0, 30 * time.Second, runReset},
+}
+
+// findCommand matches the leading arguments against the command names and returns the remaining arguments
+func findCommand(args []string) (*command, []string, error) {
+	if len(args) == 0 {
+		return nil, nil, errors.New("no command given")
+	}
+	for i, c := range commands {
+		if len(args) >= len(c.name) && strings.EqualFold(strings.Join(args[:len(c.name)], " "), strings.Join(c.name, " ")) {
+			return &commands[i], args[len(c.name):], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("unknown command %q", strings.Join(args, " "))
+}
+
+type infoResult struct {
+	Desc  string `json:"desc"`
+	Value string `json:"value"`
+}
+
+func runInfo(ctx context.Context, tr ecu.Client, _ []string, out *output) error {
+	values, err := tr.Info(ctx)
+	if err != nil {
+		return err
+	}
+	result := make([]infoResult, len(values))
+	lines := make([]string, len(values))
+	for i, v := range values {
+		result[i] = infoResult{Desc: v.Desc, Value: v.Value}
+		lines[i] = v.String()
+	}
+	out.Result(result, strings.Join(lines, "\n"))
+	return reset(ctx, tr, out)
+}
+
+type fileResult struct {
+	File string `json:"file"`
+	Size int    `json:"size"`
+	MD5  string `json:"md5"`
+}
+
+func newFileResult(filename string, data []byte) fileResult {
+	sum := md5.Sum(data)
+	return fileResult{File: filename, Size: len(data), MD5: hex.EncodeToString(sum[:])}
+}
+
+func runDump(ctx context.Context, tr ecu.Client, args []string, out *output) error {
+	filename := args[0]
+	bin, err := tr.DumpECU(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, bin, 0644); err != nil {
+		return err
+	}
+	r := newFileResult(filename, bin)
+	out.Result(r, fmt.Sprintf("Saved %d bytes as %s, md5 %s", r.Size, r.File, r.MD5))
+	return reset(ctx, tr, out)
+}
+
+func runFlash(ctx context.Context, tr ecu.Client, args []string, out *output) error {
+	filename := args[0]
+	bin, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if _, ok := tr.(*t7.Client); ok {
+		if err := t7.VerifyChecksum(bin); err != nil {
+			if !errors.Is(err, t7.ErrChecksumMismatch) || !*fixChecksum {
+				return fmt.Errorf("%w, use -fix-checksum to correct them", err)
+			}
+			if err := t7.FixChecksum(bin); err != nil {
+				return err
+			}
+			out.Message("Checksums corrected")
+		} else {
+			out.Message("Checksums OK")
+		}
+	}
+	start := time.Now()
+	if err := tr.FlashECU(ctx, bin); err != nil {
+		return err
+	}
+	r := newFileResult(filename, bin)
+	out.Result(r, fmt.Sprintf("Flashed %s in %s", r.File, time.Since(start).Round(time.Second)))
+	time.Sleep(200 * time.Millisecond)
+	return reset(ctx, tr, out)
+}
+
+func runErase(ctx context.Context, tr ecu.Client, _ []string, out *output) error {
+	if err := tr.EraseECU(ctx); err != nil {
+		return err
+	}
+	out.Result(struct{}{}, "Flash erased")
+	return reset(ctx, tr, out)
+}
+
+type dtcResult struct {
+	Code        string `json:"code"`
+	Status      byte   `json:"status"`
+	StatusText  string `json:"status_text,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func runReadDTC(ctx context.Context, tr ecu.Client, _ []string, out *output) error {
+	dtcs, err := tr.ReadDTC(ctx)
+	if err != nil {
+		return err
+	}
+	result := make([]dtcResult, len(dtcs))
+	var b strings.Builder
+	for i, d := range dtcs {
+		info := d.Info()
+		result[i] = dtcResult{
+			Code:        d.String(),
+			Status:      d.Status,
+			StatusText:  d.StatusString(),
+			Name:        info.Name,
+			Description: info.Description,
+		}
+		b.WriteString(d.String())
+		if info.Name != "" {
+			b.WriteString(" - " + info.Name)
+		}
+		if status := d.StatusString(); status != "" {
+			b.WriteString("\n  " + status)
+		}
+		b.WriteString("\n")
+	}
+	text := strings.TrimSuffix(b.String(), "\n")
+	if len(dtcs) == 0 {
+		text = "No DTCs found"
+	}
+	out.Result(result, text)
+	return nil
+}
+
+func runClearDTC(ctx context.Context, tr ecu.Client, _ []string, out *output) error {
+	if err := tr.ClearDTC(ctx); err != nil {
+		return err
+	}
+	out.Result(struct{}{}, "DTCs cleared")
+	return nil
+}
+
+func runReset(ctx context.Context, tr ecu.Client, _ []string, out *output) error {
+	if err := tr.ResetECU(ctx); err != nil {
+		return err
+	}
+	out.Result(struct{}{}, "ECU reset")
+	return nil
+}
+
+// reset leaves the bootloader after a command that may have started it
+func reset(ctx context.Context, tr ecu.Client, out *output) error {
+	if err := tr.ResetECU(ctx); err != nil {
+		return fmt.Errorf("reset failed: %w", err)
+	}
+	out.Message("ECU reset")
+	return nil
+}
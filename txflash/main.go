@@ -0,0 +1,274 @@
+// txflash reads, writes and diagnoses ECUs from the command line using the
+// same ECU drivers as the txlogger CAN flasher
+//
+//	txflash -adapter CANUSB -port COM3 -ecu T7 dump t7.bin
+//	txflash -adapter "txbridge wifi" -ecu T8 -json flash t8.bin
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/cangw"
+	"github.com/roffe/txlogger/pkg/ecu"
+	"github.com/roffe/txlogger/pkg/mdns"
+
+	// Import ecu packages
+	_ "github.com/roffe/txlogger/pkg/ecu/t5"
+	_ "github.com/roffe/txlogger/pkg/ecu/t5legion"
+	_ "github.com/roffe/txlogger/pkg/ecu/t7"
+	_ "github.com/roffe/txlogger/pkg/ecu/t8"
+	_ "github.com/roffe/txlogger/pkg/ecu/t8mcp"
+	_ "github.com/roffe/txlogger/pkg/ecu/z22se"
+	_ "github.com/roffe/txlogger/pkg/ecu/z22semcp"
+)
+
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+)
+
+var (
+	adapterName  = flag.String("adapter", "", "CANbus adapter name, use -adapters to list available adapters")
+	port         = flag.String("port", "", "serial port for adapters that requires it")
+	baud         = flag.Int("baud", 1000000, "serial port speed")
+	ecuType      = flag.String("ecu", "", "ECU type, T5, T7, T8 or a name from -ecus")
+	jsonOutput   = flag.Bool("json", false, "write messages, progress and the result as JSON lines")
+	canDebug     = flag.Bool("candebug", false, "enable adapter debug output")
+	timeout      = flag.Duration("timeout", 0, "abort after this long, defaults to 30m for flash, 20m for dump and 30s for everything else")
+	boot         = flag.Bool("boot", false, "flash: also write the boot partition (Trionic 8)")
+	nvdm         = flag.Bool("nvdm", false, "flash: also write the NVDM partitions (Trionic 8)")
+	partitions   = flag.String("partitions", "", "flash: comma separated partitions to write (Trionic 8), default all that differs")
+	verify       = flag.String("verify", "md5", "flash/dump: verification, md5 or none")
+	noHighSpeed  = flag.Bool("no-highspeed", false, "don't lower the bootloader inter frame latency")
+	retries      = flag.Uint("retries", 0, "extra attempts added to every retried operation, 0 uses the ECU defaults")
+	retryDelay   = flag.Duration("retry-delay", 0, "delay between retries, 0 uses the ECU default")
+	fixChecksum  = flag.Bool("fix-checksum", false, "flash: correct invalid Trionic 7 checksums instead of aborting")
+	listAdapters = flag.Bool("adapters", false, "list available CANbus adapters and exit")
+	listECUs     = flag.Bool("ecus", false, "list supported ECUs and exit")
+)
+
+var ecuAliases = map[string]string{
+	"T5":    "Trionic 5",
+	"T5L":   "Trionic 5 Legion",
+	"T7":    "Trionic 7",
+	"T8":    "Trionic 8",
+	"T8MCP": "Trionic 8 MCP",
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] <command> [args]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-18s %s\n", c.usage, c.help)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	os.Exit(run())
+}
+
+func run() int {
+	if *listAdapters {
+		for _, a := range gocan.ListAdapters() {
+			fmt.Printf("%-30s serial port: %t\n", a.Name, a.RequiresSerialPort)
+		}
+		return exitOK
+	}
+	if *listECUs {
+		for _, name := range ecu.List() {
+			fmt.Println(name)
+		}
+		return exitOK
+	}
+
+	out := newOutput(*jsonOutput)
+
+	cmd, args, err := findCommand(flag.Args())
+	if err != nil {
+		out.Error(err)
+		flag.Usage()
+		return exitUsage
+	}
+
+	name, err := resolveECU(*ecuType)
+	if err != nil {
+		out.Error(err)
+		return exitUsage
+	}
+	if *adapterName == "" {
+		out.Error(errors.New("no adapter specified, use -adapter"))
+		return exitUsage
+	}
+	opts, err := flashOptions()
+	if err != nil {
+		out.Error(err)
+		return exitUsage
+	}
+	if len(args) != cmd.args {
+		out.Error(fmt.Errorf("usage: %s", cmd.usage))
+		return exitUsage
+	}
+
+	d := cmd.timeout
+	if *timeout > 0 {
+		d = *timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := withECU(ctx, name, opts, out, func(tr ecu.Client) error {
+		return cmd.run(ctx, tr, args, out)
+	}); err != nil {
+		out.Error(err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// resolveECU returns the registry name for a short alias or a registered name in any case
+func resolveECU(s string) (string, error) {
+	if s == "" {
+		return "", errors.New("no ECU specified, use -ecu")
+	}
+	if name, found := ecuAliases[strings.ToUpper(strings.ReplaceAll(s, " ", ""))]; found {
+		return name, nil
+	}
+	for _, name := range ecu.List() {
+		if strings.EqualFold(name, s) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown ECU %q, use -ecus to list supported ECUs", s)
+}
+
+func flashOptions() (ecu.FlashOptions, error) {
+	opts := ecu.FlashOptions{
+		Boot:         *boot,
+		NVDM:         *nvdm,
+		NoHighSpeed:  *noHighSpeed,
+		ExtraRetries: *retries,
+		RetryDelay:   *retryDelay,
+	}
+	switch strings.ToLower(*verify) {
+	case "md5":
+		opts.Verify = ecu.VerifyMD5
+	case "none":
+		opts.Verify = ecu.VerifyNone
+	default:
+		return opts, fmt.Errorf("invalid verify mode %q, expected md5 or none", *verify)
+	}
+	if *partitions != "" {
+		for _, p := range strings.Split(*partitions, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || n < 1 || n > 9 {
+				return opts, fmt.Errorf("invalid partition %q, expected 1-9", p)
+			}
+			opts.Partitions = append(opts.Partitions, n)
+		}
+	}
+	return opts, nil
+}
+
+// withECU opens the adapter and runs fn with a client for the ECU
+func withECU(ctx context.Context, name string, opts ecu.FlashOptions, out *output, fn func(ecu.Client) error) error {
+	if strings.HasPrefix(*adapterName, "J2534") {
+		p, err := cangw.Start()
+		if p != nil {
+			defer p.Kill()
+		}
+		if err != nil {
+			return fmt.Errorf("cangateway is not ready: %w", err)
+		}
+	}
+
+	dev, err := newAdapter(ctx, name, out)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case err := <-dev.Err():
+				if err == nil {
+					return
+				}
+				out.Message("adapter: " + err.Error())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	c, err := gocan.NewWithOpts(ctx, dev)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tr, err := ecu.New(c, &ecu.Config{
+		Name:       name,
+		Flash:      opts,
+		OnProgress: out.Progress,
+		OnMessage:  out.Message,
+		OnError: func(err error) {
+			out.Message(err.Error())
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return fn(tr)
+}
+
+func newAdapter(ctx context.Context, name string, out *output) (gocan.Adapter, error) {
+	cfg := &gocan.AdapterConfig{
+		Port:         *port,
+		PortBaudrate: *baud,
+		CANRate:      ecu.CANRate(name),
+		CANFilter:    ecu.Filters(name),
+		Debug:        *canDebug,
+	}
+
+	if strings.HasPrefix(*adapterName, "J2534") {
+		return gocan.NewGWClient(*adapterName, cfg)
+	}
+
+	if *adapterName == "txbridge wifi" {
+		address := *port
+		if address == "" {
+			qctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			addr, err := mdns.Query(qctx, "txbridge.local")
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve txbridge address via mDNS, use -port host:port: %w", err)
+			}
+			address = fmt.Sprintf("%s:%d", addr.String(), 1337)
+		}
+		out.Message("txbridge address " + address)
+		cfg.AdditionalConfig = map[string]string{
+			"address": address,
+		}
+	}
+	return gocan.NewAdapter(*adapterName, cfg)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// event is one JSON line written in -json mode
+type event struct {
+	Type     string    `json:"type"`
+	Time     string    `json:"time"`
+	Message  string    `json:"message,omitempty"`
+	Progress *progress `json:"progress,omitempty"`
+	Result   any       `json:"result,omitempty"`
+}
+
+type progress struct {
+	Percent int     `json:"percent"`
+	Done    float64 `json:"done"`
+	Total   float64 `json:"total"`
+}
+
+// output prints messages and progress as text on stderr, or as JSON lines on stdout
+type output struct {
+	json bool
+
+	mu      sync.Mutex
+	enc     *json.Encoder
+	total   float64
+	percent int
+	inBar   bool
+}
+
+func newOutput(jsonMode bool) *output {
+	return &output{
+		json:    jsonMode,
+		enc:     json.NewEncoder(os.Stdout),
+		percent: -1,
+	}
+}
+
+func (o *output) write(e event) {
+	e.Time = time.Now().Format(time.RFC3339Nano)
+	_ = o.enc.Encode(e)
+}
+
+// endBar moves past an unfinished progress line before printing text
+func (o *output) endBar() {
+	if o.inBar {
+		fmt.Fprintln(os.Stderr)
+		o.inBar = false
+	}
+}
+
+func (o *output) Message(msg string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.json {
+		o.write(event{Type: "message", Message: msg})
+		return
+	}
+	o.endBar()
+	fmt.Fprintln(os.Stderr, time.Now().Format("15:04:05.000")+" "+msg)
+}
+
+func (o *output) Error(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.json {
+		o.write(event{Type: "error", Message: err.Error()})
+		return
+	}
+	o.endBar()
+	fmt.Fprintln(os.Stderr, "error: "+err.Error())
+}
+
+// Progress follows ecu.Config.OnProgress, a negative value sets the total
+// and positive values are the amount done
+func (o *output) Progress(v float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if v < 0 {
+		o.total = math.Abs(v)
+		o.percent = -1
+		return
+	}
+	if o.total == 0 {
+		return
+	}
+	p := int(min(v/o.total*100, 100))
+	// only report whole percents to keep the output readable
+	if p == o.percent {
+		return
+	}
+	o.percent = p
+	if o.json {
+		o.write(event{Type: "progress", Progress: &progress{Percent: p, Done: v, Total: o.total}})
+		return
+	}
+	const width = 40
+	fill := width * p / 100
+	bar := make([]byte, width)
+	for i := range bar {
+		if i < fill {
+			bar[i] = '#'
+		} else {
+			bar[i] = '.'
+		}
+	}
+	fmt.Fprintf(os.Stderr, "\r[%s] %3d%%", bar, p)
+	o.inBar = p < 100
+	if !o.inBar {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// Result prints the outcome of a command, text is used outside of -json mode
+func (o *output) Result(result any, text string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.json {
+		o.write(event{Type: "result", Result: result})
+		return
+	}
+	o.endBar()
+	if text != "" {
+		fmt.Println(text)
+	}
+}